@@ -0,0 +1,166 @@
+package app
+
+import (
+	"backend/config"
+	"backend/db"
+	"backend/graph"
+	"backend/handlers"
+	"backend/health"
+	"backend/idempotency"
+	"backend/logging"
+	"backend/middleware"
+	"backend/pagination"
+	"backend/services"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// App is the application container. It is built once at startup and holds
+// the configuration together with every long-lived dependency, so requests
+// never re-read configuration or create their own HTTP clients.
+type App struct {
+	Logger      *slog.Logger
+	Supabase    *services.SupabaseClient
+	Games       *services.GameService
+	Users       *services.UserService
+	Subjects    *services.SubjectService
+	Results     *services.ResultService
+	States      *services.GameStateService
+	Handlers    *handlers.Handlers
+	Graph       *graph.Server
+	Cursors     *pagination.Codec // signs the page cursors of every API
+	Readiness   *health.Readiness
+	Idempotency idempotency.Store // responses replayed to retries with the same Idempotency-Key
+	CORS        *middleware.CORSHandler
+
+	logLevel *slog.LevelVar
+	database *sql.DB
+
+	mu     sync.RWMutex
+	config config.Config
+}
+
+// New builds the container from a loaded and validated configuration
+func New(cfg config.Config) (*App, error) {
+	a := &App{
+		Supabase:    services.NewSupabaseClient(cfg),
+		CORS:        middleware.NewCORSHandler(cfg.CORS),
+		Idempotency: idempotency.NewMemoryStore(),
+		logLevel:    new(slog.LevelVar),
+		config:      cfg,
+	}
+	a.logLevel.Set(logging.ParseLevel(cfg.Log.Level))
+	a.Logger = logging.NewWithLevel(os.Stdout, cfg.Log, a.logLevel)
+
+	a.Games = services.NewGameService(a.Supabase)
+	a.Users = services.NewUserService(a.Supabase)
+	a.Subjects = services.NewSubjectService(a.Supabase)
+	a.Results = services.NewResultService(a.Supabase)
+	a.States = services.NewGameStateService(a.Supabase)
+	a.Cursors = pagination.NewCodec(cursorSecret(cfg))
+	pages := handlers.NewPaginator(a.Cursors, cfg.Pagination.DefaultLimit, cfg.Pagination.MaxLimit)
+	a.Handlers = handlers.New(a.Games, a.Users, a.Subjects, a.Results, a.Games, a.Games, pages)
+
+	// GraphQL shares the services and the cursor key of the REST endpoints
+	graphServer, err := graph.NewServer(
+		graph.Services{Users: a.Users, Games: a.Games, Subjects: a.Subjects, States: a.States, Results: a.Results},
+		a.Cursors,
+		graph.Options{
+			Limits:          graph.Limits{MaxDepth: cfg.GraphQL.MaxDepth, MaxComplexity: cfg.GraphQL.MaxComplexity},
+			DefaultPageSize: cfg.Pagination.DefaultLimit,
+			MaxPageSize:     cfg.Pagination.MaxLimit,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	a.Graph = graphServer
+
+	// Readiness checks cover Supabase, the client's circuit breaker and, in direct-Postgres mode, the database
+	a.Readiness = health.NewReadiness(cfg.Health.CheckTimeout, cfg.Health.CacheTTL, health.SupabaseChecks(cfg.SupabaseURL, cfg.SupabaseKey)...)
+	a.Readiness.Register(health.CheckFunc{CheckName: "supabase_circuit", Func: a.checkBreaker})
+	if cfg.DatabaseURL != "" {
+		database, err := db.Open(cfg.DatabaseURL)
+		if err != nil {
+			return nil, err
+		}
+		a.database = database
+		a.Readiness.Register(health.DatabaseCheck("database", database))
+	}
+
+	return a, nil
+}
+
+// cursorSecret returns the key that signs page cursors, derived from the JWT
+// secret when none is configured so cursors stay valid across restarts
+func cursorSecret(cfg config.Config) string {
+	if cfg.Pagination.CursorSecret != "" {
+		return cfg.Pagination.CursorSecret
+	}
+	mac := hmac.New(sha256.New, []byte(cfg.JWTSecret))
+	mac.Write([]byte("pagination cursor"))
+	return string(mac.Sum(nil))
+}
+
+// checkBreaker fails while the Supabase circuit breaker is open
+func (a *App) checkBreaker(ctx context.Context) error {
+	if a.Supabase.Breaker().State() == services.BreakerOpen {
+		return services.ErrCircuitOpen
+	}
+	return nil
+}
+
+// Config returns the configuration currently in effect
+func (a *App) Config() config.Config {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.config
+}
+
+// Reload applies the settings of cfg that are safe to change while serving:
+// the log level and the CORS policy. Other changed settings are kept at their
+// current value and their keys are returned, since they need a restart.
+func (a *App) Reload(cfg config.Config) (restartRequired []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var applied []string
+	for _, key := range a.config.Changed(cfg) {
+		if reloadable(key) {
+			applied = append(applied, key)
+		} else {
+			restartRequired = append(restartRequired, key)
+		}
+	}
+
+	a.logLevel.Set(logging.ParseLevel(cfg.Log.Level))
+	a.CORS.Reload(cfg.CORS)
+	a.config.Log.Level = cfg.Log.Level
+	a.config.CORS = cfg.CORS
+
+	a.Logger.Info("Configuration reloaded", "applied", applied)
+	if len(restartRequired) > 0 {
+		a.Logger.Warn("Some changed settings only take effect after a restart", "keys", restartRequired)
+	}
+	return restartRequired
+}
+
+// reloadable reports whether the setting key can be changed without a restart
+func reloadable(key string) bool {
+	return key == "log.level" || strings.HasPrefix(key, "cors.")
+}
+
+// Close releases the resources held by the container
+func (a *App) Close() error {
+	a.Supabase.CloseIdleConnections()
+	if a.database != nil {
+		return a.database.Close()
+	}
+	return nil
+}
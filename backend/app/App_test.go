@@ -0,0 +1,46 @@
+package app
+
+import (
+	"backend/config"
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestReloadAppliesSafeSettingsOnly(t *testing.T) {
+	cfg := config.Config{
+		SupabaseURL: "https://example.supabase.co",
+		Server:      config.ServerConfig{Addr: ":8080"},
+		Health:      config.HealthConfig{CheckTimeout: time.Second, CacheTTL: time.Second},
+		Log:         config.LogConfig{Level: "info"},
+		CORS:        config.CORSConfig{AllowedOrigins: []string{"https://old.ilang.dev"}},
+	}
+	a, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	next := cfg
+	next.Log.Level = "debug"
+	next.CORS.AllowedOrigins = []string{"https://new.ilang.dev"}
+	next.Server.Addr = ":9090"
+
+	restart := a.Reload(next)
+
+	if !slices.Equal(restart, []string{"server.addr"}) {
+		t.Errorf("expected only server.addr to need a restart, got %v", restart)
+	}
+	if !a.Logger.Enabled(context.Background(), slog.LevelDebug) {
+		t.Error("expected the log level to change without a restart")
+	}
+	current := a.Config()
+	if current.Server.Addr != ":8080" {
+		t.Errorf("expected server.addr to keep its startup value, got %q", current.Server.Addr)
+	}
+	if current.Log.Level != "debug" || current.CORS.AllowedOrigins[0] != "https://new.ilang.dev" {
+		t.Errorf("expected reloaded settings in Config, got %+v", current)
+	}
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"backend/metrics"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// RunPurge purges the trash every trash.purge_interval until ctx is done. It
+// returns at once when purging is disabled.
+func (a *App) RunPurge(ctx context.Context) {
+	interval := a.Config().Trash.PurgeInterval
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.PurgeTrash(ctx); err != nil && ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Failed to purge the trash", "err", err)
+			}
+		}
+	}
+}
+
+// PurgeTrash deletes the games and users that have been in the trash for
+// longer than trash.retention for good
+func (a *App) PurgeTrash(ctx context.Context) error {
+	cutoff := time.Now().Add(-a.Config().Trash.Retention)
+
+	games, gamesErr := a.Games.PurgeGames(ctx, cutoff)
+	metrics.TrashPurged.WithLabelValues("games").Add(float64(games))
+	users, usersErr := a.Users.PurgeUsers(ctx, cutoff)
+	metrics.TrashPurged.WithLabelValues("users").Add(float64(users))
+
+	if games > 0 || users > 0 {
+		slog.InfoContext(ctx, "Purged the trash", "games", games, "users", users, "cutoff", cutoff)
+	}
+	return errors.Join(gamesErr, usersErr)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"backend/app"
+	"backend/bulk"
+	"backend/config"
+	"backend/logging"
+
+	"github.com/google/uuid"
+)
+
+// runBulk runs the import or export subcommand with args and returns the exit code.
+//
+//	backend import -user <id> [-format csv|json|ndjson] [-dry-run] [-atomic] [file]
+//	backend export -user <id> [-format csv|json|ndjson] [file]
+//
+// The file defaults to standard input or output. The configuration is loaded
+// as for the server, so the usual flags and environment variables apply.
+func runBulk(command string, args []string) int {
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	userFlag := flags.String("user", "", "ID of the user whose games are imported or exported")
+	formatFlag := flags.String("format", "", "file format: csv, json or ndjson; taken from the file extension when empty")
+	dryRun := flags.Bool("dry-run", false, "validate the file and print the report without writing anything")
+	atomic := flags.Bool("atomic", false, "import nothing unless every row is valid, in one transaction")
+	batchSize := flags.Int("batch-size", bulk.DefaultBatchSize, "rows per transaction when not atomic")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s -user <id> [flags] [file]\n", os.Args[0], command)
+		flags.PrintDefaults()
+	}
+
+	cfg, err := config.Load(flags, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		return 2
+	}
+	userID, err := uuid.Parse(*userFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "-user must be a user ID")
+		return 2
+	}
+	path := flags.Arg(0)
+	format, err := fileFormat(*formatFlag, path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	// Standard output may carry the exported file, so logs go to standard error
+	slog.SetDefault(logging.New(os.Stderr, cfg.Log))
+
+	application, err := app.New(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to start: %v\n", err)
+		return 1
+	}
+	defer application.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if command == "export" {
+		err = exportFile(ctx, path, userID, format, application)
+	} else {
+		opts := bulk.Options{Format: format, DryRun: *dryRun, Atomic: *atomic, BatchSize: *batchSize}
+		err = importFile(ctx, path, userID, opts, application)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed: %v\n", command, err)
+		return 1
+	}
+	return 0
+}
+
+// importFile imports path, or standard input, and prints the report to standard
+// output. Invalid rows make the import fail even though valid rows were written.
+func importFile(ctx context.Context, path string, userID uuid.UUID, opts bulk.Options, application *app.App) error {
+	in := io.Reader(os.Stdin)
+	if path != "" && path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	report, err := bulk.Import(ctx, in, userID, opts, application.Games)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+	if err == nil && report.Invalid > 0 {
+		err = fmt.Errorf("%d of %d rows are invalid", report.Invalid, report.Rows)
+	}
+	return err
+}
+
+// exportFile writes the user's games to path, or standard output
+func exportFile(ctx context.Context, path string, userID uuid.UUID, format bulk.Format, application *app.App) error {
+	if path == "" || path == "-" {
+		return bulk.Export(ctx, os.Stdout, userID, format, application.Games)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := bulk.Export(ctx, f, userID, format, application.Games); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// fileFormat returns the format named by the -format flag or, failing that, by the extension of path
+func fileFormat(name, path string) (bulk.Format, error) {
+	if name == "" {
+		switch ext := filepath.Ext(path); ext {
+		case ".csv", ".json", ".ndjson":
+			name = ext[1:]
+		case ".jsonl":
+			name = string(bulk.NDJSON)
+		default:
+			return "", errors.New("-format is required when the file has no .csv, .json or .ndjson extension")
+		}
+	}
+	return bulk.ParseFormat(name)
+}
@@ -0,0 +1,18 @@
+package bulk
+
+import (
+	"context"
+	"io"
+
+	"github.com/google/uuid"
+)
+
+// Export writes every game of userID to w in format f. Rows are written as the
+// store pages through them, so w receives a partial file when an error is returned.
+func Export(ctx context.Context, w io.Writer, userID uuid.UUID, f Format, store Store) error {
+	writer := NewWriter(w, f)
+	if err := store.ExportGames(ctx, userID, writer.Write); err != nil {
+		return err
+	}
+	return writer.Close()
+}
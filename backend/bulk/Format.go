@@ -0,0 +1,57 @@
+package bulk
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// Format is the encoding of a bulk file
+type Format string
+
+// Supported bulk file formats
+const (
+	CSV    Format = "csv"    // a header row naming the Columns, then one game per row
+	JSON   Format = "json"   // an array of game objects
+	NDJSON Format = "ndjson" // one game object per line
+)
+
+// Columns are the CSV columns, in the order exports write them. Imports accept them in any order.
+var Columns = []string{"external_key", "title", "description", "subject", "difficulty_level", "language"}
+
+var contentTypes = map[Format]string{
+	CSV:    "text/csv",
+	JSON:   "application/json",
+	NDJSON: "application/x-ndjson",
+}
+
+// ParseFormat returns the format named s
+func ParseFormat(s string) (Format, error) {
+	f := Format(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := contentTypes[f]; !ok {
+		return "", fmt.Errorf("unknown format %q, must be csv, json or ndjson", s)
+	}
+	return f, nil
+}
+
+// FormatOf returns the format of a Content-Type header, ok is false when it is not a bulk format
+func FormatOf(contentType string) (f Format, ok bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	if mediaType == "application/ndjson" || mediaType == "application/jsonl" {
+		return NDJSON, true
+	}
+	for f, ct := range contentTypes {
+		if ct == mediaType {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// ContentType is the media type of files in format f
+func (f Format) ContentType() string {
+	return contentTypes[f]
+}
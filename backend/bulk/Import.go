@@ -0,0 +1,169 @@
+package bulk
+
+import (
+	"backend/models"
+	"backend/services"
+	"backend/utils"
+	"backend/validation"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+)
+
+// DefaultBatchSize is the number of rows written per transaction when an import is not atomic
+const DefaultBatchSize = 500
+
+// MaxReportedErrors bounds the field errors listed in a report. Invalid rows beyond it are still counted.
+const MaxReportedErrors = 1000
+
+// CodeDuplicate is the field error of an external key that appears on more than one row
+const CodeDuplicate = "duplicate"
+
+// ErrInvalidRows is returned by atomic imports that were refused because some rows are invalid
+var ErrInvalidRows = errors.New("import has invalid rows")
+
+// Store reads and writes the games of bulk files. *services.GameService implements it.
+type Store interface {
+	ImportGames(ctx context.Context, userID uuid.UUID, records []models.GameRecord) (services.ImportResult, error)
+	ExportGames(ctx context.Context, userID uuid.UUID, emit func(models.GameRecord) error) error
+}
+
+// Options control an import
+type Options struct {
+	Format    Format
+	DryRun    bool // validate every row and report, without writing anything
+	Atomic    bool // write nothing unless every row is valid, and then all rows in one transaction
+	BatchSize int  // rows per transaction when not atomic; 0 means DefaultBatchSize
+}
+
+// Report is the outcome of an import. Errors name fields as rows[N].field,
+// with rows numbered from 1 not counting the CSV header.
+type Report struct {
+	DryRun    bool               `json:"dry_run"`
+	Atomic    bool               `json:"atomic"`
+	Rows      int                `json:"rows"`
+	Valid     int                `json:"valid"`
+	Invalid   int                `json:"invalid"`
+	Created   int                `json:"created"`
+	Updated   int                `json:"updated"`
+	Errors    []utils.FieldError `json:"errors"`
+	Truncated bool               `json:"errors_truncated,omitempty"` // more errors than MaxReportedErrors
+}
+
+// Import reads every row of r, validates it and, unless opts.DryRun, upserts
+// the valid rows for userID. Invalid rows are skipped and reported; atomic
+// imports write nothing if there are any and return ErrInvalidRows.
+//
+// Rows are written in batches of opts.BatchSize as soon as they are read,
+// each in its own transaction, so a failing non-atomic import may have written
+// some batches already; the report counts them. Only atomic imports hold every
+// row in memory, to write them in one transaction at the end.
+func Import(ctx context.Context, r io.Reader, userID uuid.UUID, opts Options, store Store) (Report, error) {
+	report := Report{DryRun: opts.DryRun, Atomic: opts.Atomic, Errors: []utils.FieldError{}}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	var batch []models.GameRecord
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		result, err := store.ImportGames(ctx, userID, batch)
+		if err != nil {
+			return err
+		}
+		report.Created += result.Created
+		report.Updated += result.Updated
+		batch = nil
+		return nil
+	}
+
+	err := readRows(NewReader(r, opts.Format), &report, func(record models.GameRecord) error {
+		if opts.DryRun {
+			return nil
+		}
+		batch = append(batch, record)
+		if !opts.Atomic && len(batch) == batchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	if opts.DryRun {
+		return report, nil
+	}
+	if opts.Atomic && report.Invalid > 0 {
+		return report, ErrInvalidRows
+	}
+	return report, flush()
+}
+
+// readRows reads and validates every row, passing valid ones to emit and
+// recording invalid ones in report
+func readRows(reader *Reader, report *Report, emit func(models.GameRecord) error) error {
+	keys := make(map[string]int) // external key -> first row
+
+	for {
+		record, row, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		report.Rows++
+
+		var errs []utils.FieldError
+		var rowErr *RowError
+		switch {
+		case errors.As(err, &rowErr):
+			errs = rowErr.Errors
+		case err != nil:
+			report.Rows--
+			return err
+		default:
+			var invalid validation.Errors
+			if errors.As(validation.Struct(record), &invalid) {
+				errs = invalid
+			}
+			if first, ok := keys[record.ExternalKey]; ok && record.ExternalKey != "" {
+				errs = append(errs, utils.FieldError{
+					Field:   "external_key",
+					Code:    CodeDuplicate,
+					Message: fmt.Sprintf("repeats the key of row %d", first),
+				})
+			} else {
+				keys[record.ExternalKey] = row
+			}
+		}
+
+		if len(errs) == 0 {
+			report.Valid++
+			if err := emit(record); err != nil {
+				return err
+			}
+			continue
+		}
+		report.Invalid++
+		for _, fe := range errs {
+			if len(report.Errors) == MaxReportedErrors {
+				report.Truncated = true
+				break
+			}
+			fe.Field = rowField(row, fe.Field)
+			report.Errors = append(report.Errors, fe)
+		}
+	}
+}
+
+// rowField names field of a row as rows[N].field, or rows[N] for the whole row
+func rowField(row int, field string) string {
+	if field == "" {
+		return fmt.Sprintf("rows[%d]", row)
+	}
+	return fmt.Sprintf("rows[%d].%s", row, field)
+}
@@ -0,0 +1,106 @@
+package bulk
+
+import (
+	"backend/models"
+	"backend/services"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// fakeStore records the batches it is asked to import
+type fakeStore struct {
+	batches [][]models.GameRecord
+	err     error
+}
+
+func (s *fakeStore) ImportGames(ctx context.Context, userID uuid.UUID, records []models.GameRecord) (services.ImportResult, error) {
+	if s.err != nil {
+		return services.ImportResult{}, s.err
+	}
+	s.batches = append(s.batches, records)
+	return services.ImportResult{Created: len(records)}, nil
+}
+
+func (s *fakeStore) ExportGames(ctx context.Context, userID uuid.UUID, emit func(models.GameRecord) error) error {
+	return nil
+}
+
+const importCSV = "external_key,title,difficulty_level,language\n" +
+	"v-1,Verbs,2,english\n" +
+	"n-1,,9,klingon\n" +
+	"a-1,Adverbs,,\n" +
+	"v-1,Verbs again,,\n" +
+	"p-1,Prepositions,1,\n"
+
+func TestImportSkipsInvalidRows(t *testing.T) {
+	store := &fakeStore{}
+	report, err := Import(context.Background(), strings.NewReader(importCSV), uuid.New(), Options{Format: CSV, BatchSize: 2}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Rows != 5 || report.Valid != 3 || report.Invalid != 2 || report.Created != 3 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if len(store.batches) != 2 || len(store.batches[0]) != 2 || store.batches[1][0].ExternalKey != "p-1" {
+		t.Errorf("expected the valid rows in batches of 2, got %+v", store.batches)
+	}
+
+	fields := make(map[string]string)
+	for _, fe := range report.Errors {
+		fields[fe.Field] = fe.Code
+	}
+	want := map[string]string{
+		"rows[2].title":            "required",
+		"rows[2].difficulty_level": "max",
+		"rows[2].language":         "oneof",
+		"rows[4].external_key":     CodeDuplicate,
+	}
+	for field, code := range want {
+		if fields[field] != code {
+			t.Errorf("expected %s to fail %s, got errors %+v", field, code, report.Errors)
+		}
+	}
+}
+
+func TestImportAtomicWritesNothingWithInvalidRows(t *testing.T) {
+	store := &fakeStore{}
+	report, err := Import(context.Background(), strings.NewReader(importCSV), uuid.New(), Options{Format: CSV, Atomic: true}, store)
+	if !errors.Is(err, ErrInvalidRows) || len(store.batches) != 0 || report.Invalid != 2 {
+		t.Fatalf("expected the import to be refused, got %v %+v", err, store.batches)
+	}
+
+	valid := "external_key,title\na,A\nb,B\nc,C\n"
+	report, err = Import(context.Background(), strings.NewReader(valid), uuid.New(), Options{Format: CSV, Atomic: true, BatchSize: 2}, store)
+	if err != nil || len(store.batches) != 1 || report.Created != 3 {
+		t.Errorf("expected one transaction for all rows, got %v %+v", err, store.batches)
+	}
+}
+
+func TestImportDryRunWritesNothing(t *testing.T) {
+	store := &fakeStore{err: errors.New("must not be called")}
+	report, err := Import(context.Background(), strings.NewReader(importCSV), uuid.New(), Options{Format: CSV, DryRun: true}, store)
+	if err != nil || !report.DryRun || report.Valid != 3 || report.Created != 0 {
+		t.Errorf("unexpected dry run %v %+v", err, report)
+	}
+}
+
+func TestImportStopsAtMalformedFile(t *testing.T) {
+	store := &fakeStore{}
+	_, err := Import(context.Background(), strings.NewReader(`[{"external_key":"a","title":"A"}, {`), uuid.New(), Options{Format: JSON}, store)
+	if !errors.Is(err, ErrMalformed) || len(store.batches) != 0 {
+		t.Errorf("expected nothing to be imported from a malformed file, got %v %+v", err, store.batches)
+	}
+}
+
+func TestImportWritesBatchesWhileReading(t *testing.T) {
+	store := &fakeStore{}
+	file := `{"external_key":"a","title":"A"}` + "\n" + `{"external_key":"b","title":"B"}` + "\n" + `{"external_key":`
+	report, err := Import(context.Background(), strings.NewReader(file), uuid.New(), Options{Format: NDJSON, BatchSize: 2}, store)
+	if !errors.Is(err, ErrMalformed) || len(store.batches) != 1 || report.Created != 2 {
+		t.Errorf("expected the first batch to be written before the malformed row, got %v %+v %+v", err, store.batches, report)
+	}
+}
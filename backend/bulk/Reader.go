@@ -0,0 +1,220 @@
+package bulk
+
+import (
+	"backend/models"
+	"backend/utils"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrMalformed reports a file that cannot be read past some point, such as
+// broken JSON or CSV quoting. Errors of single rows are RowErrors instead.
+var ErrMalformed = errors.New("malformed file")
+
+// Field error codes of rows that could not be decoded
+const (
+	CodeInvalidRow   = "invalid_row"
+	CodeInvalidType  = "invalid_type"
+	CodeUnknownField = "unknown_field"
+)
+
+// RowError is a row that could not be decoded. Reading continues with the next row.
+// Field names are CSV columns or JSON keys; an empty field refers to the whole row.
+type RowError struct {
+	Errors []utils.FieldError
+}
+
+func (e *RowError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = strings.TrimPrefix(fe.Field+": "+fe.Message, ": ")
+	}
+	return "invalid row: " + strings.Join(msgs, "; ")
+}
+
+// Reader reads game records from a bulk file one row at a time
+type Reader struct {
+	next func() (models.GameRecord, error)
+	row  int
+}
+
+// NewReader reads records in format f from r
+func NewReader(r io.Reader, f Format) *Reader {
+	switch f {
+	case CSV:
+		c := &csvReader{r: csv.NewReader(r)}
+		return &Reader{next: c.next}
+	default:
+		j := &jsonReader{dec: json.NewDecoder(r), array: f == JSON}
+		j.dec.DisallowUnknownFields()
+		return &Reader{next: j.next}
+	}
+}
+
+// Next returns the next record and its row number, counted from 1 without the
+// CSV header. err is a *RowError for a row that could not be decoded, io.EOF
+// after the last row, and wraps ErrMalformed when the rest of the file cannot be read.
+func (r *Reader) Next() (models.GameRecord, int, error) {
+	r.row++
+	record, err := r.next()
+	var rowErr *RowError
+	if err != nil && err != io.EOF && !errors.As(err, &rowErr) {
+		err = fmt.Errorf("%w: reading row %d: %w", ErrMalformed, r.row, err)
+	}
+	return record, r.row, err
+}
+
+// csvReader decodes rows by the column names of the header row
+type csvReader struct {
+	r       *csv.Reader
+	columns []string
+}
+
+func (c *csvReader) next() (models.GameRecord, error) {
+	var record models.GameRecord
+	if c.columns == nil {
+		if err := c.readHeader(); err != nil {
+			return record, err
+		}
+	}
+
+	fields, err := c.r.Read()
+	if errors.Is(err, csv.ErrFieldCount) {
+		return record, &RowError{Errors: []utils.FieldError{{
+			Code:    CodeInvalidRow,
+			Message: fmt.Sprintf("has %d fields, the header has %d", len(fields), len(c.columns)),
+		}}}
+	}
+	if err != nil {
+		return record, err
+	}
+
+	var errs []utils.FieldError
+	for i, column := range c.columns {
+		value := fields[i]
+		switch column {
+		case "external_key":
+			record.ExternalKey = value
+		case "title":
+			record.Title = value
+		case "description":
+			record.Description = value
+		case "subject":
+			record.Subject = value
+		case "language":
+			record.Language = value
+		case "difficulty_level":
+			if strings.TrimSpace(value) == "" {
+				continue
+			}
+			if record.Difficulty, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				errs = append(errs, utils.FieldError{Field: column, Code: CodeInvalidType, Message: "must be an integer"})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return record, &RowError{Errors: errs}
+	}
+	return record, nil
+}
+
+// readHeader reads the column names. Every column must be known and external_key and title are required.
+func (c *csvReader) readHeader() error {
+	header, err := c.r.Read()
+	if err != nil {
+		return err
+	}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if i == 0 {
+			// Spreadsheet programs often start UTF-8 files with a byte order mark
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		if !slices.Contains(Columns, name) {
+			return fmt.Errorf("unknown column %q, columns are %s", name, strings.Join(Columns, ", "))
+		}
+		if slices.Contains(header[:i], name) {
+			return fmt.Errorf("duplicate column %q", name)
+		}
+		header[i] = name
+	}
+	for _, required := range []string{"external_key", "title"} {
+		if !slices.Contains(header, required) {
+			return fmt.Errorf("missing column %q", required)
+		}
+	}
+	c.columns = header
+	c.r.FieldsPerRecord = len(header)
+	return nil
+}
+
+// jsonReader decodes the elements of a JSON array or a stream of JSON values
+type jsonReader struct {
+	dec   *json.Decoder
+	array bool
+	state int // of an array: 0 before the opening bracket, 1 inside, 2 after the closing bracket
+}
+
+func (j *jsonReader) next() (models.GameRecord, error) {
+	var record models.GameRecord
+	if j.array {
+		switch j.state {
+		case 0:
+			tok, err := j.dec.Token()
+			if err != nil && err != io.EOF {
+				return record, err
+			}
+			if tok != json.Delim('[') {
+				return record, errors.New("expected a JSON array of games")
+			}
+			j.state = 1
+		case 2:
+			return record, io.EOF
+		}
+		if !j.dec.More() {
+			j.state = 2
+			if _, err := j.dec.Token(); err != nil {
+				return record, err
+			}
+			if _, err := j.dec.Token(); err != io.EOF {
+				return record, errors.New("unexpected data after the array")
+			}
+			return record, io.EOF
+		}
+	}
+
+	err := j.dec.Decode(&record)
+	if err == io.EOF && !j.array {
+		return record, io.EOF
+	}
+	if err != nil {
+		if fe, ok := decodeFieldError(err); ok {
+			return record, &RowError{Errors: []utils.FieldError{fe}}
+		}
+		return record, err
+	}
+	return record, nil
+}
+
+// decodeFieldError converts errors that concern a single JSON value; ok is false
+// for syntax errors, after which the stream cannot be read any further
+func decodeFieldError(err error) (utils.FieldError, bool) {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		if typeErr.Field == "" {
+			return utils.FieldError{Code: CodeInvalidType, Message: "must be a JSON object"}, true
+		}
+		return utils.FieldError{Field: typeErr.Field, Code: CodeInvalidType, Message: "must be of type " + typeErr.Type.String()}, true
+	}
+	// encoding/json has no typed error for DisallowUnknownFields
+	if name, ok := strings.CutPrefix(err.Error(), `json: unknown field "`); ok {
+		return utils.FieldError{Field: strings.TrimSuffix(name, `"`), Code: CodeUnknownField, Message: "is not a known field"}, true
+	}
+	return utils.FieldError{}, false
+}
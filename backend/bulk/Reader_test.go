@@ -0,0 +1,149 @@
+package bulk
+
+import (
+	"backend/models"
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readAll returns the records read from data and the row errors by row number
+func readAll(t *testing.T, data string, f Format) ([]models.GameRecord, map[int]*RowError, error) {
+	t.Helper()
+	reader := NewReader(strings.NewReader(data), f)
+	var records []models.GameRecord
+	rowErrs := make(map[int]*RowError)
+	for {
+		record, row, err := reader.Next()
+		var rowErr *RowError
+		switch {
+		case err == io.EOF:
+			return records, rowErrs, nil
+		case errors.As(err, &rowErr):
+			rowErrs[row] = rowErr
+		case err != nil:
+			return records, rowErrs, err
+		default:
+			records = append(records, record)
+		}
+	}
+}
+
+func TestReaderCSV(t *testing.T) {
+	data := "\ufeffTitle,external_key,difficulty_level,subject\n" +
+		"Verbs,v-1,2,Grammar\n" +
+		"Nouns,n-1,hard,Grammar\n" +
+		"\"Commas, quoted\",c-1,,\n" +
+		"Short,s-1\n"
+	records, rowErrs, err := readAll(t, data, CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0] != (models.GameRecord{ExternalKey: "v-1", Title: "Verbs", Difficulty: 2, Subject: "Grammar"}) {
+		t.Errorf("unexpected records %+v", records)
+	}
+	if records[1].Title != "Commas, quoted" || records[1].Difficulty != 0 {
+		t.Errorf("expected a quoted title without difficulty, got %+v", records[1])
+	}
+	if e := rowErrs[2]; e == nil || e.Errors[0].Field != "difficulty_level" || e.Errors[0].Code != CodeInvalidType {
+		t.Errorf("expected a type error on row 2, got %v", e)
+	}
+	if e := rowErrs[4]; e == nil || e.Errors[0].Code != CodeInvalidRow {
+		t.Errorf("expected a field count error on row 4, got %v", e)
+	}
+}
+
+func TestReaderCSVRejectsUnknownColumns(t *testing.T) {
+	_, _, err := readAll(t, "external_key,title,colour\n", CSV)
+	if !errors.Is(err, ErrMalformed) || !strings.Contains(err.Error(), `"colour"`) {
+		t.Errorf("expected the unknown column to be reported, got %v", err)
+	}
+	_, _, err = readAll(t, "title,subject\n", CSV)
+	if !errors.Is(err, ErrMalformed) {
+		t.Errorf("expected a missing external_key column to be rejected, got %v", err)
+	}
+}
+
+func TestReaderJSON(t *testing.T) {
+	data := `[
+		{"external_key":"v-1","title":"Verbs","difficulty_level":2},
+		{"external_key":"n-1","title":"Nouns","difficulty_level":"hard"},
+		{"external_key":"c-1","title":"Colours","colour":"red"},
+		42,
+		{"external_key":"a-1","title":"Adverbs"}
+	]`
+	records, rowErrs, err := readAll(t, data, JSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1].Title != "Adverbs" {
+		t.Errorf("unexpected records %+v", records)
+	}
+	want := map[int]string{2: CodeInvalidType, 3: CodeUnknownField, 4: CodeInvalidType}
+	for row, code := range want {
+		if e := rowErrs[row]; e == nil || e.Errors[0].Code != code {
+			t.Errorf("row %d: expected %s, got %v", row, code, e)
+		}
+	}
+}
+
+func TestReaderJSONMalformed(t *testing.T) {
+	for _, data := range []string{``, `{"title":"x"}`, `[{"title":"x"},`, `[{"title":"x"}] []`, `[{"title": x}]`} {
+		if _, _, err := readAll(t, data, JSON); !errors.Is(err, ErrMalformed) {
+			t.Errorf("%q: expected ErrMalformed, got %v", data, err)
+		}
+	}
+}
+
+func TestReaderNDJSON(t *testing.T) {
+	data := "{\"external_key\":\"v-1\",\"title\":\"Verbs\"}\n\n{\"external_key\":\"n-1\",\"title\":\"Nouns\"}\n"
+	records, rowErrs, err := readAll(t, data, NDJSON)
+	if err != nil || len(rowErrs) != 0 {
+		t.Fatal(err, rowErrs)
+	}
+	if len(records) != 2 || records[1].ExternalKey != "n-1" {
+		t.Errorf("unexpected records %+v", records)
+	}
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	games := []models.GameRecord{
+		{ExternalKey: "v-1", Title: "Verbs, irregular", Description: "Say \"went\"", Subject: "Grammar", Difficulty: 2, Language: "english"},
+		{ExternalKey: "n-1", Title: "Nouns"},
+	}
+	for _, f := range []Format{CSV, JSON, NDJSON} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, f)
+		for _, g := range games {
+			if err := w.Write(g); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		records, rowErrs, err := readAll(t, buf.String(), f)
+		if err != nil || len(rowErrs) != 0 {
+			t.Fatalf("%s: %v %v\n%s", f, err, rowErrs, buf.String())
+		}
+		if len(records) != 2 || records[0] != games[0] || records[1] != games[1] {
+			t.Errorf("%s: expected the written games back, got %+v", f, records)
+		}
+	}
+}
+
+func TestWriterEmptyFiles(t *testing.T) {
+	want := map[Format]string{CSV: strings.Join(Columns, ",") + "\n", JSON: "[]\n", NDJSON: ""}
+	for f, expected := range want {
+		var buf bytes.Buffer
+		if err := NewWriter(&buf, f).Close(); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != expected {
+			t.Errorf("%s: expected %q, got %q", f, expected, buf.String())
+		}
+	}
+}
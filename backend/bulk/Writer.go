@@ -0,0 +1,81 @@
+package bulk
+
+import (
+	"backend/models"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// Writer writes game records as a bulk file. Close must be called to finish the file.
+type Writer struct {
+	w      *bufio.Writer
+	csv    *csv.Writer
+	format Format
+	count  int
+}
+
+// NewWriter writes records in format f to w. Output is buffered until Flush or Close.
+func NewWriter(w io.Writer, f Format) *Writer {
+	bw := &Writer{w: bufio.NewWriter(w), format: f}
+	switch f {
+	case CSV:
+		bw.csv = csv.NewWriter(bw.w)
+		bw.csv.Write(Columns)
+	case JSON:
+		bw.w.WriteString("[")
+	}
+	return bw
+}
+
+// Write appends one record
+func (w *Writer) Write(record models.GameRecord) error {
+	w.count++
+	if w.format == CSV {
+		difficulty := ""
+		if record.Difficulty != 0 {
+			difficulty = strconv.Itoa(record.Difficulty)
+		}
+		return w.csv.Write([]string{record.ExternalKey, record.Title, record.Description, record.Subject, difficulty, record.Language})
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if w.format == JSON {
+		if w.count > 1 {
+			w.w.WriteString(",")
+		}
+		w.w.WriteString("\n")
+	}
+	w.w.Write(data)
+	if w.format == NDJSON {
+		w.w.WriteString("\n")
+	}
+	return nil
+}
+
+// Flush sends the records written so far to the underlying writer
+func (w *Writer) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		if err := w.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return w.w.Flush()
+}
+
+// Close finishes the file and flushes it. The underlying writer is not closed.
+func (w *Writer) Close() error {
+	if w.format == JSON {
+		if w.count > 0 {
+			w.w.WriteString("\n")
+		}
+		w.w.WriteString("]\n")
+	}
+	return w.Flush()
+}
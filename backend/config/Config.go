@@ -1,27 +1,155 @@
 package config
 
 import (
-	"log"
-	"os"
-
-	"github.com/joho/godotenv"
+	"time"
 )
 
+// Config holds every setting of the service. Settings are layered, lowest
+// precedence first: `default` tags, the optional YAML/TOML config file,
+// environment variables (a .env file is loaded into the environment when
+// present) and finally command-line flags.
+//
+// The `config` tag is the key in the config file and, with "." and "_"
+// replaced by "-", the flag name. The `env` tag names the environment
+// variable; NAME_FILE may be set instead to read the value from a file
+// (Docker secrets). Fields tagged `secret` are masked in Dump.
 type Config struct {
-    SupabaseURL string
-    SupabaseKey string
-    JWTSecret string
+	ConfigFile     string `config:"config_file" env:"CONFIG_FILE" usage:"path to a YAML (.yaml, .yml) or TOML (.toml) config file"`
+	SupabaseURL    string `config:"supabase_url" env:"SUPABASE_URL" required:"true" usage:"Supabase project URL"`
+	SupabaseKey    string `config:"supabase_key" env:"SUPABASE_KEY" required:"true" secret:"true" usage:"Supabase anon (public) API key"`
+	ServiceRoleKey string `config:"service_role_key" env:"SERVICE_ROLE_KEY" required:"true" secret:"true" usage:"Supabase service role key used for admin calls"`
+	JWTSecret      string `config:"jwt_secret" env:"JWT_SECRET" required:"true" secret:"true" usage:"Supabase JWT secret used to validate access tokens"`
+	DatabaseURL    string `config:"database_url" env:"DATABASE_URL" secret:"true" usage:"optional direct Postgres connection, checked for readiness when set"`
+
+	Supabase    SupabaseClientConfig `config:"supabase"`
+	Server      ServerConfig         `config:"server"`
+	Health      HealthConfig         `config:"health"`
+	Log         LogConfig            `config:"log"`
+	Tracing     TracingConfig        `config:"tracing"`
+	CORS        CORSConfig           `config:"cors"`
+	Pagination  PaginationConfig     `config:"pagination"`
+	API         APIConfig            `config:"api"`
+	GraphQL     GraphQLConfig        `config:"graphql"`
+	GRPC        GRPCConfig           `config:"grpc"`
+	Idempotency IdempotencyConfig    `config:"idempotency"`
+	Trash       TrashConfig          `config:"trash"`
+
+	sources map[string]string // layer each setting was taken from, for Dump
+}
+
+// SupabaseClientConfig tunes the shared HTTP client used for Supabase calls
+type SupabaseClientConfig struct {
+	Timeout         time.Duration `config:"timeout" env:"SUPABASE_TIMEOUT" default:"10s" usage:"timeout of a single Supabase request attempt"`
+	MaxIdleConns    int           `config:"max_idle_conns" env:"SUPABASE_MAX_IDLE_CONNS" default:"32" usage:"keep-alive connections kept open to Supabase"`
+	RetryAttempts   int           `config:"retry_attempts" env:"SUPABASE_RETRY_ATTEMPTS" default:"3" usage:"attempts for idempotent requests, including the first"`
+	RetryBaseDelay  time.Duration `config:"retry_base_delay" env:"SUPABASE_RETRY_BASE_DELAY" default:"100ms" usage:"backoff before the first retry, doubled for each further retry and jittered"`
+	RetryMaxDelay   time.Duration `config:"retry_max_delay" env:"SUPABASE_RETRY_MAX_DELAY" default:"2s"`
+	BreakerFailures int           `config:"breaker_failures" env:"SUPABASE_BREAKER_FAILURES" default:"5" usage:"consecutive failures that open the circuit breaker"`
+	BreakerCooldown time.Duration `config:"breaker_cooldown" env:"SUPABASE_BREAKER_COOLDOWN" default:"30s" usage:"how long the breaker fails fast before letting a probe through"`
+}
+
+// ServerConfig controls how the HTTP server listens and shuts down.
+// Zero values fall back to the defaults of the server package.
+type ServerConfig struct {
+	Addr              string        `config:"addr" env:"SERVER_ADDR" default:":8080" usage:"TCP listen address"`
+	SocketPath        string        `config:"socket_path" env:"SERVER_SOCKET" usage:"listen on this Unix socket instead of addr"`
+	ReadTimeout       time.Duration `config:"read_timeout" env:"SERVER_READ_TIMEOUT" default:"15s"`
+	ReadHeaderTimeout time.Duration `config:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" default:"5s"`
+	WriteTimeout      time.Duration `config:"write_timeout" env:"SERVER_WRITE_TIMEOUT" default:"30s"`
+	IdleTimeout       time.Duration `config:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" default:"120s"`
+	DrainDelay        time.Duration `config:"drain_delay" env:"SERVER_DRAIN_DELAY" usage:"how long to keep serving while reporting not ready before shutdown"`
+	ShutdownTimeout   time.Duration `config:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" default:"20s" usage:"how long in-flight requests may drain on SIGTERM"`
+	MaxHeaderBytes    int           `config:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES" default:"1048576"`
+	MaxBodyBytes      int           `config:"max_body_bytes" env:"SERVER_MAX_BODY_BYTES" default:"1048576" usage:"largest accepted request body"`
+	MaxImportBytes    int           `config:"max_import_bytes" env:"SERVER_MAX_IMPORT_BYTES" default:"33554432" usage:"largest accepted bulk import body"`
+	DocsUI            bool          `config:"docs_ui" env:"SERVER_DOCS_UI" default:"true" usage:"serve interactive API documentation at /docs"`
+	TLSCertFile       string        `config:"tls_cert_file" env:"TLS_CERT_FILE" usage:"serve HTTPS when both TLS files are set; reloaded when they change"`
+	TLSKeyFile        string        `config:"tls_key_file" env:"TLS_KEY_FILE"`
+}
+
+// HealthConfig tunes the readiness checks
+type HealthConfig struct {
+	CheckTimeout time.Duration `config:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"2s" usage:"timeout of each dependency check"`
+	CacheTTL     time.Duration `config:"cache_ttl" env:"HEALTH_CACHE_TTL" default:"5s" usage:"how long a check result is reused"`
+}
+
+// LogConfig controls the format, verbosity and redaction of the structured logger
+type LogConfig struct {
+	Level      string   `config:"level" env:"LOG_LEVEL" default:"info" usage:"debug, info, warn or error"`
+	Format     string   `config:"format" env:"LOG_FORMAT" default:"json" usage:"json or text"`
+	RedactKeys []string `config:"redact_keys" env:"LOG_REDACT_KEYS" usage:"extra attribute keys masked on top of the built-in ones"`
+}
+
+// TracingConfig selects where OpenTelemetry spans are exported
+type TracingConfig struct {
+	Exporter     string  `config:"exporter" env:"TRACING_EXPORTER" default:"none" usage:"otlp, stdout or none"`
+	OTLPEndpoint string  `config:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT" usage:"overrides the standard OTEL_EXPORTER_OTLP_* variables when set"`
+	ServiceName  string  `config:"service_name" env:"OTEL_SERVICE_NAME" default:"ilang-backend" usage:"reported as service.name"`
+	SampleRatio  float64 `config:"sample_ratio" env:"TRACING_SAMPLE_RATIO" default:"1" usage:"fraction of new traces to sample"`
+}
+
+// CORSConfig controls which browser origins may call the API
+type CORSConfig struct {
+	AllowedOrigins   []string `config:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" usage:"exact origins, * or wildcard subdomains like https://*.example.com"`
+	AllowedMethods   []string `config:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string `config:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	ExposedHeaders   []string `config:"exposed_headers" env:"CORS_EXPOSED_HEADERS"`
+	AllowCredentials bool     `config:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           int      `config:"max_age" env:"CORS_MAX_AGE" default:"600" usage:"seconds browsers may cache a preflight response"`
+}
+
+// PaginationConfig controls list endpoints
+type PaginationConfig struct {
+	CursorSecret string `config:"cursor_secret" env:"PAGINATION_CURSOR_SECRET" secret:"true" usage:"key that signs page cursors; derived from jwt_secret when empty"`
+	DefaultLimit int    `config:"default_limit" env:"PAGINATION_DEFAULT_LIMIT" default:"25" usage:"page size when a request sets no limit"`
+	MaxLimit     int    `config:"max_limit" env:"PAGINATION_MAX_LIMIT" default:"100" usage:"largest page size a request may ask for"`
+}
+
+// APIConfig controls the unversioned paths kept for clients that predate /v1
+type APIConfig struct {
+	UnversionedPaths  bool   `config:"unversioned_paths" env:"API_UNVERSIONED_PATHS" default:"true" usage:"serve routes without a version prefix, such as /games, marked deprecated"`
+	DefaultVersion    string `config:"default_version" env:"API_DEFAULT_VERSION" default:"v1" usage:"version serving unversioned paths when a request sends no API-Version header"`
+	UnversionedSunset string `config:"unversioned_sunset" env:"API_UNVERSIONED_SUNSET" usage:"date (YYYY-MM-DD) after which unversioned paths are removed, sent in their Sunset header"`
+}
+
+// APIVersions are the API versions the server serves, oldest first. The
+// router registers a version group for each of them.
+var APIVersions = []string{"v1"}
+
+// GRPCConfig controls the gRPC API for internal services
+type GRPCConfig struct {
+	Addr       string `config:"addr" env:"GRPC_ADDR" usage:"TCP listen address of the gRPC API, e.g. :9090; disabled when empty"`
+	Reflection bool   `config:"reflection" env:"GRPC_REFLECTION" default:"true" usage:"serve the gRPC reflection service so tools like grpcurl can list the API"`
+}
+
+// GraphQLConfig bounds the queries the GraphQL endpoint accepts
+type GraphQLConfig struct {
+	MaxDepth      int `config:"max_depth" env:"GRAPHQL_MAX_DEPTH" default:"8" usage:"deepest field nesting of a query; 0 disables the limit"`
+	MaxComplexity int `config:"max_complexity" env:"GRAPHQL_MAX_COMPLEXITY" default:"1000" usage:"most fields a query may resolve, list fields counting once per requested item; 0 disables the limit"`
+}
+
+// IdempotencyConfig controls how long Idempotency-Key responses are replayed
+type IdempotencyConfig struct {
+	TTL         time.Duration `config:"ttl" env:"IDEMPOTENCY_TTL" default:"24h" usage:"how long the response of a request with an Idempotency-Key is replayed to retries; 0 disables the header"`
+	LockTimeout time.Duration `config:"lock_timeout" env:"IDEMPOTENCY_LOCK_TIMEOUT" default:"1m" usage:"how long a key stays locked by a request that has not finished"`
+}
+
+// TrashConfig controls how long deleted games and users can be restored
+type TrashConfig struct {
+	Retention     time.Duration `config:"retention" env:"TRASH_RETENTION" default:"720h" usage:"how long deleted games and users stay in the trash before they are purged for good"`
+	PurgeInterval time.Duration `config:"purge_interval" env:"TRASH_PURGE_INTERVAL" default:"1h" usage:"how often the trash is purged; 0 disables purging"`
+}
+
+// Sunset returns the parsed UnversionedSunset, or the zero time when it is unset or invalid
+func (c APIConfig) Sunset() time.Time {
+	sunset, _ := time.Parse(time.DateOnly, c.UnversionedSunset)
+	return sunset
 }
 
+// LoadConfig returns the configuration from defaults, the config file and
+// the environment, without command-line flags or validation. It never exits;
+// use Load at startup to get validation errors.
 func LoadConfig() Config {
-    err := godotenv.Load()
-    if err != nil {
-        log.Fatal("Error loading .env file", err)
-    }
-
-    return Config{
-        SupabaseURL: os.Getenv("SUPABASE_URL"),
-        SupabaseKey: os.Getenv("SUPABASE_KEY"),
-        JWTSecret: os.Getenv("JWT_SECRET"),
-    }
+	cfg, _ := load(nil)
+	return cfg
 }
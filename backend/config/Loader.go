@@ -0,0 +1,387 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Sources a setting value can come from, reported by Dump
+const (
+	sourceDefault = "default"
+	sourceFile    = "file"
+	sourceEnv     = "env"
+	sourceEnvFile = "env file"
+	sourceDotenv  = ".env"
+	sourceFlag    = "flag"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setting is one leaf field of Config together with its tag metadata
+type setting struct {
+	key      string // dotted config file key, e.g. "server.addr"
+	env      string
+	def      string
+	usage    string
+	secret   bool
+	required bool
+	value    reflect.Value
+}
+
+// flagName derives the command-line flag from the key, e.g. "server.read_timeout" is "server-read-timeout"
+func (s *setting) flagName() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(s.key)
+}
+
+// Load builds the configuration from every layer and validates it. A flag is
+// registered on flags for each setting before args are parsed, so callers can
+// define their own flags (e.g. -print-config) on the same set beforehand.
+// All validation problems are reported together in the returned error.
+func Load(flags *flag.FlagSet, args []string) (Config, error) {
+	cfg, err := load(&flagLayer{set: flags, args: args})
+	if err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// flagLayer is the command-line layer of Load
+type flagLayer struct {
+	set  *flag.FlagSet
+	args []string
+}
+
+func load(flags *flagLayer) (Config, error) {
+	cfg := Config{sources: make(map[string]string)}
+	settings := collectSettings(reflect.ValueOf(&cfg).Elem(), "")
+	var errs []error
+
+	// Flags are parsed first so -config-file can select the file, but they are applied last
+	flagValues := map[string]string{}
+	if flags != nil {
+		for _, s := range settings {
+			name := s.flagName()
+			record := func(value string) error { flagValues[name] = value; return nil }
+			if s.value.Kind() == reflect.Bool {
+				flags.set.BoolFunc(name, s.usage, record)
+			} else {
+				flags.set.Func(name, s.usage, record)
+			}
+		}
+		if err := flags.set.Parse(flags.args); err != nil {
+			return cfg, err
+		}
+	}
+
+	// 1. Defaults
+	for _, s := range settings {
+		if s.def != "" {
+			errs = append(errs, cfg.apply(s, s.def, sourceDefault))
+		}
+	}
+
+	// A .env file is optional; real environment variables take precedence over it
+	if err := loadDotenv(); err != nil {
+		errs = append(errs, fmt.Errorf(".env: %w", err))
+	}
+
+	// 2. Config file
+	path := flagValues["config-file"]
+	if path == "" {
+		path, _ = lookupEnv("CONFIG_FILE")
+	}
+	if path != "" {
+		errs = append(errs, cfg.applyFile(settings, path))
+	}
+
+	// 3. Environment
+	for _, s := range settings {
+		if s.env == "" {
+			continue
+		}
+		value, source, err := lookupEnvOrFile(s.env)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if source != "" {
+			errs = append(errs, cfg.apply(s, value, source))
+		}
+	}
+
+	// 4. Flags
+	for _, s := range settings {
+		if value, ok := flagValues[s.flagName()]; ok {
+			errs = append(errs, cfg.apply(s, value, sourceFlag))
+		}
+	}
+
+	return cfg, errors.Join(errs...)
+}
+
+// collectSettings walks the config struct, returning its leaf fields in declaration order
+func collectSettings(v reflect.Value, prefix string) []*setting {
+	var settings []*setting
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := field.Tag.Get("config")
+		if key == "" || !field.IsExported() {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			settings = append(settings, collectSettings(v.Field(i), key)...)
+			continue
+		}
+		settings = append(settings, &setting{
+			key:      key,
+			env:      field.Tag.Get("env"),
+			def:      field.Tag.Get("default"),
+			usage:    field.Tag.Get("usage"),
+			secret:   field.Tag.Get("secret") == "true",
+			required: field.Tag.Get("required") == "true",
+			value:    v.Field(i),
+		})
+	}
+	return settings
+}
+
+// apply parses raw into the setting and records where it came from
+func (c *Config) apply(s *setting, raw string, source string) error {
+	if err := setValue(s.value, raw); err != nil {
+		return fmt.Errorf("%s (%s): %w", s.key, source, err)
+	}
+	c.sources[s.key] = source
+	return nil
+}
+
+func setValue(v reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int:
+		i, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(i))
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		v.Set(reflect.ValueOf(splitList(raw)))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// applyFile reads a YAML or TOML file and applies every key it contains
+func (c *Config) applyFile(settings []*setting, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+
+	raw := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return fmt.Errorf("config file %s: unsupported extension, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	byKey := make(map[string]*setting, len(settings))
+	for _, s := range settings {
+		byKey[s.key] = s
+	}
+
+	var errs []error
+	for key, value := range flatten("", raw) {
+		s, ok := byKey[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("config file %s: unknown key %q", path, key))
+			continue
+		}
+		errs = append(errs, c.apply(s, fileValueString(value), sourceFile))
+	}
+	return errors.Join(errs...)
+}
+
+// flatten turns nested file sections into dotted keys
+func flatten(prefix string, values map[string]interface{}) map[string]interface{} {
+	flat := map[string]interface{}{}
+	for key, value := range values {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if section, ok := value.(map[string]interface{}); ok {
+			for k, v := range flatten(key, section) {
+				flat[k] = v
+			}
+			continue
+		}
+		flat[key] = value
+	}
+	return flat
+}
+
+// fileValueString converts a decoded file value into the string form used by the other layers
+func fileValueString(value interface{}) string {
+	if list, ok := value.([]interface{}); ok {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(value)
+}
+
+// dotenv holds the environment variables that were set from .env
+var dotenv = struct {
+	sync.Mutex
+	names map[string]bool
+}{names: make(map[string]bool)}
+
+// loadDotenv reads .env into the environment. Unlike godotenv.Load it is
+// meant to run again on reload: variables that came from .env take the values
+// it has now, or are unset when it no longer has them. Variables that were
+// set before .env was first read are never touched.
+func loadDotenv() error {
+	values, err := godotenv.Read()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	dotenv.Lock()
+	defer dotenv.Unlock()
+	for name := range dotenv.names {
+		if _, ok := values[name]; !ok {
+			os.Unsetenv(name)
+			delete(dotenv.names, name)
+		}
+	}
+	for name, value := range values {
+		if _, set := os.LookupEnv(name); set && !dotenv.names[name] {
+			continue
+		}
+		os.Setenv(name, value)
+		dotenv.names[name] = true
+	}
+	return nil
+}
+
+// envSource names the layer an environment variable came from
+func envSource(name string) string {
+	dotenv.Lock()
+	defer dotenv.Unlock()
+	if dotenv.names[name] {
+		return sourceDotenv
+	}
+	return sourceEnv
+}
+
+func lookupEnv(name string) (string, bool) {
+	value, ok := os.LookupEnv(name)
+	return value, ok && value != ""
+}
+
+// lookupEnvOrFile reads NAME, or the file named by NAME_FILE when NAME is unset
+func lookupEnvOrFile(name string) (value string, source string, err error) {
+	if value, ok := lookupEnv(name); ok {
+		return value, envSource(name), nil
+	}
+	path, ok := lookupEnv(name + "_FILE")
+	if !ok {
+		return "", "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", fmt.Errorf("%s_FILE: %w", name, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), sourceEnvFile, nil
+}
+
+// splitList parses a comma separated value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Dump writes the effective configuration, one setting per line with the
+// layer it came from. Secrets are masked.
+func (c Config) Dump(w io.Writer) {
+	cfg := c
+	for _, s := range collectSettings(reflect.ValueOf(&cfg).Elem(), "") {
+		value := fmt.Sprint(s.value.Interface())
+		if s.value.Kind() == reflect.Slice {
+			value = strings.Join(s.value.Interface().([]string), ",")
+		}
+		if s.secret && value != "" {
+			value = "********"
+		}
+		source := c.sources[s.key]
+		if source == "" {
+			source = "unset"
+		}
+		fmt.Fprintf(w, "%s = %q (%s)\n", s.key, value, source)
+	}
+}
+
+// Changed returns the keys of the settings whose values differ between c and other
+func (c Config) Changed(other Config) []string {
+	before := collectSettings(reflect.ValueOf(&c).Elem(), "")
+	after := collectSettings(reflect.ValueOf(&other).Elem(), "")
+	var keys []string
+	for i, s := range before {
+		if !reflect.DeepEqual(s.value.Interface(), after[i].value.Interface()) {
+			keys = append(keys, s.key)
+		}
+	}
+	return keys
+}
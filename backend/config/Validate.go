@@ -0,0 +1,122 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Validate checks required settings and value ranges, reporting every problem at once
+func (c Config) Validate() error {
+	var errs []error
+	invalid := func(key, format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+
+	cfg := c
+	for _, s := range collectSettings(reflect.ValueOf(&cfg).Elem(), "") {
+		if s.required && s.value.IsZero() {
+			invalid(s.key, "is required (set %s or %s_FILE)", s.env, s.env)
+		}
+	}
+
+	if c.SupabaseURL != "" {
+		if u, err := url.Parse(c.SupabaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid("supabase_url", "must be an absolute http(s) URL")
+		}
+	}
+
+	if !oneOf(c.Log.Level, "debug", "info", "warn", "error") {
+		invalid("log.level", "must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+	if !oneOf(c.Log.Format, "json", "text") {
+		invalid("log.format", "must be json or text, got %q", c.Log.Format)
+	}
+
+	if !oneOf(c.Tracing.Exporter, "otlp", "stdout", "none") {
+		invalid("tracing.exporter", "must be otlp, stdout or none, got %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		invalid("tracing.sample_ratio", "must be between 0 and 1")
+	}
+
+	if (c.Server.TLSCertFile == "") != (c.Server.TLSKeyFile == "") {
+		invalid("server.tls_cert_file", "and server.tls_key_file must be set together")
+	}
+	nonNegative := map[string]interface{}{
+		"server.read_timeout":        c.Server.ReadTimeout,
+		"server.read_header_timeout": c.Server.ReadHeaderTimeout,
+		"server.write_timeout":       c.Server.WriteTimeout,
+		"server.idle_timeout":        c.Server.IdleTimeout,
+		"server.drain_delay":         c.Server.DrainDelay,
+		"server.shutdown_timeout":    c.Server.ShutdownTimeout,
+		"health.check_timeout":       c.Health.CheckTimeout,
+		"health.cache_ttl":           c.Health.CacheTTL,
+		"server.max_header_bytes":    c.Server.MaxHeaderBytes,
+		"server.max_body_bytes":      c.Server.MaxBodyBytes,
+		"server.max_import_bytes":    c.Server.MaxImportBytes,
+		"cors.max_age":               c.CORS.MaxAge,
+		"supabase.timeout":           c.Supabase.Timeout,
+		"supabase.max_idle_conns":    c.Supabase.MaxIdleConns,
+		"supabase.retry_base_delay":  c.Supabase.RetryBaseDelay,
+		"supabase.retry_max_delay":   c.Supabase.RetryMaxDelay,
+		"supabase.breaker_failures":  c.Supabase.BreakerFailures,
+		"supabase.breaker_cooldown":  c.Supabase.BreakerCooldown,
+		"graphql.max_depth":          c.GraphQL.MaxDepth,
+		"graphql.max_complexity":     c.GraphQL.MaxComplexity,
+		"idempotency.ttl":            c.Idempotency.TTL,
+		"idempotency.lock_timeout":   c.Idempotency.LockTimeout,
+		"trash.retention":            c.Trash.Retention,
+		"trash.purge_interval":       c.Trash.PurgeInterval,
+	}
+	for key, value := range nonNegative {
+		if reflect.ValueOf(value).Int() < 0 {
+			invalid(key, "must not be negative")
+		}
+	}
+
+	if c.Supabase.RetryAttempts < 1 {
+		invalid("supabase.retry_attempts", "must be at least 1")
+	}
+
+	if c.Pagination.DefaultLimit < 1 {
+		invalid("pagination.default_limit", "must be at least 1")
+	}
+	if c.Pagination.MaxLimit < c.Pagination.DefaultLimit {
+		invalid("pagination.max_limit", "must not be below pagination.default_limit")
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin != "*" && !strings.Contains(origin, "://") {
+			invalid("cors.allowed_origins", "%q must be \"*\" or include a scheme, e.g. https://app.example.com", origin)
+		}
+	}
+
+	if !slices.Contains(APIVersions, c.API.DefaultVersion) {
+		invalid("api.default_version", "must be a served API version (%s), got %q", strings.Join(APIVersions, ", "), c.API.DefaultVersion)
+	}
+	if c.API.UnversionedSunset != "" {
+		if _, err := time.Parse(time.DateOnly, c.API.UnversionedSunset); err != nil {
+			invalid("api.unversioned_sunset", "must be a date like 2027-06-30, got %q", c.API.UnversionedSunset)
+		}
+	}
+
+	if c.GRPC.Addr != "" && c.Server.SocketPath == "" && c.GRPC.Addr == c.Server.Addr {
+		invalid("grpc.addr", "must differ from server.addr, the gRPC API has its own port")
+	}
+
+	return errors.Join(errs...)
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if strings.EqualFold(value, a) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,14 @@
+package db
+
+import (
+	"database/sql"
+
+	// Registers the "pgx" database/sql driver
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Open connects to Postgres directly, bypassing PostgREST. It is only used
+// when DATABASE_URL is configured; the connection is opened lazily.
+func Open(databaseURL string) (*sql.DB, error) {
+	return sql.Open("pgx", databaseURL)
+}
@@ -0,0 +1,68 @@
+/* Full-text search over games.
+   Each game stores the text search configuration of its language, so title and
+   description are stemmed with the matching dictionary. search_vector is kept
+   up to date by Postgres and indexed with GIN. */
+
+ALTER TABLE games
+    ADD COLUMN language regconfig NOT NULL DEFAULT 'simple';
+
+ALTER TABLE games
+    ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector(language, coalesce(title, '')), 'A') ||
+        setweight(to_tsvector(language, coalesce(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX games_search_vector_idx ON games USING GIN (search_vector);
+
+/* search_games matches every word of search_query as a prefix (for type-ahead),
+   using the dictionary of each game, and returns one page of ranked games with
+   the total and facet counts by subject and difficulty over all matches.
+   Called through PostgREST as POST /rest/v1/rpc/search_games. */
+CREATE OR REPLACE FUNCTION search_games(
+    search_query text,
+    owner_id uuid,
+    search_language regconfig DEFAULT NULL,
+    subject uuid DEFAULT NULL,
+    difficulty int DEFAULT NULL,
+    page_limit int DEFAULT 20,
+    page_offset int DEFAULT 0
+) RETURNS jsonb
+LANGUAGE sql STABLE
+AS $$
+    WITH words AS (
+        SELECT string_agg(quote_literal(word) || ':*', ' & ') AS prefixes
+        FROM regexp_split_to_table(lower(trim(search_query)), '[^[:alnum:]]+') AS word
+        WHERE word <> ''
+    ),
+    matches AS (
+        SELECT g.*, ts_rank(g.search_vector, to_tsquery(g.language, w.prefixes)) AS rank
+        FROM games g, words w
+        WHERE w.prefixes IS NOT NULL
+          AND g.user_id = owner_id
+          AND g.search_vector @@ to_tsquery(g.language, w.prefixes)
+          AND (search_language IS NULL OR g.language = search_language)
+          AND (subject IS NULL OR g.subject_id = subject)
+          AND (difficulty IS NULL OR g.difficulty_level = difficulty)
+    )
+    SELECT jsonb_build_object(
+        'total', (SELECT count(*) FROM matches),
+        'items', coalesce((
+            SELECT jsonb_agg(to_jsonb(page) - 'search_vector' - 'rank')
+            FROM (
+                SELECT * FROM matches
+                ORDER BY rank DESC, title, id
+                LIMIT page_limit OFFSET page_offset
+            ) page
+        ), '[]'::jsonb),
+        'facets', jsonb_build_object(
+            'subject_id', coalesce((
+                SELECT jsonb_agg(jsonb_build_object('value', subject_id, 'count', n) ORDER BY n DESC, subject_id)
+                FROM (SELECT subject_id, count(*) AS n FROM matches GROUP BY subject_id) s
+            ), '[]'::jsonb),
+            'difficulty_level', coalesce((
+                SELECT jsonb_agg(jsonb_build_object('value', difficulty_level, 'count', n) ORDER BY difficulty_level)
+                FROM (SELECT difficulty_level, count(*) AS n FROM matches GROUP BY difficulty_level) d
+            ), '[]'::jsonb)
+        )
+    );
+$$;
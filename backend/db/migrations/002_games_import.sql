@@ -0,0 +1,56 @@
+/* Bulk import of games.
+   external_key identifies a game across imports so re-importing a file updates
+   the games it created instead of duplicating them. Games created through the
+   API have no key; NULLs never conflict, so the unique index ignores them. */
+
+ALTER TABLE games
+    ADD COLUMN external_key text;
+
+CREATE UNIQUE INDEX games_user_external_key_idx ON games (user_id, external_key);
+
+/* import_games upserts records (a JSON array of bulk records, see
+   models.GameRecord) for owner_id by external key. Subjects are matched by
+   name and created when missing. A function call is one transaction, so either
+   every record is written or none is. Returns {"created": n, "updated": n}.
+   Called through PostgREST as POST /rest/v1/rpc/import_games. */
+CREATE OR REPLACE FUNCTION import_games(owner_id uuid, records jsonb)
+RETURNS jsonb
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    created int;
+    updated int;
+BEGIN
+    INSERT INTO subjects (name)
+    SELECT DISTINCT r->>'subject'
+    FROM jsonb_array_elements(records) r
+    WHERE coalesce(r->>'subject', '') <> ''
+    ON CONFLICT (name) DO NOTHING;
+
+    WITH upserted AS (
+        INSERT INTO games (user_id, external_key, title, description, subject_id, difficulty_level, language)
+        SELECT owner_id,
+               r->>'external_key',
+               r->>'title',
+               nullif(r->>'description', ''),
+               s.id,
+               nullif((r->>'difficulty_level')::int, 0),
+               coalesce(nullif(r->>'language', ''), 'simple')::regconfig
+        FROM jsonb_array_elements(records) r
+        LEFT JOIN subjects s ON s.name = r->>'subject'
+        ON CONFLICT (user_id, external_key) DO UPDATE SET
+            title = excluded.title,
+            description = excluded.description,
+            subject_id = excluded.subject_id,
+            difficulty_level = excluded.difficulty_level,
+            language = excluded.language
+        -- xmax is 0 only for rows this statement inserted
+        RETURNING (xmax = 0) AS inserted
+    )
+    SELECT count(*) FILTER (WHERE inserted), count(*) FILTER (WHERE NOT inserted)
+    INTO created, updated
+    FROM upserted;
+
+    RETURN jsonb_build_object('created', created, 'updated', updated);
+END;
+$$;
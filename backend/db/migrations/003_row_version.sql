@@ -0,0 +1,31 @@
+/* Row versions for optimistic concurrency.
+   version counts the updates of a row. The API sends it as the ETag of games
+   and users and only applies a PATCH or DELETE with If-Match when the row is
+   still at that version, checked in the WHERE clause of the same statement,
+   so two editors cannot overwrite each other. */
+
+ALTER TABLE games
+    ADD COLUMN version bigint NOT NULL DEFAULT 1;
+
+ALTER TABLE public.users
+    ADD COLUMN version bigint NOT NULL DEFAULT 1;
+
+/* bump_row_version increments version on every update, including updates that
+   do not come through the API such as import_games. */
+CREATE OR REPLACE FUNCTION bump_row_version()
+RETURNS trigger
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER games_bump_version
+    BEFORE UPDATE ON games
+    FOR EACH ROW EXECUTE FUNCTION bump_row_version();
+
+CREATE TRIGGER users_bump_version
+    BEFORE UPDATE ON public.users
+    FOR EACH ROW EXECUTE FUNCTION bump_row_version();
@@ -0,0 +1,112 @@
+/* Soft deletion of games and users.
+   Deleting through the API sets deleted_at instead of removing the row, so the
+   results and game states that would go with it through ON DELETE CASCADE are
+   kept. Trashed rows are left out of every read, can be restored, and are only
+   removed by the purge job once they have been in the trash longer than the
+   retention window. Apply after 003_row_version.sql. */
+
+ALTER TABLE games
+    ADD COLUMN deleted_at timestamptz;
+
+ALTER TABLE public.users
+    ADD COLUMN deleted_at timestamptz;
+
+/* Trash listings and the purge job only look at trashed rows */
+CREATE INDEX games_trash_idx ON games (user_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX users_trash_idx ON public.users (deleted_at) WHERE deleted_at IS NOT NULL;
+
+/* search_games as in 001_games_search.sql, without trashed games */
+CREATE OR REPLACE FUNCTION search_games(
+    search_query text,
+    owner_id uuid,
+    search_language regconfig DEFAULT NULL,
+    subject uuid DEFAULT NULL,
+    difficulty int DEFAULT NULL,
+    page_limit int DEFAULT 20,
+    page_offset int DEFAULT 0
+) RETURNS jsonb
+LANGUAGE sql STABLE
+AS $$
+    WITH words AS (
+        SELECT string_agg(quote_literal(word) || ':*', ' & ') AS prefixes
+        FROM regexp_split_to_table(lower(trim(search_query)), '[^[:alnum:]]+') AS word
+        WHERE word <> ''
+    ),
+    matches AS (
+        SELECT g.*, ts_rank(g.search_vector, to_tsquery(g.language, w.prefixes)) AS rank
+        FROM games g, words w
+        WHERE w.prefixes IS NOT NULL
+          AND g.user_id = owner_id
+          AND g.deleted_at IS NULL
+          AND g.search_vector @@ to_tsquery(g.language, w.prefixes)
+          AND (search_language IS NULL OR g.language = search_language)
+          AND (subject IS NULL OR g.subject_id = subject)
+          AND (difficulty IS NULL OR g.difficulty_level = difficulty)
+    )
+    SELECT jsonb_build_object(
+        'total', (SELECT count(*) FROM matches),
+        'items', coalesce((
+            SELECT jsonb_agg(to_jsonb(page) - 'search_vector' - 'rank')
+            FROM (
+                SELECT * FROM matches
+                ORDER BY rank DESC, title, id
+                LIMIT page_limit OFFSET page_offset
+            ) page
+        ), '[]'::jsonb),
+        'facets', jsonb_build_object(
+            'subject_id', coalesce((
+                SELECT jsonb_agg(jsonb_build_object('value', subject_id, 'count', n) ORDER BY n DESC, subject_id)
+                FROM (SELECT subject_id, count(*) AS n FROM matches GROUP BY subject_id) s
+            ), '[]'::jsonb),
+            'difficulty_level', coalesce((
+                SELECT jsonb_agg(jsonb_build_object('value', difficulty_level, 'count', n) ORDER BY difficulty_level)
+                FROM (SELECT difficulty_level, count(*) AS n FROM matches GROUP BY difficulty_level) d
+            ), '[]'::jsonb)
+        )
+    );
+$$;
+
+/* import_games as in 002_games_import.sql. Importing the external key of a
+   trashed game restores it, so re-importing a file brings back what it created. */
+CREATE OR REPLACE FUNCTION import_games(owner_id uuid, records jsonb)
+RETURNS jsonb
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    created int;
+    updated int;
+BEGIN
+    INSERT INTO subjects (name)
+    SELECT DISTINCT r->>'subject'
+    FROM jsonb_array_elements(records) r
+    WHERE coalesce(r->>'subject', '') <> ''
+    ON CONFLICT (name) DO NOTHING;
+
+    WITH upserted AS (
+        INSERT INTO games (user_id, external_key, title, description, subject_id, difficulty_level, language)
+        SELECT owner_id,
+               r->>'external_key',
+               r->>'title',
+               nullif(r->>'description', ''),
+               s.id,
+               nullif((r->>'difficulty_level')::int, 0),
+               coalesce(nullif(r->>'language', ''), 'simple')::regconfig
+        FROM jsonb_array_elements(records) r
+        LEFT JOIN subjects s ON s.name = r->>'subject'
+        ON CONFLICT (user_id, external_key) DO UPDATE SET
+            title = excluded.title,
+            description = excluded.description,
+            subject_id = excluded.subject_id,
+            difficulty_level = excluded.difficulty_level,
+            language = excluded.language,
+            deleted_at = NULL
+        -- xmax is 0 only for rows this statement inserted
+        RETURNING (xmax = 0) AS inserted
+    )
+    SELECT count(*) FILTER (WHERE inserted), count(*) FILTER (WHERE NOT inserted)
+    INTO created, updated
+    FROM upserted;
+
+    RETURN jsonb_build_object('created', created, 'updated', updated);
+END;
+$$;
@@ -0,0 +1,24 @@
+/* Every game gets an external key, so exporting games and importing the file
+   again updates them instead of creating copies. Games created through the API
+   or before the import migration had none; their key is their ID, which is also
+   what the API sets on new games (see GameService.CreateGame). */
+
+UPDATE games SET external_key = id::text WHERE external_key IS NULL;
+
+/* Inserts that leave the key out, e.g. from the SQL editor, get their ID too.
+   Column defaults are applied before BEFORE triggers run, so NEW.id is set. */
+CREATE OR REPLACE FUNCTION default_external_key()
+RETURNS trigger
+LANGUAGE plpgsql
+AS $$
+BEGIN
+    NEW.external_key := coalesce(NEW.external_key, NEW.id::text);
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER games_default_external_key
+    BEFORE INSERT ON games
+    FOR EACH ROW EXECUTE FUNCTION default_external_key();
+
+ALTER TABLE games ALTER COLUMN external_key SET NOT NULL;
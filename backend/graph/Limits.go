@@ -0,0 +1,165 @@
+package graph
+
+import (
+	"backend/utils"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits bound the cost of a query. Both are checked before any field is
+// resolved; zero disables a limit. Introspection fields are not counted.
+type Limits struct {
+	// MaxDepth is the deepest nesting of fields, counting root fields as 1
+	MaxDepth int
+	// MaxComplexity caps the fields a query may resolve. Each field counts 1,
+	// and the fields below a field with a first argument count once per item.
+	MaxComplexity int
+}
+
+// check measures the operation named operationName, or the only operation of doc
+func (l Limits) check(schema *graphql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}, defaultPage, maxPage int) *codedError {
+	m := measurer{
+		schema:      schema,
+		fragments:   make(map[string]*ast.FragmentDefinition),
+		variables:   variables,
+		defaultPage: defaultPage,
+		maxPage:     maxPage,
+	}
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch d := definition.(type) {
+		case *ast.FragmentDefinition:
+			m.fragments[d.Name.Value] = d
+		case *ast.OperationDefinition:
+			if operationName == "" || (d.Name != nil && d.Name.Value == operationName) {
+				operation = d
+			}
+		}
+	}
+	if operation == nil {
+		return nil // the executor reports the missing operation
+	}
+
+	depth, complexity := m.measure(operation.SelectionSet, schema.QueryType(), 1)
+	if l.MaxDepth > 0 && depth > l.MaxDepth {
+		return &codedError{utils.CodeQueryTooComplex, fmt.Sprintf("The query is nested %d levels deep, at most %d are allowed", depth, l.MaxDepth)}
+	}
+	if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+		return &codedError{utils.CodeQueryTooComplex, fmt.Sprintf("The query may resolve %d fields, at most %d are allowed", complexity, l.MaxComplexity)}
+	}
+	return nil
+}
+
+// measurer walks the selections of a validated query
+type measurer struct {
+	schema      *graphql.Schema
+	fragments   map[string]*ast.FragmentDefinition
+	variables   map[string]interface{}
+	defaultPage int
+	maxPage     int
+}
+
+// measure returns the depth and complexity of the selections of set on parent,
+// a set nested depth levels deep
+func (m *measurer) measure(set *ast.SelectionSet, parent graphql.Type, depth int) (int, int) {
+	if set == nil {
+		return depth - 1, 0
+	}
+	deepest, complexity := depth, 0
+	for _, selection := range set.Selections {
+		var d, c int
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+			d, c = m.measureField(s, parent, depth)
+		case *ast.InlineFragment:
+			d, c = m.measure(s.SelectionSet, m.typeCondition(s.TypeCondition, parent), depth)
+		case *ast.FragmentSpread:
+			fragment, ok := m.fragments[s.Name.Value]
+			if !ok {
+				continue
+			}
+			d, c = m.measure(fragment.SelectionSet, m.typeCondition(fragment.TypeCondition, parent), depth)
+		}
+		deepest = max(deepest, d)
+		complexity += c
+	}
+	return deepest, complexity
+}
+
+func (m *measurer) measureField(field *ast.Field, parent graphql.Type, depth int) (int, int) {
+	object, ok := parent.(*graphql.Object)
+	if !ok {
+		return depth, 1
+	}
+	definition, ok := object.Fields()[field.Name.Value]
+	if !ok {
+		return depth, 1
+	}
+
+	d, c := m.measure(field.SelectionSet, namedType(definition.Type), depth+1)
+	items := 1
+	for _, arg := range definition.Args {
+		if arg.Name() == "first" {
+			items = m.first(field)
+		}
+	}
+	return d, 1 + items*c
+}
+
+// first returns the page size a field asks for, capped at the largest page
+func (m *measurer) first(field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "first" {
+			continue
+		}
+		n := m.defaultPage
+		switch v := arg.Value.(type) {
+		case *ast.IntValue:
+			n, _ = strconv.Atoi(v.Value)
+		case *ast.Variable:
+			switch value := m.variables[v.Name.Value].(type) {
+			case float64:
+				n = int(value)
+			case int:
+				n = value
+			case json.Number:
+				parsed, _ := value.Int64()
+				n = int(parsed)
+			}
+		}
+		return min(max(n, 0), m.maxPage)
+	}
+	return m.defaultPage
+}
+
+func (m *measurer) typeCondition(condition *ast.Named, parent graphql.Type) graphql.Type {
+	if condition == nil {
+		return parent
+	}
+	if t := m.schema.Type(condition.Name.Value); t != nil {
+		return t
+	}
+	return parent
+}
+
+// namedType strips the list and non-null wrappers of t
+func namedType(t graphql.Type) graphql.Type {
+	for {
+		switch wrapped := t.(type) {
+		case *graphql.List:
+			t = wrapped.OfType
+		case *graphql.NonNull:
+			t = wrapped.OfType
+		default:
+			return t
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package graph
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// loader batches the IDs requested while one level of a query is resolved and
+// fetches them with a single call. Resolvers return the thunk of Load, which
+// the executor only calls after every sibling field has queued its ID, so a
+// list of 25 games loads their subjects with one request instead of 25.
+// Loaded values are kept for the rest of the request.
+type loader[V any] struct {
+	fetch   func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]V, error)
+	mu      sync.Mutex
+	pending []uuid.UUID
+	entries map[uuid.UUID]*entry[V]
+}
+
+type entry[V any] struct {
+	loaded bool
+	value  V
+	err    error
+}
+
+func newLoader[V any](fetch func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]V, error)) *loader[V] {
+	return &loader[V]{fetch: fetch, entries: make(map[uuid.UUID]*entry[V])}
+}
+
+// Load queues id and returns a thunk yielding its value, the zero value when
+// the fetch returned none
+func (l *loader[V]) Load(ctx context.Context, id uuid.UUID) func() (V, error) {
+	l.mu.Lock()
+	e, ok := l.entries[id]
+	if !ok {
+		e = &entry[V]{}
+		l.entries[id] = e
+		l.pending = append(l.pending, id)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if !e.loaded {
+			l.dispatch(ctx)
+		}
+		return e.value, e.err
+	}
+}
+
+// dispatch fetches every pending ID. The caller holds mu.
+func (l *loader[V]) dispatch(ctx context.Context) {
+	ids := l.pending
+	l.pending = nil
+	values, err := l.fetch(ctx, ids)
+	for _, id := range ids {
+		e := l.entries[id]
+		e.loaded, e.value, e.err = true, values[id], err
+	}
+}
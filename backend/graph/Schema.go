@@ -0,0 +1,515 @@
+package graph
+
+import (
+	"backend/models"
+	"backend/pagination"
+	"backend/services"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Fixed sort orders of the list fields. They are written like the sort
+// parameter of the REST list endpoints, so cursors work in both.
+var (
+	gamesSort    = []services.SortKey{{Column: "created_at", Descending: true}, {Column: "id", Descending: true}}
+	subjectsSort = []services.SortKey{{Column: "name"}, {Column: "id"}}
+	resultsSort  = []services.SortKey{{Column: "completed_at", Descending: true}, {Column: "id", Descending: true}}
+)
+
+// DateTime is an RFC 3339 timestamp, null when unset
+var DateTime = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "DateTime",
+	Description: "An RFC 3339 timestamp",
+	Serialize: func(value interface{}) interface{} {
+		if ts, ok := value.(models.Timestamp); ok && !ts.IsZero() {
+			return ts.Format(time.RFC3339Nano)
+		}
+		return nil
+	},
+	ParseValue:   func(value interface{}) interface{} { return nil },
+	ParseLiteral: func(valueAST ast.Value) interface{} { return nil },
+})
+
+// JSON is an arbitrary JSON value
+var JSON = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "An arbitrary JSON value",
+	Serialize: func(value interface{}) interface{} {
+		if raw, ok := value.(json.RawMessage); ok && len(raw) > 0 {
+			return raw
+		}
+		return nil
+	},
+	ParseValue:   func(value interface{}) interface{} { return nil },
+	ParseLiteral: func(valueAST ast.Value) interface{} { return nil },
+})
+
+// connection is the value of a list field
+type connection struct {
+	Nodes      interface{}
+	TotalCount *int
+	PageInfo   pageInfo
+}
+
+type pageInfo struct {
+	EndCursor   *string
+	HasNextPage bool
+}
+
+// requestKey stores the requestState in the context of a request
+type requestKey struct{}
+
+// requestState is the caller and the loaders of one request, so loaded rows
+// are shared by every field of that request and no other
+type requestState struct {
+	principal Principal
+	users     *loader[*models.User]
+	games     *loader[*models.Game]
+	subjects  *loader[*models.Subject]
+	states    *loader[*models.GameState]
+	results   *loader[[]models.GameResult]
+}
+
+func (s *Server) newRequestState(principal Principal) *requestState {
+	userID := principal.UserID
+	return &requestState{
+		principal: principal,
+		users: newLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.User, error) {
+			rows, err := s.services.Users.GetUsersByIDs(ctx, ids)
+			return byID(rows, func(u models.User) uuid.UUID { return u.ID }), err
+		}),
+		games: newLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.Game, error) {
+			rows, err := s.services.Games.FetchGamesByIDs(ctx, userID, ids)
+			return byID(rows, func(g models.Game) uuid.UUID { return g.ID }), err
+		}),
+		subjects: newLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.Subject, error) {
+			rows, err := s.services.Subjects.FetchSubjectsByIDs(ctx, ids)
+			return byID(rows, func(s models.Subject) uuid.UUID { return s.ID }), err
+		}),
+		states: newLoader(func(ctx context.Context, gameIDs []uuid.UUID) (map[uuid.UUID]*models.GameState, error) {
+			rows, err := s.services.States.FetchStates(ctx, userID, gameIDs)
+			return byID(rows, func(s models.GameState) uuid.UUID { return s.GameID }), err
+		}),
+		results: newLoader(func(ctx context.Context, gameIDs []uuid.UUID) (map[uuid.UUID][]models.GameResult, error) {
+			rows, err := s.services.Results.ResultsForGames(ctx, userID, gameIDs)
+			grouped := make(map[uuid.UUID][]models.GameResult)
+			for _, row := range rows {
+				grouped[row.GameID] = append(grouped[row.GameID], row)
+			}
+			return grouped, err
+		}),
+	}
+}
+
+func stateOf(ctx context.Context) *requestState {
+	return ctx.Value(requestKey{}).(*requestState)
+}
+
+// byID indexes rows by their ID
+func byID[T any](rows []T, id func(T) uuid.UUID) map[uuid.UUID]*T {
+	indexed := make(map[uuid.UUID]*T, len(rows))
+	for i := range rows {
+		indexed[id(rows[i])] = &rows[i]
+	}
+	return indexed
+}
+
+// thunk defers a loader result until the executor asks for it
+func thunk[V any](load func() (V, error)) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		return load()
+	}
+}
+
+// buildSchema defines the types of the schema and how their fields resolve
+func (s *Server) buildSchema() (graphql.Schema, error) {
+	pageArgs := graphql.FieldConfigArgument{
+		"first": {Type: graphql.Int, Description: fmt.Sprintf("Page size, %d by default and at most %d", s.opts.DefaultPageSize, s.opts.MaxPageSize)},
+		"after": {Type: graphql.String, Description: "endCursor of the previous page"},
+	}
+	withPageArgs := func(extra graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+		args := graphql.FieldConfigArgument{}
+		for name, arg := range pageArgs {
+			args[name] = arg
+		}
+		for name, arg := range extra {
+			args[name] = arg
+		}
+		return args
+	}
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"endCursor":   {Type: graphql.String, Description: "Cursor to pass as after for the next page"},
+			"hasNextPage": {Type: graphql.NewNonNull(graphql.Boolean)},
+		},
+	})
+	connectionType := func(node *graphql.Object) *graphql.Object {
+		return graphql.NewObject(graphql.ObjectConfig{
+			Name: node.Name() + "Connection",
+			Fields: graphql.Fields{
+				"nodes":      {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(node)))},
+				"totalCount": {Type: graphql.Int, Description: "Items across all pages"},
+				"pageInfo":   {Type: graphql.NewNonNull(pageInfoType)},
+			},
+		})
+	}
+
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":        {Type: graphql.NewNonNull(graphql.ID)},
+			"email":     {Type: graphql.NewNonNull(graphql.String)},
+			"role":      {Type: graphql.NewNonNull(graphql.String)},
+			"createdAt": {Type: DateTime},
+		},
+	})
+	subjectType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Subject",
+		Description: "A topic that games belong to",
+		Fields: graphql.Fields{
+			"id":        {Type: graphql.NewNonNull(graphql.ID)},
+			"name":      {Type: graphql.NewNonNull(graphql.String)},
+			"createdAt": {Type: DateTime},
+		},
+	})
+	gameType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Game",
+		Fields: graphql.Fields{
+			"id":          {Type: graphql.NewNonNull(graphql.ID)},
+			"title":       {Type: graphql.NewNonNull(graphql.String)},
+			"description": {Type: graphql.NewNonNull(graphql.String)},
+			"difficulty":  {Type: graphql.NewNonNull(graphql.Int), Description: "1 to 5"},
+			"language":    {Type: graphql.NewNonNull(graphql.String), Description: "Text search configuration, e.g. english"},
+			"externalKey": {Type: graphql.String, Description: "Key of games created by a bulk import"},
+			"createdAt":   {Type: DateTime},
+			"subject": {Type: subjectType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				game := p.Source.(*models.Game)
+				if game.SubjectID == nil {
+					return nil, nil
+				}
+				return thunk(stateOf(p.Context).subjects.Load(p.Context, *game.SubjectID)), nil
+			}},
+		},
+	})
+	stateType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "GameState",
+		Description: "The saved progress of the caller in a game",
+		Fields: graphql.Fields{
+			"game": {Type: gameType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return thunk(stateOf(p.Context).games.Load(p.Context, p.Source.(*models.GameState).GameID)), nil
+			}},
+			"data": {Type: graphql.NewNonNull(JSON), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*models.GameState).StateData, nil
+			}},
+			"lastUpdated": {Type: DateTime},
+		},
+	})
+	resultType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "GameResult",
+		Description: "The outcome of a completed game",
+		Fields: graphql.Fields{
+			"id":             {Type: graphql.NewNonNull(graphql.ID)},
+			"score":          {Type: graphql.Int, Description: "0 to 100"},
+			"completionTime": {Type: graphql.String, Description: "Postgres interval, e.g. 00:04:31"},
+			"completedAt":    {Type: DateTime},
+			"game": {Type: gameType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return thunk(stateOf(p.Context).games.Load(p.Context, p.Source.(*models.GameResult).GameID)), nil
+			}},
+			"user": {Type: userType, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return thunk(stateOf(p.Context).users.Load(p.Context, p.Source.(*models.GameResult).UserID)), nil
+			}},
+		},
+	})
+
+	gameType.AddFieldConfig("state", &graphql.Field{
+		Type:        stateType,
+		Description: "Saved progress of the caller, null when there is none",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return thunk(stateOf(p.Context).states.Load(p.Context, p.Source.(*models.Game).ID)), nil
+		},
+	})
+	gameType.AddFieldConfig("bestResult", &graphql.Field{
+		Type:        resultType,
+		Description: "Highest scoring result of the caller, the latest of equal ones",
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			load := stateOf(p.Context).results.Load(p.Context, p.Source.(*models.Game).ID)
+			return func() (interface{}, error) {
+				results, err := load()
+				if err != nil || len(results) == 0 {
+					return nil, err
+				}
+				best := &results[0]
+				for i := range results {
+					if score(&results[i]) > score(best) {
+						best = &results[i]
+					}
+				}
+				return best, nil
+			}, nil
+		},
+	})
+	gameType.AddFieldConfig("results", &graphql.Field{
+		Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(resultType))),
+		Description: "Results of the caller, latest first",
+		Args:        graphql.FieldConfigArgument{"first": pageArgs["first"]},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			first, err := s.first(p.Args)
+			if err != nil {
+				return nil, err
+			}
+			load := stateOf(p.Context).results.Load(p.Context, p.Source.(*models.Game).ID)
+			return func() (interface{}, error) {
+				results, err := load()
+				if err != nil {
+					return nil, err
+				}
+				return pointers(results[:min(first, len(results))]), nil
+			}, nil
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": {
+				Type:        graphql.NewNonNull(userType),
+				Description: "The caller",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return s.loadUser(p.Context, stateOf(p.Context).principal.UserID), nil
+				},
+			},
+			"user": {
+				Type:        userType,
+				Description: "A user; only administrators may read other users",
+				Args:        graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := idArg(p.Args, "id")
+					if err != nil {
+						return nil, err
+					}
+					principal := stateOf(p.Context).principal
+					if id != principal.UserID && principal.Role != "admin" {
+						return nil, errForbidden
+					}
+					return s.loadUser(p.Context, id), nil
+				},
+			},
+			"game": {
+				Type:        gameType,
+				Description: "A game of the caller, null when there is none with this ID",
+				Args:        graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := idArg(p.Args, "id")
+					if err != nil {
+						return nil, err
+					}
+					return thunk(stateOf(p.Context).games.Load(p.Context, id)), nil
+				},
+			},
+			"games": {
+				Type:        graphql.NewNonNull(connectionType(gameType)),
+				Description: "Games of the caller, newest first",
+				Args: withPageArgs(graphql.FieldConfigArgument{
+					"subjectId":  {Type: graphql.ID},
+					"difficulty": {Type: graphql.Int},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					opts, err := s.listOptions(p, gamesSort)
+					if err != nil {
+						return nil, err
+					}
+					if _, ok := p.Args["subjectId"]; ok {
+						subjectID, err := idArg(p.Args, "subjectId")
+						if err != nil {
+							return nil, err
+						}
+						opts.Filters = append(opts.Filters, services.Filter{Column: "subject_id", Operator: services.OpEq, Value: subjectID})
+					}
+					if difficulty, ok := p.Args["difficulty"].(int); ok {
+						opts.Filters = append(opts.Filters, services.Filter{Column: "difficulty_level", Operator: services.OpEq, Value: difficulty})
+					}
+					page, err := s.services.Games.FetchGames(p.Context, stateOf(p.Context).principal.UserID, opts)
+					return s.connection(page, gamesSort), err
+				},
+			},
+			"subject": {
+				Type: subjectType,
+				Args: graphql.FieldConfigArgument{"id": {Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := idArg(p.Args, "id")
+					if err != nil {
+						return nil, err
+					}
+					return thunk(stateOf(p.Context).subjects.Load(p.Context, id)), nil
+				},
+			},
+			"subjects": {
+				Type:        graphql.NewNonNull(connectionType(subjectType)),
+				Description: "Subjects by name",
+				Args:        withPageArgs(nil),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					opts, err := s.listOptions(p, subjectsSort)
+					if err != nil {
+						return nil, err
+					}
+					page, err := s.services.Subjects.ListSubjects(p.Context, opts)
+					return s.connection(page, subjectsSort), err
+				},
+			},
+			"results": {
+				Type:        graphql.NewNonNull(connectionType(resultType)),
+				Description: "Game results of the caller, latest first",
+				Args:        withPageArgs(graphql.FieldConfigArgument{"gameId": {Type: graphql.ID}}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					opts, err := s.listOptions(p, resultsSort)
+					if err != nil {
+						return nil, err
+					}
+					if _, ok := p.Args["gameId"]; ok {
+						gameID, err := idArg(p.Args, "gameId")
+						if err != nil {
+							return nil, err
+						}
+						opts.Filters = append(opts.Filters, services.Filter{Column: "game_id", Operator: services.OpEq, Value: gameID})
+					}
+					page, err := s.services.Results.ListResults(p.Context, stateOf(p.Context).principal.UserID, opts)
+					return s.connection(page, resultsSort), err
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// loadUser loads a user that must exist
+func (s *Server) loadUser(ctx context.Context, id uuid.UUID) func() (interface{}, error) {
+	load := stateOf(ctx).users.Load(ctx, id)
+	return func() (interface{}, error) {
+		user, err := load()
+		if err == nil && user == nil {
+			err = fmt.Errorf("user %s: %w", id, services.ErrNotFound)
+		}
+		return user, err
+	}
+}
+
+// listOptions reads the first and after arguments of a list field. Rows are
+// only counted when the query selects totalCount.
+func (s *Server) listOptions(p graphql.ResolveParams, sort []services.SortKey) (services.ListOptions, error) {
+	opts := services.ListOptions{Sort: sort, NoCount: !selects(p.Info, "totalCount")}
+	first, err := s.first(p.Args)
+	if err != nil {
+		return opts, err
+	}
+	opts.Limit = first
+	if after, ok := p.Args["after"].(string); ok {
+		cursor, err := s.cursors.Decode(after, services.FormatSort(sort))
+		if err != nil || len(cursor.After) != len(sort) {
+			return opts, invalidArgument("after is not a cursor of this list")
+		}
+		opts.After = cursor.After
+	}
+	return opts, nil
+}
+
+func (s *Server) first(args map[string]interface{}) (int, error) {
+	first, ok := args["first"].(int)
+	if !ok {
+		return s.opts.DefaultPageSize, nil
+	}
+	if first < 1 || first > s.opts.MaxPageSize {
+		return 0, invalidArgument("first must be between 1 and " + strconv.Itoa(s.opts.MaxPageSize))
+	}
+	return first, nil
+}
+
+// connection wraps a page for the Connection types
+func (s *Server) connection(page any, sort []services.SortKey) *connection {
+	var c connection
+	var next []*string
+	var total int
+	switch p := page.(type) {
+	case services.Page[models.Game]:
+		c.Nodes, next, total = pointers(p.Items), p.Next, p.Total
+	case services.Page[models.Subject]:
+		c.Nodes, next, total = pointers(p.Items), p.Next, p.Total
+	case services.Page[models.GameResult]:
+		c.Nodes, next, total = pointers(p.Items), p.Next, p.Total
+	}
+	if total >= 0 {
+		c.TotalCount = &total
+	}
+	if next != nil {
+		cursor := s.cursors.Encode(pagination.Cursor{Sort: services.FormatSort(sort), After: next})
+		c.PageInfo = pageInfo{EndCursor: &cursor, HasNextPage: true}
+	}
+	return &c
+}
+
+// selects reports whether the field being resolved selects child, directly or through fragments
+func selects(info graphql.ResolveInfo, child string) bool {
+	var walk func(set *ast.SelectionSet) bool
+	walk = func(set *ast.SelectionSet) bool {
+		if set == nil {
+			return false
+		}
+		for _, selection := range set.Selections {
+			switch s := selection.(type) {
+			case *ast.Field:
+				if s.Name.Value == child {
+					return true
+				}
+			case *ast.InlineFragment:
+				if walk(s.SelectionSet) {
+					return true
+				}
+			case *ast.FragmentSpread:
+				if fragment, ok := info.Fragments[s.Name.Value].(*ast.FragmentDefinition); ok && walk(fragment.SelectionSet) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	for _, field := range info.FieldASTs {
+		if walk(field.SelectionSet) {
+			return true
+		}
+	}
+	return false
+}
+
+func idArg(args map[string]interface{}, name string) (uuid.UUID, error) {
+	raw, _ := args[name].(string)
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, invalidArgument(name + " must be a UUID")
+	}
+	return id, nil
+}
+
+// score orders results without a score below all others
+func score(r *models.GameResult) int {
+	if r.Score == nil {
+		return -1
+	}
+	return *r.Score
+}
+
+func pointers[T any](items []T) []*T {
+	ptrs := make([]*T, len(items))
+	for i := range items {
+		ptrs[i] = &items[i]
+	}
+	return ptrs
+}
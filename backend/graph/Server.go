@@ -0,0 +1,205 @@
+package graph
+
+import (
+	"backend/models"
+	"backend/pagination"
+	"backend/services"
+	"backend/utils"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/location"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// UserReader reads users for the schema
+type UserReader interface {
+	GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]models.User, error)
+}
+
+// GameReader reads the games of a user for the schema
+type GameReader interface {
+	FetchGames(ctx context.Context, userID uuid.UUID, opts services.ListOptions) (services.Page[models.Game], error)
+	FetchGamesByIDs(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]models.Game, error)
+}
+
+// SubjectReader reads subjects for the schema
+type SubjectReader interface {
+	ListSubjects(ctx context.Context, opts services.ListOptions) (services.Page[models.Subject], error)
+	FetchSubjectsByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Subject, error)
+}
+
+// StateReader reads the saved game states of a user for the schema
+type StateReader interface {
+	FetchStates(ctx context.Context, userID uuid.UUID, gameIDs []uuid.UUID) ([]models.GameState, error)
+}
+
+// ResultReader reads the game results of a user for the schema
+type ResultReader interface {
+	ListResults(ctx context.Context, userID uuid.UUID, opts services.ListOptions) (services.Page[models.GameResult], error)
+	ResultsForGames(ctx context.Context, userID uuid.UUID, gameIDs []uuid.UUID) ([]models.GameResult, error)
+}
+
+// Services are the reads the schema resolves fields with
+type Services struct {
+	Users    UserReader
+	Games    GameReader
+	Subjects SubjectReader
+	States   StateReader
+	Results  ResultReader
+}
+
+// Options bound the size of queries and of their pages
+type Options struct {
+	Limits
+	DefaultPageSize int // items of a list field without a first argument
+	MaxPageSize     int // largest first argument
+}
+
+// Principal is the authenticated caller, as set by middleware.ValidateJWT
+type Principal struct {
+	UserID uuid.UUID
+	Role   string
+}
+
+// Request is a GraphQL request as sent over HTTP
+type Request struct {
+	Query         string                 `json:"query" validate:"required"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	// Extensions are accepted since common clients send them, and ignored
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// Result is the response to a Request
+type Result struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error is a GraphQL error. Extensions carry a stable code from package utils.
+type Error struct {
+	Message    string                    `json:"message"`
+	Locations  []location.SourceLocation `json:"locations,omitempty"`
+	Path       []interface{}             `json:"path,omitempty"`
+	Extensions map[string]interface{}    `json:"extensions,omitempty"`
+
+	// Err is the service error a resolver failed with. The caller maps it to a
+	// code and a message fit for clients, since it may hold upstream details.
+	Err error `json:"-"`
+}
+
+// codedError is a resolver error whose message is meant for the client
+type codedError struct {
+	code    string
+	message string
+}
+
+func (e *codedError) Error() string { return e.message }
+
+// errForbidden is returned for data of other users
+var errForbidden = &codedError{utils.CodeForbidden, "Only administrators may read other users"}
+
+func invalidArgument(message string) error {
+	return &codedError{utils.CodeValidationFailed, message}
+}
+
+// Server executes GraphQL requests against the schema
+type Server struct {
+	schema   graphql.Schema
+	services Services
+	cursors  *pagination.Codec
+	opts     Options
+}
+
+// NewServer builds the schema. Page cursors are signed with cursors, so they
+// are interchangeable with those of the REST list endpoints.
+func NewServer(s Services, cursors *pagination.Codec, opts Options) (*Server, error) {
+	server := &Server{services: s, cursors: cursors, opts: opts}
+	schema, err := server.buildSchema()
+	if err != nil {
+		return nil, err
+	}
+	server.schema = schema
+	return server, nil
+}
+
+// Execute runs req for principal. Invalid or too expensive queries are
+// rejected before any field is resolved.
+func (s *Server) Execute(ctx context.Context, principal Principal, req Request) *Result {
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(req.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		return &Result{Errors: convertErrors(gqlerrors.FormatErrors(err), utils.CodeInvalidQuery)}
+	}
+	if validation := graphql.ValidateDocument(&s.schema, doc, nil); !validation.IsValid {
+		return &Result{Errors: convertErrors(validation.Errors, utils.CodeInvalidQuery)}
+	}
+	if err := s.checkLimits(doc, req); err != nil {
+		return &Result{Errors: []*Error{{Message: err.message, Extensions: map[string]interface{}{"code": err.code}}}}
+	}
+
+	ctx = context.WithValue(ctx, requestKey{}, s.newRequestState(principal))
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+	return &Result{Data: result.Data, Errors: convertErrors(result.Errors, utils.CodeInvalidQuery)}
+}
+
+// checkLimits measures the operation of req that will run
+func (s *Server) checkLimits(doc *ast.Document, req Request) *codedError {
+	return s.opts.Limits.check(&s.schema, doc, req.OperationName, req.Variables, s.opts.DefaultPageSize, s.opts.MaxPageSize)
+}
+
+// convertErrors turns executor errors into Errors. Errors that did not come
+// from a resolver, such as syntax errors, get code.
+func convertErrors(errs []gqlerrors.FormattedError, code string) []*Error {
+	if len(errs) == 0 {
+		return nil
+	}
+	converted := make([]*Error, len(errs))
+	for i, formatted := range errs {
+		e := &Error{Message: formatted.Message, Locations: formatted.Locations, Path: formatted.Path}
+		var coded *codedError
+		switch original := originalError(formatted); {
+		case original == nil:
+			e.Extensions = map[string]interface{}{"code": code}
+		case errors.As(original, &coded):
+			e.Message = coded.message
+			e.Extensions = map[string]interface{}{"code": coded.code}
+		default:
+			e.Err = original
+		}
+		converted[i] = e
+	}
+	return converted
+}
+
+// originalError digs the error a resolver returned out of the wrappers the
+// executor adds, or returns nil when the error did not come from a resolver
+func originalError(err error) error {
+	for {
+		switch e := err.(type) {
+		case gqlerrors.FormattedError:
+			err = e.OriginalError()
+		case *gqlerrors.Error:
+			err = e.OriginalError
+		default:
+			return err
+		}
+		if err == nil {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package graph
+
+import (
+	"backend/models"
+	"backend/pagination"
+	"backend/services"
+	"backend/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// fakeServices serves fixed rows and counts the calls of every method
+type fakeServices struct {
+	users    []models.User
+	games    []models.Game
+	subjects []models.Subject
+	states   []models.GameState
+	results  []models.GameResult
+	err      error
+
+	calls   map[string]int
+	listed  services.ListOptions
+	batches map[string][]uuid.UUID
+}
+
+func (f *fakeServices) record(method string, ids []uuid.UUID) {
+	f.calls[method]++
+	f.batches[method] = ids
+}
+
+func (f *fakeServices) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]models.User, error) {
+	f.record("users", ids)
+	return pick(f.users, ids, func(u models.User) uuid.UUID { return u.ID }), f.err
+}
+
+func (f *fakeServices) FetchGames(ctx context.Context, userID uuid.UUID, opts services.ListOptions) (services.Page[models.Game], error) {
+	f.calls["games.list"]++
+	f.listed = opts
+	page := services.Page[models.Game]{Items: f.games, Total: -1}
+	if opts.Limit < len(f.games) {
+		last := f.games[opts.Limit-1].ID.String()
+		page.Items = f.games[:opts.Limit]
+		page.Next = []*string{&last, &last}
+	}
+	if !opts.NoCount {
+		page.Total = len(f.games)
+	}
+	return page, f.err
+}
+
+func (f *fakeServices) FetchGamesByIDs(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]models.Game, error) {
+	f.record("games", ids)
+	return pick(f.games, ids, func(g models.Game) uuid.UUID { return g.ID }), f.err
+}
+
+func (f *fakeServices) ListSubjects(ctx context.Context, opts services.ListOptions) (services.Page[models.Subject], error) {
+	f.calls["subjects.list"]++
+	return services.Page[models.Subject]{Items: f.subjects, Total: -1}, f.err
+}
+
+func (f *fakeServices) FetchSubjectsByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Subject, error) {
+	f.record("subjects", ids)
+	return pick(f.subjects, ids, func(s models.Subject) uuid.UUID { return s.ID }), f.err
+}
+
+func (f *fakeServices) FetchStates(ctx context.Context, userID uuid.UUID, gameIDs []uuid.UUID) ([]models.GameState, error) {
+	f.record("states", gameIDs)
+	return pick(f.states, gameIDs, func(s models.GameState) uuid.UUID { return s.GameID }), f.err
+}
+
+func (f *fakeServices) ListResults(ctx context.Context, userID uuid.UUID, opts services.ListOptions) (services.Page[models.GameResult], error) {
+	f.calls["results.list"]++
+	return services.Page[models.GameResult]{Items: f.results, Total: -1}, f.err
+}
+
+func (f *fakeServices) ResultsForGames(ctx context.Context, userID uuid.UUID, gameIDs []uuid.UUID) ([]models.GameResult, error) {
+	f.record("results", gameIDs)
+	return pick(f.results, gameIDs, func(r models.GameResult) uuid.UUID { return r.GameID }), f.err
+}
+
+func pick[T any](rows []T, ids []uuid.UUID, id func(T) uuid.UUID) []T {
+	var picked []T
+	for _, row := range rows {
+		for _, want := range ids {
+			if id(row) == want {
+				picked = append(picked, row)
+				break
+			}
+		}
+	}
+	return picked
+}
+
+var (
+	callerID = uuid.MustParse("00000000-0000-0000-0000-0000000000aa")
+	otherID  = uuid.MustParse("00000000-0000-0000-0000-0000000000bb")
+)
+
+// newFixture has five games: the first four alternate between two subjects,
+// every game has a saved state and the first one has two results
+func newFixture() *fakeServices {
+	math, art := uuid.New(), uuid.New()
+	f := &fakeServices{
+		users:    []models.User{{ID: callerID, Email: "me@example.com", Role: "user"}, {ID: otherID, Email: "other@example.com", Role: "user"}},
+		subjects: []models.Subject{{ID: math, Name: "Math"}, {ID: art, Name: "Art"}},
+		calls:    make(map[string]int),
+		batches:  make(map[string][]uuid.UUID),
+	}
+	for i := 0; i < 5; i++ {
+		game := models.Game{ID: uuid.New(), Title: "Game", Difficulty: 2, Language: "english"}
+		if i < 4 {
+			subject := []uuid.UUID{math, art}[i%2]
+			game.SubjectID = &subject
+		}
+		f.games = append(f.games, game)
+		f.states = append(f.states, models.GameState{UserID: callerID, GameID: game.ID, StateData: json.RawMessage(`{"level":3}`)})
+	}
+	low, high := 40, 90
+	f.results = []models.GameResult{
+		{ID: uuid.New(), UserID: callerID, GameID: f.games[0].ID, Score: &low},
+		{ID: uuid.New(), UserID: callerID, GameID: f.games[0].ID, Score: &high},
+	}
+	return f
+}
+
+func newTestServer(t *testing.T, f *fakeServices, limits Limits) *Server {
+	t.Helper()
+	server, err := NewServer(
+		Services{Users: f, Games: f, Subjects: f, States: f, Results: f},
+		pagination.NewCodec("secret"),
+		Options{Limits: limits, DefaultPageSize: 25, MaxPageSize: 100},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server
+}
+
+func execute(t *testing.T, server *Server, role, query string, variables map[string]interface{}) (map[string]interface{}, []*Error) {
+	t.Helper()
+	result := server.Execute(context.Background(), Principal{UserID: callerID, Role: role}, Request{Query: query, Variables: variables})
+	data, err := json.Marshal(result.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	json.Unmarshal(data, &decoded)
+	return decoded, result.Errors
+}
+
+func TestNestedFieldsAreLoadedInBatches(t *testing.T) {
+	f := newFixture()
+	server := newTestServer(t, f, Limits{})
+
+	data, errs := execute(t, server, "user", `{
+		games(first: 5) {
+			nodes { title subject { name } state { data game { title } } bestResult { score } }
+		}
+	}`, nil)
+	if errs != nil {
+		t.Fatalf("expected no errors, got %+v", errs[0])
+	}
+
+	for method, want := range map[string]int{"games.list": 1, "subjects": 1, "states": 1, "results": 1, "games": 1} {
+		if f.calls[method] != want {
+			t.Errorf("expected %d %s call(s), got %d", want, method, f.calls[method])
+		}
+	}
+	if len(f.batches["subjects"]) != 2 || len(f.batches["states"]) != 5 {
+		t.Errorf("expected 2 distinct subjects and 5 states per batch, got %v", f.batches)
+	}
+
+	nodes := data["games"].(map[string]interface{})["nodes"].([]interface{})
+	first := nodes[0].(map[string]interface{})
+	if first["subject"].(map[string]interface{})["name"] != "Math" {
+		t.Errorf("expected the subject of the first game, got %v", first["subject"])
+	}
+	if first["bestResult"].(map[string]interface{})["score"] != float64(90) {
+		t.Errorf("expected the highest score as best result, got %v", first["bestResult"])
+	}
+	if first["state"].(map[string]interface{})["data"].(map[string]interface{})["level"] != float64(3) {
+		t.Errorf("expected the saved state as JSON, got %v", first["state"])
+	}
+	if last := nodes[4].(map[string]interface{}); last["subject"] != nil || last["bestResult"] != nil {
+		t.Errorf("expected no subject and no best result for the last game, got %v", last)
+	}
+}
+
+func TestConnectionsPageWithCursors(t *testing.T) {
+	f := newFixture()
+	server := newTestServer(t, f, Limits{})
+
+	data, errs := execute(t, server, "user", `{ games(first: 2) { nodes { id } pageInfo { endCursor hasNextPage } } }`, nil)
+	if errs != nil {
+		t.Fatalf("expected no errors, got %+v", errs[0])
+	}
+	if !f.listed.NoCount {
+		t.Error("expected rows not to be counted without totalCount")
+	}
+	pageInfo := data["games"].(map[string]interface{})["pageInfo"].(map[string]interface{})
+	if pageInfo["hasNextPage"] != true {
+		t.Fatalf("expected a next page, got %v", pageInfo)
+	}
+
+	query := `query Next($after: String) { games(first: 2, after: $after) { totalCount } }`
+	data, errs = execute(t, server, "user", query, map[string]interface{}{"after": pageInfo["endCursor"]})
+	if errs != nil {
+		t.Fatalf("expected the cursor to be accepted, got %+v", errs[0])
+	}
+	if len(f.listed.After) != 2 || f.listed.NoCount {
+		t.Errorf("expected the keyset of the cursor and a count, got %+v", f.listed)
+	}
+	if data["games"].(map[string]interface{})["totalCount"] != float64(5) {
+		t.Errorf("expected totalCount 5, got %v", data["games"])
+	}
+
+	_, errs = execute(t, server, "user", `{ subjects(after: "bogus") { nodes { id } } }`, nil)
+	if len(errs) != 1 || errs[0].Extensions["code"] != utils.CodeValidationFailed {
+		t.Errorf("expected a validation error for a bad cursor, got %+v", errs)
+	}
+}
+
+func TestOtherUsersRequireAdmin(t *testing.T) {
+	f := newFixture()
+	server := newTestServer(t, f, Limits{})
+	query := `query User($id: ID!) { user(id: $id) { email } }`
+	variables := map[string]interface{}{"id": otherID.String()}
+
+	data, errs := execute(t, server, "user", query, variables)
+	if len(errs) != 1 || errs[0].Extensions["code"] != utils.CodeForbidden || data["user"] != nil {
+		t.Errorf("expected a forbidden error for a user, got %v %+v", data, errs)
+	}
+
+	data, errs = execute(t, server, "admin", query, variables)
+	if errs != nil || data["user"].(map[string]interface{})["email"] != "other@example.com" {
+		t.Errorf("expected an admin to read the user, got %v %+v", data, errs)
+	}
+
+	data, errs = execute(t, server, "user", `{ me { email } }`, nil)
+	if errs != nil || data["me"].(map[string]interface{})["email"] != "me@example.com" {
+		t.Errorf("expected the caller, got %v %+v", data, errs)
+	}
+}
+
+func TestLimitsRejectExpensiveQueries(t *testing.T) {
+	f := newFixture()
+	server := newTestServer(t, f, Limits{MaxDepth: 4, MaxComplexity: 200})
+
+	cases := []struct {
+		name, query string
+		variables   map[string]interface{}
+		rejected    string
+	}{
+		{"shallow", `{ games { nodes { title } } }`, nil, ""},
+		{"too deep", `{ games { nodes { state { game { subject { name } } } } } }`, nil, "nested 6 levels"},
+		{"too deep through a fragment", `{ games { nodes { ...Deep } } } fragment Deep on Game { state { game { title } } }`, nil, "nested 5 levels"},
+		{"large pages", `{ games(first: 100) { nodes { title subject { name } } } }`, nil, "may resolve"},
+		{"large page variable", `query Q($n: Int) { games(first: $n) { nodes { title subject { name } } } }`, map[string]interface{}{"n": float64(100)}, "may resolve"},
+		{"introspection", `{ __schema { types { name fields { name type { name ofType { name ofType { name } } } } } } }`, nil, ""},
+	}
+	for _, tc := range cases {
+		f.calls = make(map[string]int)
+		result := server.Execute(context.Background(), Principal{UserID: callerID}, Request{Query: tc.query, Variables: tc.variables})
+		if tc.rejected == "" {
+			if result.Errors != nil {
+				t.Errorf("%s: expected the query to run, got %+v", tc.name, result.Errors[0])
+			}
+			continue
+		}
+		if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != utils.CodeQueryTooComplex || !strings.Contains(result.Errors[0].Message, tc.rejected) {
+			t.Errorf("%s: expected %s rejection, got %+v", tc.name, tc.rejected, result.Errors)
+		}
+		if len(f.calls) != 0 {
+			t.Errorf("%s: expected nothing to be read, got %v", tc.name, f.calls)
+		}
+	}
+}
+
+func TestErrorsKeepTheirCause(t *testing.T) {
+	f := newFixture()
+	server := newTestServer(t, f, Limits{})
+
+	result := server.Execute(context.Background(), Principal{UserID: callerID}, Request{Query: `{ games { nodes { title } } `})
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != utils.CodeInvalidQuery {
+		t.Errorf("expected a syntax error, got %+v", result.Errors)
+	}
+	result = server.Execute(context.Background(), Principal{UserID: callerID}, Request{Query: `{ games { nodes { score } } }`})
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != utils.CodeInvalidQuery {
+		t.Errorf("expected an unknown field error, got %+v", result.Errors)
+	}
+
+	f.err = services.ErrUpstream
+	result = server.Execute(context.Background(), Principal{UserID: callerID}, Request{Query: `{ game(id: "` + f.games[0].ID.String() + `") { title } }`})
+	if len(result.Errors) != 1 || !errors.Is(result.Errors[0].Err, services.ErrUpstream) {
+		t.Errorf("expected the service error to be kept for the caller to map, got %+v", result.Errors)
+	}
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"backend/bulk"
+	"backend/middleware"
+	"backend/utils"
+	"backend/validation"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ImportGamesHandler upserts the games of a CSV, JSON or NDJSON request body by
+// external key and answers with a report listing the invalid rows.
+// ?dry_run=true only validates; ?atomic=true writes nothing unless every row is valid.
+func (h *Handlers) ImportGamesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		utils.WriteError(w, r, http.StatusUnauthorized, utils.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	opts, err := parseImport(r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	clearDeadlines(w)
+	report, err := bulk.Import(r.Context(), r.Body, userID, opts, h.transfer)
+	var maxErr *http.MaxBytesError
+	var problem *utils.Problem
+	switch {
+	case errors.Is(err, bulk.ErrInvalidRows):
+		problem := utils.NewProblem(http.StatusUnprocessableEntity, utils.CodeValidationFailed,
+			fmt.Sprintf("%d of %d rows are invalid, nothing was imported", report.Invalid, report.Rows))
+		utils.WriteProblem(w, r, problem.WithFieldErrors(report.Errors...))
+		return
+	case errors.As(err, &maxErr):
+		problem = bodyErrorProblem(err)
+	case errors.Is(err, bulk.ErrMalformed):
+		problem = utils.NewProblem(http.StatusBadRequest, utils.CodeInvalidBody, err.Error())
+	case err != nil:
+		problem = logServiceError(r, err, "Failed to import games", "rows", report.Rows, "created", report.Created, "updated", report.Updated)
+	default:
+		utils.WriteJSONResponse(w, http.StatusOK, report)
+		return
+	}
+
+	// Batches written before the error stay written; the report tells the client which
+	if written := report.Created + report.Updated; written > 0 {
+		problem.Detail += fmt.Sprintf(" (%d games were imported before the error)", written)
+	}
+	utils.WriteProblem(w, r, problem.WithExtension("report", report))
+}
+
+// ExportGamesHandler streams all of the user's games as CSV, JSON or NDJSON,
+// chosen by ?format= or the Accept header and defaulting to JSON
+func (h *Handlers) ExportGamesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		utils.WriteError(w, r, http.StatusUnauthorized, utils.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	format := bulk.JSON
+	if raw := r.URL.Query().Get("format"); raw != "" {
+		f, err := bulk.ParseFormat(raw)
+		if err != nil {
+			writeBodyError(w, r, validation.Errors{{Field: "format", Code: validation.RuleOneOf, Message: "must be one of csv, json, ndjson"}})
+			return
+		}
+		format = f
+	} else if f, ok := bulk.FormatOf(r.Header.Get("Accept")); ok {
+		format = f
+	}
+
+	clearDeadlines(w)
+	out := &countingWriter{w: w}
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="games.%s"`, format))
+	err := bulk.Export(r.Context(), out, userID, format, h.transfer)
+	if err == nil {
+		return
+	}
+	if out.n == 0 {
+		w.Header().Del("Content-Disposition")
+		writeServiceError(w, r, err, "Failed to export games")
+		return
+	}
+	// The status line is gone; cut the response short so the client sees a truncated file
+	slog.ErrorContext(r.Context(), "Failed to export games", "err", err, "bytes", out.n)
+	panic(http.ErrAbortHandler)
+}
+
+// parseImport reads the format, dry_run and atomic parameters. The format
+// defaults to the one of the Content-Type header.
+func parseImport(r *http.Request) (bulk.Options, error) {
+	params := r.URL.Query()
+	var errs validation.Errors
+	invalid := func(param, code, message string) {
+		errs = append(errs, utils.FieldError{Field: param, Code: code, Message: message})
+	}
+
+	opts := bulk.Options{BatchSize: bulk.DefaultBatchSize}
+	if raw := params.Get("format"); raw != "" {
+		f, err := bulk.ParseFormat(raw)
+		if err != nil {
+			invalid("format", validation.RuleOneOf, "must be one of csv, json, ndjson")
+		}
+		opts.Format = f
+	} else if f, ok := bulk.FormatOf(r.Header.Get("Content-Type")); ok {
+		opts.Format = f
+	} else {
+		invalid("format", validation.RuleRequired, "is required unless Content-Type is text/csv, application/json or application/x-ndjson")
+	}
+	flags := []struct {
+		param string
+		value *bool
+	}{{"dry_run", &opts.DryRun}, {"atomic", &opts.Atomic}}
+	for _, flag := range flags {
+		if raw := params.Get(flag.param); raw != "" {
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				invalid(flag.param, "invalid_value", "must be true or false")
+			}
+			*flag.value = b
+		}
+	}
+
+	if len(errs) > 0 {
+		return opts, errs
+	}
+	return opts, nil
+}
+
+// clearDeadlines lifts the server read and write timeouts, which are sized for
+// ordinary requests, for a bulk transfer of any length
+func clearDeadlines(w http.ResponseWriter) {
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"backend/bulk"
+	"backend/middleware"
+	"backend/models"
+	"backend/services"
+	"backend/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// memoryStore keeps imported games in memory for the bulk handler tests
+type memoryStore struct {
+	games []models.GameRecord
+}
+
+func (s *memoryStore) ImportGames(ctx context.Context, userID uuid.UUID, records []models.GameRecord) (services.ImportResult, error) {
+	s.games = append(s.games, records...)
+	return services.ImportResult{Created: len(records)}, nil
+}
+
+func (s *memoryStore) ExportGames(ctx context.Context, userID uuid.UUID, emit func(models.GameRecord) error) error {
+	for _, g := range s.games {
+		if err := emit(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func bulkRequest(method, target, contentType, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, uuid.New()))
+}
+
+func TestImportGamesHandler(t *testing.T) {
+	store := &memoryStore{}
+	h := &Handlers{transfer: store}
+
+	body := "{\"external_key\":\"a\",\"title\":\"A\"}\n{\"external_key\":\"b\",\"title\":\"\"}\n"
+	rr := httptest.NewRecorder()
+	h.ImportGamesHandler(rr, bulkRequest(http.MethodPost, "/games/import", "application/x-ndjson", body))
+	var report struct {
+		Created int                `json:"created"`
+		Errors  []utils.FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Code != http.StatusOK || report.Created != 1 || len(report.Errors) != 1 || report.Errors[0].Field != "rows[2].title" {
+		t.Errorf("expected one game imported and one row error, got %d %s", rr.Code, rr.Body)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ImportGamesHandler(rr, bulkRequest(http.MethodPost, "/games/import?atomic=true", "application/x-ndjson", body))
+	if p := problem(t, rr); rr.Code != http.StatusUnprocessableEntity || len(p.Errors) != 1 || len(store.games) != 1 {
+		t.Errorf("expected an atomic import with an invalid row to be refused, got %d %+v", rr.Code, p)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ImportGamesHandler(rr, bulkRequest(http.MethodPost, "/games/import?format=json", "", `[{"title":`))
+	if p := problem(t, rr); rr.Code != http.StatusBadRequest || p.Code != utils.CodeInvalidBody {
+		t.Errorf("expected a malformed file to be rejected, got %d %+v", rr.Code, p)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ImportGamesHandler(rr, bulkRequest(http.MethodPost, "/games/import?dry_run=maybe", "text/plain", ""))
+	if p := problem(t, rr); rr.Code != http.StatusBadRequest || len(p.Errors) != 2 {
+		t.Errorf("expected format and dry_run errors, got %d %+v", rr.Code, p)
+	}
+}
+
+func TestImportGamesHandlerReportsWrittenBatchesOnError(t *testing.T) {
+	store := &memoryStore{}
+	h := &Handlers{transfer: store}
+
+	var body strings.Builder
+	for i := 0; i <= bulk.DefaultBatchSize; i++ {
+		fmt.Fprintf(&body, "{\"external_key\":\"k%d\",\"title\":\"T\"}\n", i)
+	}
+	limit := int64(body.Len())
+	body.WriteString(strings.Repeat(" ", 100))
+
+	rr := httptest.NewRecorder()
+	req := bulkRequest(http.MethodPost, "/games/import", "application/x-ndjson", body.String())
+	req.Body = http.MaxBytesReader(rr, req.Body, limit)
+	h.ImportGamesHandler(rr, req)
+
+	var p struct {
+		Code   string      `json:"code"`
+		Report bulk.Report `json:"report"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Code != http.StatusRequestEntityTooLarge || p.Code != utils.CodeBodyTooLarge || p.Report.Created != bulk.DefaultBatchSize || len(store.games) != bulk.DefaultBatchSize {
+		t.Errorf("expected a 413 reporting the first batch as written, got %d %+v", rr.Code, p)
+	}
+}
+
+func TestExportGamesHandler(t *testing.T) {
+	h := &Handlers{transfer: &memoryStore{games: []models.GameRecord{{ExternalKey: "a", Title: "A", Difficulty: 3}}}}
+
+	rr := httptest.NewRecorder()
+	h.ExportGamesHandler(rr, bulkRequest(http.MethodGet, "/games/export?format=csv", "", ""))
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Type") != "text/csv" {
+		t.Fatalf("unexpected response %d %v", rr.Code, rr.Header())
+	}
+	if want := "external_key,title,description,subject,difficulty_level,language\na,A,,,3,\n"; rr.Body.String() != want {
+		t.Errorf("expected %q, got %q", want, rr.Body.String())
+	}
+
+	req := bulkRequest(http.MethodGet, "/games/export", "", "")
+	req.Header.Set("Accept", "application/x-ndjson")
+	rr = httptest.NewRecorder()
+	h.ExportGamesHandler(rr, req)
+	if rr.Header().Get("Content-Type") != "application/x-ndjson" || strings.Count(rr.Body.String(), "\n") != 1 {
+		t.Errorf("expected NDJSON from the Accept header, got %q", rr.Body.String())
+	}
+}
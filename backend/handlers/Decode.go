@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"backend/utils"
+	"backend/validation"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var errTrailingData = errors.New("request body must contain a single JSON value")
+
+// fieldValueError is a value rejected by the type of its field, such as a
+// malformed UUID. encoding/json reports these without the field name.
+type fieldValueError struct {
+	Field string
+	Err   error
+}
+
+func (e *fieldValueError) Error() string { return e.Field + ": " + e.Err.Error() }
+func (e *fieldValueError) Unwrap() error { return e.Err }
+
+// decodeJSON reads a single JSON value into T, rejecting unknown fields and trailing data
+func decodeJSON[T any](r *http.Request) (T, error) {
+	var v T
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return v, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&v); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+			return v, err
+		}
+		if fieldErr := locateFieldError(reflect.TypeOf(v), data); fieldErr != nil {
+			return v, fieldErr
+		}
+		return v, err
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return v, errTrailingData
+	}
+	return v, nil
+}
+
+// locateFieldError decodes each field of the struct type t on its own to find
+// the one whose value was rejected
+func locateFieldError(t reflect.Type, data []byte) *fieldValueError {
+	var raw map[string]json.RawMessage
+	if t.Kind() != reflect.Struct || json.Unmarshal(data, &raw) != nil {
+		return nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "" {
+			name = sf.Name
+		}
+		value, ok := raw[name]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(value, reflect.New(sf.Type).Interface()); err != nil {
+			return &fieldValueError{Field: name, Err: err}
+		}
+	}
+	return nil
+}
+
+// pathUUID parses the named path parameter, answering 400 when it is not a UUID
+func pathUUID(w http.ResponseWriter, r *http.Request, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue(name))
+	if err != nil {
+		problem := utils.NewProblem(http.StatusBadRequest, utils.CodeValidationFailed, "The request has invalid fields")
+		utils.WriteProblem(w, r, problem.WithFieldErrors(utils.FieldError{
+			Field:   name,
+			Code:    validation.RuleUUID,
+			Message: "must be a UUID",
+		}))
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testUserID = "5803acaf-821a-4463-b8b4-15ac6e0e466a"
+
+func TestCreateGameValidation(t *testing.T) {
+	h := &Handlers{}
+	cases := []struct {
+		name   string
+		body   string
+		status int
+		fields map[string]string
+	}{
+		{
+			name:   "all field errors at once",
+			body:   `{"title":"  ","description":"x","difficulty_level":9}`,
+			status: http.StatusBadRequest,
+			fields: map[string]string{"title": "required", "difficulty_level": "max"},
+		},
+		{
+			name:   "malformed uuid",
+			body:   `{"title":"Verbs","subject_id":"42"}`,
+			status: http.StatusBadRequest,
+			fields: map[string]string{"subject_id": "invalid_value"},
+		},
+		{
+			name:   "unknown field",
+			body:   `{"title":"Verbs","owner":"me"}`,
+			status: http.StatusBadRequest,
+			fields: map[string]string{"owner": "unknown_field"},
+		},
+		{
+			name:   "trailing data",
+			body:   `{"title":"Verbs"} {}`,
+			status: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			h.CreateGameHandler(rr, httptest.NewRequest(http.MethodPost, "/games", strings.NewReader(tc.body)))
+
+			if rr.Code != tc.status {
+				t.Fatalf("expected %d, got %d: %s", tc.status, rr.Code, rr.Body)
+			}
+			p := problem(t, rr)
+			if len(p.Errors) != len(tc.fields) {
+				t.Fatalf("expected %d field errors, got %+v", len(tc.fields), p.Errors)
+			}
+			for _, fe := range p.Errors {
+				if tc.fields[fe.Field] != fe.Code {
+					t.Errorf("%s: expected %q, got %q", fe.Field, tc.fields[fe.Field], fe.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateUserValidatesPresentFieldsOnly(t *testing.T) {
+	h := &Handlers{}
+	req := httptest.NewRequest(http.MethodPatch, "/users/"+testUserID, strings.NewReader(`{"role":"owner"}`))
+	req.SetPathValue("id", testUserID)
+	rr := httptest.NewRecorder()
+	h.UpdateUserByIDHandler(rr, req)
+
+	p := problem(t, rr)
+	if rr.Code != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Field != "role" {
+		t.Errorf("expected only role to be rejected, got %d %+v", rr.Code, p.Errors)
+	}
+}
+
+func TestBodyTooLarge(t *testing.T) {
+	h := &Handlers{}
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"email":"ada@example.com","password":"secret"}`))
+	rr := httptest.NewRecorder()
+	req.Body = http.MaxBytesReader(rr, req.Body, 10)
+	h.CreateUserHandler(rr, req)
+
+	if rr.Code != http.StatusRequestEntityTooLarge || problem(t, rr).Code != "body_too_large" {
+		t.Errorf("expected a body_too_large problem, got %d %s", rr.Code, rr.Body)
+	}
+}
+
+func TestPathIDMustBeUUID(t *testing.T) {
+	h := &Handlers{}
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.SetPathValue("id", "42")
+	rr := httptest.NewRecorder()
+	h.GetUserByIDHandler(rr, req)
+
+	p := problem(t, rr)
+	if rr.Code != http.StatusBadRequest || len(p.Errors) != 1 || p.Errors[0].Field != "id" || p.Errors[0].Code != "uuid" {
+		t.Errorf("expected an id field error, got %d %+v", rr.Code, p.Errors)
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"backend/bulk"
+	"backend/openapi"
+	"backend/services"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// listSpecs are the list endpoints by resource, for the API documentation
+var listSpecs = map[string]listSpec{
+	"games":    gamesList,
+	"subjects": subjectsList,
+	"results":  resultsList,
+	"users":    usersList,
+
+	"trashed_games": trashedGamesList,
+	"trashed_users": trashedUsersList,
+}
+
+// ListHeaders are the pagination headers of list responses
+var ListHeaders = map[string]openapi.Header{
+	"X-Total-Count": {Description: "Number of rows matching the filters across all pages", Schema: &openapi.Schema{Type: "integer"}},
+	"X-Next-Cursor": {Description: "Cursor of the next page, absent on the last page", Schema: &openapi.Schema{Type: "string"}},
+	"Link":          {Description: `URL of the next page as rel="next"`, Schema: &openapi.Schema{Type: "string"}},
+}
+
+// ETagHeaders is the version header of single game and user responses
+var ETagHeaders = map[string]openapi.Header{
+	"ETag": {Description: "Strong entity tag of the version returned, for If-Match and If-None-Match", Schema: &openapi.Schema{Type: "string"}},
+}
+
+// ReadParameters are the conditional headers of reading a single game or user
+var ReadParameters = []openapi.Parameter{
+	{Name: "If-None-Match", In: "header", Description: "ETags the client already has; a match is answered with 304 Not Modified",
+		Schema: &openapi.Schema{Type: "string"}},
+}
+
+// WriteParameters are the conditional headers of changing a single game or user
+var WriteParameters = []openapi.Parameter{
+	{Name: "If-Match", In: "header", Description: "ETags of the versions the change is based on; when the row is at another version nothing is written and 412 is returned",
+		Schema: &openapi.Schema{Type: "string"}},
+}
+
+// IdempotencyParameters are the headers of the routes that replay responses to retries
+var IdempotencyParameters = []openapi.Parameter{
+	{Name: "Idempotency-Key", In: "header", Description: "Unique key of the request; retries with the same key and body get the first response again with Idempotent-Replayed: true",
+		Schema: &openapi.Schema{Type: "string", MaxLength: &maxIdempotencyKeyLength}},
+}
+
+// maxIdempotencyKeyLength is the longest Idempotency-Key accepted
+var maxIdempotencyKeyLength int64 = 255
+
+// ListParameters documents the query parameters of the list endpoint of
+// resource: games, subjects, results, users, trashed_games or trashed_users
+func (h *Handlers) ListParameters(resource string) []openapi.Parameter {
+	spec, ok := listSpecs[resource]
+	if !ok {
+		panic("handlers: no list endpoint for " + resource)
+	}
+
+	maxLimit := int64(h.pages.maxLimit)
+	one := int64(1)
+	params := []openapi.Parameter{
+		{Name: "limit", In: "query", Description: fmt.Sprintf("Page size, %d by default", h.pages.defaultLimit),
+			Schema: &openapi.Schema{Type: "integer", Minimum: &one, Maximum: &maxLimit}},
+		{Name: "cursor", In: "query", Description: "X-Next-Cursor of the previous page, used with the same sort",
+			Schema: &openapi.Schema{Type: "string"}},
+		{Name: "sort", In: "query", Description: fmt.Sprintf("Comma separated columns from %s, each optionally prefixed with - for descending order; %s by default",
+			strings.Join(append(slices.Clone(spec.sortable), "id"), ", "), spec.defaultSort),
+			Schema: &openapi.Schema{Type: "string"}},
+	}
+
+	names := make([]string, 0, len(spec.filters))
+	for name := range spec.filters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		filter := spec.filters[name]
+		schema := filter.param.schema
+		params = append(params, openapi.Parameter{
+			Name:        name,
+			In:          "query",
+			Description: filterDescription(filter),
+			Schema:      &schema,
+		})
+	}
+	return params
+}
+
+func filterDescription(f listFilter) string {
+	switch f.operator {
+	case services.OpGte:
+		return "Only rows whose " + f.column + " is at least this"
+	case services.OpLte:
+		return "Only rows whose " + f.column + " is at most this"
+	default:
+		return "Only rows whose " + f.column + " equals this"
+	}
+}
+
+// SearchParameters documents the query parameters of SearchGamesHandler
+func (h *Handlers) SearchParameters() []openapi.Parameter {
+	one, five := int64(1), int64(5)
+	zero, maxOffset := int64(0), int64(maxSearchOffset)
+	maxQuery := int64(maxSearchQuery)
+	maxLimit := int64(h.pages.maxLimit)
+	return []openapi.Parameter{
+		{Name: "q", In: "query", Required: true, Description: "Words that must each start a word of the title or description",
+			Schema: &openapi.Schema{Type: "string", MaxLength: &maxQuery}},
+		{Name: "language", In: "query", Description: "Only games in this language",
+			Schema: &openapi.Schema{Type: "string", Enum: services.SearchLanguages}},
+		{Name: "subject_id", In: "query", Description: "Only games of this subject",
+			Schema: &openapi.Schema{Type: "string", Format: "uuid"}},
+		{Name: "difficulty", In: "query", Description: "Only games of this difficulty level",
+			Schema: &openapi.Schema{Type: "integer", Minimum: &one, Maximum: &five}},
+		{Name: "limit", In: "query", Description: "Page size, " + strconv.Itoa(h.pages.defaultLimit) + " by default",
+			Schema: &openapi.Schema{Type: "integer", Minimum: &one, Maximum: &maxLimit}},
+		{Name: "offset", In: "query", Description: "Number of matches to skip",
+			Schema: &openapi.Schema{Type: "integer", Minimum: &zero, Maximum: &maxOffset}},
+	}
+}
+
+// BulkTypes are the media types of bulk import and export files
+var BulkTypes = []string{bulk.JSON.ContentType(), bulk.NDJSON.ContentType(), bulk.CSV.ContentType()}
+
+// ImportParameters documents the query parameters of ImportGamesHandler
+var ImportParameters = []openapi.Parameter{
+	{Name: "format", In: "query", Description: "File format, taken from Content-Type when absent",
+		Schema: &openapi.Schema{Type: "string", Enum: []string{"csv", "json", "ndjson"}}},
+	{Name: "dry_run", In: "query", Description: "Only validate the rows and report", Schema: &openapi.Schema{Type: "boolean"}},
+	{Name: "atomic", In: "query", Description: "Write nothing unless every row is valid, and then all rows in one transaction",
+		Schema: &openapi.Schema{Type: "boolean"}},
+}
+
+// ExportParameters documents the query parameters of ExportGamesHandler
+var ExportParameters = []openapi.Parameter{
+	{Name: "format", In: "query", Description: "File format, taken from Accept when absent and JSON by default",
+		Schema: &openapi.Schema{Type: "string", Enum: []string{"csv", "json", "ndjson"}}},
+}
+
+// GraphQLParameters documents the query parameters of GraphQLHandler for GET requests
+var GraphQLParameters = []openapi.Parameter{
+	{Name: "query", In: "query", Description: "GraphQL query document", Required: true, Schema: &openapi.Schema{Type: "string"}},
+	{Name: "operationName", In: "query", Description: "Operation to run when the document has several", Schema: &openapi.Schema{Type: "string"}},
+	{Name: "variables", In: "query", Description: "Variables as a JSON object", Schema: &openapi.Schema{Type: "string"}},
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"backend/services"
+	"backend/utils"
+	"backend/validation"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// StatusForError maps a service error to the HTTP status reported to the client
+func StatusForError(err error) int {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, services.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrCircuitOpen):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, services.ErrUpstream):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// writeServiceError logs err with attrs and answers with the status it maps to
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error, failure string, attrs ...any) {
+	utils.WriteProblem(w, r, logServiceError(r, err, failure, attrs...))
+}
+
+// logServiceError logs err with attrs and returns the problem it maps to. Client
+// errors carry the upstream explanation when there is one; server errors only
+// say what failed, the detail stays in the log.
+func logServiceError(r *http.Request, err error, failure string, attrs ...any) *utils.Problem {
+	status := StatusForError(err)
+	problem := utils.NewProblem(status, errorCode(err), failure)
+	switch status {
+	case http.StatusNotFound:
+		problem.Detail = "The requested resource does not exist"
+	case http.StatusConflict, http.StatusBadRequest:
+		problem.Detail = ""
+		var upstream *services.UpstreamError
+		if errors.As(err, &upstream) {
+			problem.Detail = upstream.Message
+		}
+	case http.StatusPreconditionFailed:
+		problem.Detail = "The resource has changed since it was read"
+	case http.StatusUnauthorized:
+		problem.Detail = "The request is not authorized"
+	case http.StatusServiceUnavailable:
+		problem.Detail = "Supabase is unavailable, try again later"
+	}
+
+	level := slog.LevelWarn
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	slog.Log(r.Context(), level, failure, append(attrs, "status", status, "err", err)...)
+	return problem
+}
+
+// errorCode returns the stable error code of a service error
+func errorCode(err error) string {
+	switch {
+	case errors.Is(err, services.ErrInvalidCredentials):
+		return utils.CodeInvalidCredentials
+	case errors.Is(err, services.ErrNotFound):
+		return utils.CodeNotFound
+	case errors.Is(err, services.ErrConflict):
+		return utils.CodeConflict
+	case errors.Is(err, services.ErrPreconditionFailed):
+		return utils.CodePreconditionFailed
+	case errors.Is(err, services.ErrValidation):
+		return utils.CodeValidationFailed
+	case errors.Is(err, services.ErrUnauthorized):
+		return utils.CodeUnauthorized
+	case errors.Is(err, services.ErrCircuitOpen):
+		return utils.CodeUnavailable
+	case errors.Is(err, services.ErrUpstream):
+		return utils.CodeUpstreamError
+	default:
+		return utils.CodeInternal
+	}
+}
+
+// writeBodyError answers a request whose JSON body or query could not be decoded or
+// failed validation, pointing at the offending fields where possible
+func writeBodyError(w http.ResponseWriter, r *http.Request, err error) {
+	utils.WriteProblem(w, r, bodyErrorProblem(err))
+}
+
+// bodyErrorProblem returns the problem writeBodyError answers err with
+func bodyErrorProblem(err error) *utils.Problem {
+	var invalid validation.Errors
+	if errors.As(err, &invalid) {
+		problem := utils.NewProblem(http.StatusBadRequest, utils.CodeValidationFailed, "The request has invalid fields")
+		return problem.WithFieldErrors(invalid...)
+	}
+
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return utils.NewProblem(http.StatusRequestEntityTooLarge, utils.CodeBodyTooLarge,
+			fmt.Sprintf("The request body must not exceed %d bytes", maxErr.Limit))
+	}
+
+	problem := utils.NewProblem(http.StatusBadRequest, utils.CodeInvalidBody, "The request body is not valid JSON for this endpoint")
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		problem.WithFieldErrors(utils.FieldError{
+			Field:   typeErr.Field,
+			Code:    "invalid_type",
+			Message: "must be of type " + typeErr.Type.String(),
+		})
+	}
+	var valueErr *fieldValueError
+	if errors.As(err, &valueErr) {
+		problem.WithFieldErrors(utils.FieldError{
+			Field:   valueErr.Field,
+			Code:    "invalid_value",
+			Message: valueErr.Err.Error(),
+		})
+	}
+	// encoding/json has no typed error for DisallowUnknownFields
+	if name, ok := strings.CutPrefix(err.Error(), `json: unknown field "`); ok {
+		problem.WithFieldErrors(utils.FieldError{
+			Field:   strings.TrimSuffix(name, `"`),
+			Code:    "unknown_field",
+			Message: "is not a known field",
+		})
+	}
+	return problem
+}
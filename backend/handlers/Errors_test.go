@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"backend/services"
+	"backend/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatusForError(t *testing.T) {
+	cases := map[error]int{
+		fmt.Errorf("game \"1\": %w", services.ErrNotFound):                           http.StatusNotFound,
+		&services.UpstreamError{Kind: services.ErrConflict, Code: "email_exists"}:    http.StatusConflict,
+		&services.UpstreamError{Kind: services.ErrValidation, Code: "22P02"}:         http.StatusBadRequest,
+		fmt.Errorf("game \"1\" is at version 2: %w", services.ErrPreconditionFailed): http.StatusPreconditionFailed,
+		services.ErrInvalidCredentials:                                               http.StatusUnauthorized,
+		fmt.Errorf("%w: %w", services.ErrUpstream, services.ErrCircuitOpen):          http.StatusServiceUnavailable,
+		fmt.Errorf("%w: connection refused", services.ErrUpstream):                   http.StatusBadGateway,
+		errors.New("unexpected"):                                                     http.StatusInternalServerError,
+	}
+	for err, want := range cases {
+		if got := StatusForError(err); got != want {
+			t.Errorf("StatusForError(%v) = %d, want %d", err, got, want)
+		}
+	}
+}
+
+func TestWriteServiceErrorExposesOnlyClientErrorDetail(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/users", nil)
+
+	rr := httptest.NewRecorder()
+	writeServiceError(rr, req, &services.UpstreamError{Kind: services.ErrConflict, Message: "Email address already registered"}, "Failed to create user")
+	if p := problem(t, rr); rr.Code != http.StatusConflict || p.Code != utils.CodeConflict || p.Detail != "Email address already registered" {
+		t.Errorf("unexpected conflict response %d %+v", rr.Code, p)
+	}
+
+	rr = httptest.NewRecorder()
+	writeServiceError(rr, req, &services.UpstreamError{Kind: services.ErrUpstream, Message: "relation \"games\" does not exist"}, "Failed to create user")
+	if p := problem(t, rr); rr.Code != http.StatusBadGateway || p.Code != utils.CodeUpstreamError || p.Detail != "Failed to create user" {
+		t.Errorf("unexpected upstream response %d %+v", rr.Code, p)
+	}
+}
+
+func TestWriteBodyErrorReportsField(t *testing.T) {
+	var req struct {
+		Difficulty int `json:"difficulty_level"`
+	}
+	err := json.Unmarshal([]byte(`{"difficulty_level":"hard"}`), &req)
+
+	rr := httptest.NewRecorder()
+	writeBodyError(rr, httptest.NewRequest(http.MethodPost, "/games", nil), err)
+
+	p := problem(t, rr)
+	if rr.Code != http.StatusBadRequest || p.Code != utils.CodeInvalidBody {
+		t.Fatalf("unexpected response %d %+v", rr.Code, p)
+	}
+	if len(p.Errors) != 1 || p.Errors[0].Field != "difficulty_level" || p.Errors[0].Code != "invalid_type" {
+		t.Errorf("expected a field error for difficulty_level, got %+v", p.Errors)
+	}
+}
+
+func problem(t *testing.T, rr *httptest.ResponseRecorder) utils.Problem {
+	if ct := rr.Header().Get("Content-Type"); ct != utils.ProblemContentType {
+		t.Errorf("expected %s, got %q", utils.ProblemContentType, ct)
+	}
+	var p utils.Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
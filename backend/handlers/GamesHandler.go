@@ -1,50 +1,54 @@
 package handlers
 
 import (
+	"backend/middleware"
 	"backend/models"
-	"backend/services"
+	"backend/utils"
+	"backend/validation"
 	"encoding/json"
-	"log"
 	"net/http"
-	"strings"
 )
 
-// GamesHandler retrieves a list of games from the Supabase database
-func GamesHandler(w http.ResponseWriter, r *http.Request) {
+// GamesHandler retrieves one page of the user's games from the Supabase database
+func (h *Handlers) GamesHandler(w http.ResponseWriter, r *http.Request) {
 	// Extract user_id from context
-	userID, ok := r.Context().Value("user_id").(string)
+	userID, ok := middleware.UserID(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		utils.WriteError(w, r, http.StatusUnauthorized, utils.CodeUnauthorized, "Unauthorized")
 		return
 	}
 
+	list, err := h.pages.parse(r, gamesList)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
 
 	// Fetch games from the Supabase service
-	games, err := services.FetchGames(userID)
+	page, err := h.games.FetchGames(r.Context(), userID, list.options)
 	if err != nil {
-		log.Println("Error fetching games:", err)
-		http.Error(w, "Failed to fetch games", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Failed to fetch games")
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(games)
+	writePage(w, r, h.pages, list, page)
 }
 
 // CreateGameHandler creates a new game in the Supabase database
-func CreateGameHandler(w http.ResponseWriter, r *http.Request) {
-	// Parse the request body
-	var req models.GameRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+func (h *Handlers) CreateGameHandler(w http.ResponseWriter, r *http.Request) {
+	// Parse and validate the request body
+	req, err := decodeJSON[models.GameRequest](r)
+	if err == nil {
+		err = validation.Struct(req)
+	}
+	if err != nil {
+		writeBodyError(w, r, err)
 		return
 	}
 
 	// Call the service to create the game
-	game, err := services.CreateGame(req.Title, req.Description, req.SubjectID, req.Difficulty)
+	game, err := h.games.CreateGame(r.Context(), req)
 	if err != nil {
-		log.Println("Error creating game:", err)
-		http.Error(w, "Failed to create game", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Failed to create game")
 		return
 	}
 
@@ -54,25 +58,25 @@ func CreateGameHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // GetGameHandler retrieves a single game by its ID from the Supabase database
-func GetGameHandler(w http.ResponseWriter, r *http.Request) {
-	userID, ok := r.Context().Value("user_id").(string)
+func (h *Handlers) GetGameHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserID(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		utils.WriteError(w, r, http.StatusUnauthorized, utils.CodeUnauthorized, "Unauthorized")
 		return
 	}
 
 	// Extract the game ID from the URL path
-	pathParts := strings.Split(r.URL.Path, "/")
-	if len(pathParts) < 3 {
-		http.Error(w, "Game ID not provided", http.StatusBadRequest)
+	gameID, ok := pathUUID(w, r, "id")
+	if !ok {
 		return
 	}
-	gameID := pathParts[2]
 	// Call the service to fetch the game
-	game, err := services.FetchGameByID(gameID, userID)
+	game, err := h.games.FetchGameByID(r.Context(), gameID, userID)
 	if err != nil {
-		log.Printf("Error fetching game: %v\n", err)
-		http.Error(w, "Failed to fetch game", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Failed to fetch game", "game_id", gameID)
+		return
+	}
+	if notModified(w, r, game.Version) {
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -81,66 +85,79 @@ func GetGameHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateGameHandler updates a game by its ID in the Supabase database
-func UpdateGameHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) UpdateGameHandler(w http.ResponseWriter, r *http.Request) {
 	// Extract the game ID from the URL path
-	pathParts := strings.Split(r.URL.Path, "/")
-	if len(pathParts) < 3 {
-		http.Error(w, "Game ID not provided", http.StatusBadRequest)
+	gameID, ok := pathUUID(w, r, "id")
+	if !ok {
 		return
 	}
-	gameID := pathParts[2]
 
 	// Retrieve user_id from context
-	userID, ok := r.Context().Value("user_id").(string)
+	userID, ok := middleware.UserID(r.Context())
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		utils.WriteError(w, r, http.StatusUnauthorized, utils.CodeUnauthorized, "Unauthorized")
 		return
 	}
 
-	// Parse the request body
-	var req models.GameRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	// Parse the request body; fields left out are not updated
+	req, err := decodeJSON[models.GameRequest](r)
+	if err == nil {
+		err = validation.Partial(req)
+	}
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if req == (models.GameRequest{}) {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeValidationFailed, "No valid fields to update")
+		return
+	}
+
+	// Only update the version the client read, if it says which
+	versions, ok := ifMatch(w, r)
+	if !ok {
 		return
 	}
 
 	// Call the service to update the game
-	updatedGame, err := services.UpdateGameByID(gameID, userID, req)
+	updatedGame, err := h.games.UpdateGameByID(r.Context(), gameID, userID, req, versions)
 	if err != nil {
-		log.Printf("Error updating game: %v\n", err)
-		http.Error(w, "Failed to update game", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Failed to update game", "game_id", gameID)
 		return
 	}
 
+	w.Header().Set("ETag", etag(updatedGame.Version))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(updatedGame)
 }
 
 // DeleteGameHandler deletes a game by its ID from the Supabase database
-func DeleteGameHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) DeleteGameHandler(w http.ResponseWriter, r *http.Request) {
 	// Extract the game ID from the URL path
-	pathParts := strings.Split(r.URL.Path, "/")
-	if len(pathParts) < 3 {
-		http.Error(w, "Game ID not provided", http.StatusBadRequest)
+	gameID, ok := pathUUID(w, r, "id")
+	if !ok {
 		return
 	}
-	gameID := pathParts[2]
 
 	// Retrieve user_id from context
-	userID, ok := r.Context().Value("user_id").(string)
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		utils.WriteError(w, r, http.StatusUnauthorized, utils.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	versions, ok := ifMatch(w, r)
 	if !ok {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
 	// Call the service to delete the game
-	err := services.DeleteGameByID(gameID, userID)
+	err := h.games.DeleteGameByID(r.Context(), gameID, userID, versions)
 	if err != nil {
-		log.Printf("Error deleting game: %v\n", err)
-		http.Error(w, "Failed to delete game", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Failed to delete game", "game_id", gameID)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent) // 204 No Content
-}
\ No newline at end of file
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"backend/graph"
+	"backend/middleware"
+	"backend/utils"
+	"backend/validation"
+	"encoding/json"
+	"net/http"
+)
+
+// GraphQLHandler executes GraphQL queries for the authenticated user. Queries
+// come as the query, operationName and variables parameters of a GET request
+// or as the JSON body of a POST. Field errors are reported in the errors of a
+// 200 response, each with a stable code in its extensions.
+func GraphQLHandler(server *graph.Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := middleware.UserID(r.Context())
+		if !ok {
+			utils.WriteError(w, r, http.StatusUnauthorized, utils.CodeUnauthorized, "Unauthorized")
+			return
+		}
+
+		req, err := parseGraphQLRequest(r)
+		if err == nil {
+			err = validation.Struct(req)
+		}
+		if err != nil {
+			writeBodyError(w, r, err)
+			return
+		}
+
+		principal := graph.Principal{UserID: userID, Role: middleware.Role(r.Context())}
+		result := server.Execute(r.Context(), principal, req)
+		for _, e := range result.Errors {
+			if e.Err == nil {
+				continue
+			}
+			problem := logServiceError(r, e.Err, "Failed to resolve GraphQL field", "path", e.Path)
+			e.Message = problem.Detail
+			if e.Message == "" {
+				e.Message = problem.Title
+			}
+			e.Extensions = map[string]interface{}{"code": problem.Code}
+		}
+		utils.WriteJSONResponse(w, http.StatusOK, result)
+	}
+}
+
+// parseGraphQLRequest reads the query of a GET request or the body of a POST
+func parseGraphQLRequest(r *http.Request) (graph.Request, error) {
+	if r.Method != http.MethodGet {
+		return decodeJSON[graph.Request](r)
+	}
+
+	params := r.URL.Query()
+	req := graph.Request{Query: params.Get("query"), OperationName: params.Get("operationName")}
+	if raw := params.Get("variables"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+			return req, validation.Errors{{Field: "variables", Code: "invalid_value", Message: "must be a JSON object"}}
+		}
+	}
+	return req, nil
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"backend/graph"
+	"backend/middleware"
+	"backend/models"
+	"backend/pagination"
+	"backend/services"
+	"backend/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// failingGames fails every read with err
+type failingGames struct{ err error }
+
+func (f failingGames) FetchGames(ctx context.Context, userID uuid.UUID, opts services.ListOptions) (services.Page[models.Game], error) {
+	return services.Page[models.Game]{}, f.err
+}
+
+func (f failingGames) FetchGamesByIDs(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]models.Game, error) {
+	return nil, f.err
+}
+
+func TestGraphQLHandler(t *testing.T) {
+	upstream := fmt.Errorf("%w: connection refused by db.internal:5432", services.ErrUpstream)
+	server, err := graph.NewServer(graph.Services{Games: failingGames{upstream}}, pagination.NewCodec("secret"),
+		graph.Options{DefaultPageSize: 25, MaxPageSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, uuid.New()))
+		rr := httptest.NewRecorder()
+		GraphQLHandler(server)(rr, req)
+		return rr
+	}
+
+	rr := serve(httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ games { nodes { title } } }"}`)))
+	body := rr.Body.String()
+	if rr.Code != http.StatusOK || !strings.Contains(body, `"code":"`+utils.CodeUpstreamError+`"`) || strings.Contains(body, "db.internal") {
+		t.Errorf("expected a field error coded without upstream details, got %d %s", rr.Code, body)
+	}
+
+	rr = serve(httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape("{ games { nodes { nope } } }"), nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"code":"`+utils.CodeInvalidQuery+`"`) {
+		t.Errorf("expected an invalid query error, got %d %s", rr.Code, rr.Body)
+	}
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/graphql?variables=%5B", nil),
+		httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": ""}`)),
+		httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ me { id } }", "variable": {}}`)),
+	} {
+		if rr := serve(req); rr.Code != http.StatusBadRequest || rr.Header().Get("Content-Type") != "application/problem+json" {
+			t.Errorf("%s %s: expected a 400 problem, got %d %s", req.Method, req.URL, rr.Code, rr.Body)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"backend/bulk"
+	"backend/services"
+)
+
+// Handlers serves the user, auth and game routes with the services built once at startup
+type Handlers struct {
+	games    *services.GameService
+	users    *services.UserService
+	subjects *services.SubjectService
+	results  *services.ResultService
+	search   services.GameSearcher
+	transfer bulk.Store
+	pages    *Paginator
+}
+
+// New creates the route handlers
+func New(games *services.GameService, users *services.UserService, subjects *services.SubjectService, results *services.ResultService, search services.GameSearcher, transfer bulk.Store, pages *Paginator) *Handlers {
+	return &Handlers{games: games, users: users, subjects: subjects, results: results, search: search, transfer: transfer, pages: pages}
+}
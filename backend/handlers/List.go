@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"backend/models"
+	"backend/openapi"
+	"backend/pagination"
+	"backend/services"
+	"backend/utils"
+	"backend/validation"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Page sizes used when the configuration leaves them unset
+const (
+	defaultPageSize = 25
+	maxPageSize     = 100
+)
+
+// Paginator reads the limit, cursor and sort parameters of list requests and
+// writes the pagination headers of their responses
+type Paginator struct {
+	cursors      *pagination.Codec
+	defaultLimit int
+	maxLimit     int
+}
+
+// NewPaginator creates a Paginator signing cursors with cursors. Zero limits fall back to 25 and 100.
+func NewPaginator(cursors *pagination.Codec, defaultLimit, maxLimit int) *Paginator {
+	if defaultLimit < 1 {
+		defaultLimit = defaultPageSize
+	}
+	if maxLimit < defaultLimit {
+		maxLimit = max(defaultLimit, maxPageSize)
+	}
+	return &Paginator{cursors: cursors, defaultLimit: defaultLimit, maxLimit: maxLimit}
+}
+
+// listSpec declares the sort columns and filter parameters a list endpoint accepts
+type listSpec struct {
+	sortable    []string // columns besides id that may be sorted by
+	defaultSort string   // e.g. "-created_at"
+	filters     map[string]listFilter
+}
+
+// listFilter maps a query parameter to a PostgREST filter
+type listFilter struct {
+	column   string
+	operator string
+	param    paramType
+}
+
+// paramType parses a query parameter and describes it in the API documentation
+type paramType struct {
+	parse  func(string) (interface{}, error)
+	schema openapi.Schema
+}
+
+var (
+	uuidParam   = paramType{parseUUIDParam, openapi.Schema{Type: "string", Format: "uuid"}}
+	intParam    = paramType{parseIntParam, openapi.Schema{Type: "integer"}}
+	timeParam   = paramType{parseTimeParam, openapi.Schema{Type: "string", Format: "date-time"}}
+	stringParam = paramType{parseStringParam, openapi.Schema{Type: "string"}}
+)
+
+var gamesList = listSpec{
+	sortable:    []string{"title", "difficulty_level", "created_at"},
+	defaultSort: "-created_at",
+	filters: map[string]listFilter{
+		"subject_id":     {"subject_id", services.OpEq, uuidParam},
+		"difficulty_min": {"difficulty_level", services.OpGte, intParam},
+		"difficulty_max": {"difficulty_level", services.OpLte, intParam},
+		"created_from":   {"created_at", services.OpGte, timeParam},
+		"created_to":     {"created_at", services.OpLte, timeParam},
+	},
+}
+
+var subjectsList = listSpec{
+	sortable:    []string{"name", "created_at"},
+	defaultSort: "name",
+	filters: map[string]listFilter{
+		"created_from": {"created_at", services.OpGte, timeParam},
+		"created_to":   {"created_at", services.OpLte, timeParam},
+	},
+}
+
+var resultsList = listSpec{
+	sortable:    []string{"score", "completed_at"},
+	defaultSort: "-completed_at",
+	filters: map[string]listFilter{
+		"game_id":        {"game_id", services.OpEq, uuidParam},
+		"score_min":      {"score", services.OpGte, intParam},
+		"score_max":      {"score", services.OpLte, intParam},
+		"completed_from": {"completed_at", services.OpGte, timeParam},
+		"completed_to":   {"completed_at", services.OpLte, timeParam},
+	},
+}
+
+var usersList = listSpec{
+	sortable:    []string{"email", "role", "created_at"},
+	defaultSort: "-created_at",
+	filters: map[string]listFilter{
+		"role":         {"role", services.OpEq, stringParam},
+		"created_from": {"created_at", services.OpGte, timeParam},
+		"created_to":   {"created_at", services.OpLte, timeParam},
+	},
+}
+
+var trashedGamesList = listSpec{
+	sortable:    []string{"title", "created_at", "deleted_at"},
+	defaultSort: "-deleted_at",
+	filters: map[string]listFilter{
+		"deleted_from": {"deleted_at", services.OpGte, timeParam},
+		"deleted_to":   {"deleted_at", services.OpLte, timeParam},
+	},
+}
+
+var trashedUsersList = listSpec{
+	sortable:    []string{"email", "created_at", "deleted_at"},
+	defaultSort: "-deleted_at",
+	filters: map[string]listFilter{
+		"deleted_from": {"deleted_at", services.OpGte, timeParam},
+		"deleted_to":   {"deleted_at", services.OpLte, timeParam},
+	},
+}
+
+// listRequest is a parsed list query together with the sort its cursor is bound to
+type listRequest struct {
+	options services.ListOptions
+	sort    string
+}
+
+// parse reads the list parameters of r, reporting every invalid one at once
+func (p *Paginator) parse(r *http.Request, spec listSpec) (listRequest, error) {
+	query := r.URL.Query()
+	var errs validation.Errors
+	invalid := func(param, code, message string) {
+		errs = append(errs, utils.FieldError{Field: param, Code: code, Message: message})
+	}
+
+	req := listRequest{options: services.ListOptions{Limit: p.defaultLimit}}
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > p.maxLimit {
+			invalid("limit", "invalid_value", "must be an integer between 1 and "+strconv.Itoa(p.maxLimit))
+		}
+		req.options.Limit = limit
+	}
+
+	sort := query.Get("sort")
+	if sort == "" {
+		sort = spec.defaultSort
+	}
+	keys, sortErr := parseSort(sort, spec.sortable)
+	if sortErr != nil {
+		invalid("sort", validation.RuleOneOf, sortErr.Error())
+	}
+	req.options.Sort = keys
+	req.sort = services.FormatSort(keys)
+
+	for param, filter := range spec.filters {
+		raw := query.Get(param)
+		if raw == "" {
+			continue
+		}
+		value, err := filter.param.parse(raw)
+		if err != nil {
+			invalid(param, "invalid_value", err.Error())
+			continue
+		}
+		req.options.Filters = append(req.options.Filters, services.Filter{Column: filter.column, Operator: filter.operator, Value: value})
+	}
+	// Map iteration is random; keep the upstream query stable
+	slices.SortFunc(req.options.Filters, func(a, b services.Filter) int {
+		return strings.Compare(a.Column+a.Operator, b.Column+b.Operator)
+	})
+
+	if token := query.Get("cursor"); token != "" && sortErr == nil {
+		cursor, err := p.cursors.Decode(token, req.sort)
+		if err != nil || len(cursor.After) != len(keys) {
+			invalid("cursor", "invalid_cursor", "is not a cursor of this listing and sort order")
+		}
+		req.options.After = cursor.After
+	}
+
+	if len(errs) > 0 {
+		slices.SortFunc(errs, func(a, b utils.FieldError) int { return strings.Compare(a.Field, b.Field) })
+		return req, errs
+	}
+	return req, nil
+}
+
+// parseSort reads a comma separated list of columns, each optionally prefixed
+// with - for descending order, and appends id as the unique tie-breaker
+func parseSort(sort string, sortable []string) ([]services.SortKey, error) {
+	var keys []services.SortKey
+	seen := map[string]bool{}
+	for _, field := range strings.Split(sort, ",") {
+		column, descending := strings.CutPrefix(strings.TrimSpace(field), "-")
+		if column != "id" && !slices.Contains(sortable, column) {
+			return nil, errors.New("must list columns from " + strings.Join(append(slices.Clone(sortable), "id"), ", ") + ", each optionally prefixed with -")
+		}
+		if seen[column] {
+			return nil, errors.New("must not repeat " + column)
+		}
+		seen[column] = true
+		keys = append(keys, services.SortKey{Column: column, Descending: descending})
+	}
+	if !seen["id"] {
+		keys = append(keys, services.SortKey{Column: "id", Descending: keys[len(keys)-1].Descending})
+	}
+	return keys, nil
+}
+
+// writePage writes the items of page as a JSON array with X-Total-Count and,
+// when there are more rows, X-Next-Cursor and a Link header to the next page
+func writePage[T any](w http.ResponseWriter, r *http.Request, p *Paginator, req listRequest, page services.Page[T]) {
+	if page.Total >= 0 {
+		w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+	}
+	if page.Next != nil {
+		token := p.cursors.Encode(pagination.Cursor{Sort: req.sort, After: page.Next})
+		next := *r.URL
+		query := next.Query()
+		query.Set("cursor", token)
+		next.RawQuery = query.Encode()
+		w.Header().Set("X-Next-Cursor", token)
+		w.Header().Add("Link", "<"+next.RequestURI()+`>; rel="next"`)
+	}
+
+	items := page.Items
+	if items == nil {
+		items = []T{}
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, items)
+}
+
+func parseUUIDParam(raw string) (interface{}, error) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, errors.New("must be a UUID")
+	}
+	return id, nil
+}
+
+func parseIntParam(raw string) (interface{}, error) {
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, errors.New("must be an integer")
+	}
+	return n, nil
+}
+
+func parseTimeParam(raw string) (interface{}, error) {
+	ts, err := models.ParseTimestamp(raw)
+	if err != nil {
+		return nil, errors.New("must be an RFC 3339 timestamp")
+	}
+	// The columns have no time zone, so the offset of the value would be dropped
+	return ts.Time.UTC(), nil
+}
+
+func parseStringParam(raw string) (interface{}, error) {
+	return raw, nil
+}
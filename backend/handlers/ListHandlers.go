@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"backend/middleware"
+	"backend/utils"
+	"net/http"
+)
+
+// ListSubjectsHandler retrieves one page of subjects
+func (h *Handlers) ListSubjectsHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := h.pages.parse(r, subjectsList)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	page, err := h.subjects.ListSubjects(r.Context(), list.options)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to fetch subjects")
+		return
+	}
+	writePage(w, r, h.pages, list, page)
+}
+
+// ListResultsHandler retrieves one page of the user's game results
+func (h *Handlers) ListResultsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		utils.WriteError(w, r, http.StatusUnauthorized, utils.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	list, err := h.pages.parse(r, resultsList)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	page, err := h.results.ListResults(r.Context(), userID, list.options)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to fetch results")
+		return
+	}
+	writePage(w, r, h.pages, list, page)
+}
+
+// ListUsersHandler retrieves one page of users for administrators
+func (h *Handlers) ListUsersHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := h.pages.parse(r, usersList)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	page, err := h.users.ListUsers(r.Context(), list.options)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to fetch users")
+		return
+	}
+	writePage(w, r, h.pages, list, page)
+}
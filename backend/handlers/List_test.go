@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"backend/pagination"
+	"backend/services"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPaginatorParse(t *testing.T) {
+	p := NewPaginator(pagination.NewCodec("secret"), 0, 0)
+	req := httptest.NewRequest(http.MethodGet, "/games?sort=difficulty_level,-created_at&difficulty_min=2&subject_id=5803acaf-821a-4463-b8b4-15ac6e0e466a", nil)
+
+	list, err := p.parse(req, gamesList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list.sort != "difficulty_level,-created_at,-id" {
+		t.Errorf("expected id to be appended as tie-breaker, got %q", list.sort)
+	}
+	if list.options.Limit != defaultPageSize || len(list.options.Filters) != 2 || list.options.After != nil {
+		t.Errorf("unexpected options %+v", list.options)
+	}
+}
+
+func TestPaginatorParseConvertsTimesToUTC(t *testing.T) {
+	p := NewPaginator(pagination.NewCodec("secret"), 0, 0)
+	req := httptest.NewRequest(http.MethodGet, "/games?created_from=2026-10-19T10:00:00%2B02:00", nil)
+
+	list, err := p.parse(req, gamesList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, ok := list.options.Filters[0].Value.(time.Time)
+	if !ok || from.Location() != time.UTC || from.Hour() != 8 {
+		t.Errorf("expected created_from as 08:00 UTC, got %v", list.options.Filters[0].Value)
+	}
+}
+
+func TestPaginatorParseReportsEveryParameter(t *testing.T) {
+	p := NewPaginator(pagination.NewCodec("secret"), 10, 50)
+	req := httptest.NewRequest(http.MethodGet, "/games?limit=500&sort=user_id&created_from=yesterday&cursor=abc", nil)
+
+	_, err := p.parse(req, gamesList)
+	rr := httptest.NewRecorder()
+	writeBodyError(rr, req, err)
+
+	fields := map[string]bool{}
+	for _, fe := range problem(t, rr).Errors {
+		fields[fe.Field] = true
+	}
+	// The cursor is only checked against a valid sort
+	if rr.Code != http.StatusBadRequest || len(fields) != 3 || !fields["limit"] || !fields["sort"] || !fields["created_from"] {
+		t.Errorf("expected limit, sort and created_from errors, got %d %v", rr.Code, fields)
+	}
+}
+
+func TestWritePageLinksNextCursor(t *testing.T) {
+	p := NewPaginator(pagination.NewCodec("secret"), 2, 10)
+	req := httptest.NewRequest(http.MethodGet, "/subjects?sort=name&created_from=2024-01-01T00:00:00Z", nil)
+	list, err := p.parse(req, subjectsList)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name, id := "Art", "5803acaf-821a-4463-b8b4-15ac6e0e466a"
+	rr := httptest.NewRecorder()
+	writePage(rr, req, p, list, services.Page[string]{Items: []string{"a", "b"}, Next: []*string{&name, &id}, Total: 5})
+
+	if rr.Header().Get("X-Total-Count") != "5" {
+		t.Errorf("expected X-Total-Count 5, got %q", rr.Header().Get("X-Total-Count"))
+	}
+	link := rr.Header().Get("Link")
+	if !strings.HasSuffix(link, `>; rel="next"`) {
+		t.Fatalf("expected a next link, got %q", link)
+	}
+	next, _ := url.Parse(strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`))
+	if next.Query().Get("created_from") == "" || next.Query().Get("cursor") != rr.Header().Get("X-Next-Cursor") {
+		t.Errorf("expected the link to keep the filters and add the cursor, got %q", link)
+	}
+
+	// The next request resumes after the last row
+	list, err = p.parse(httptest.NewRequest(http.MethodGet, next.String(), nil), subjectsList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.options.After) != 2 || *list.options.After[0] != name {
+		t.Errorf("expected the cursor keyset, got %v", list.options.After)
+	}
+
+	// A cursor cannot be replayed with another sort
+	replay := httptest.NewRequest(http.MethodGet, "/subjects?sort=-name&cursor="+url.QueryEscape(rr.Header().Get("X-Next-Cursor")), nil)
+	if _, err := p.parse(replay, subjectsList); err == nil {
+		t.Error("expected a cursor for another sort order to be rejected")
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"backend/utils"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// etag returns the strong entity tag of a row at version
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// entityTags splits the list of entity tags in a conditional request header
+func entityTags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// notModified sets the ETag of a row at version and answers 304 Not Modified
+// when If-None-Match already names it. Like RFC 9110 asks, tags are compared
+// weakly here, so W/"3" matches too.
+func notModified(w http.ResponseWriter, r *http.Request, version int64) bool {
+	current := etag(version)
+	w.Header().Set("ETag", current)
+	for _, tag := range entityTags(r.Header.Get("If-None-Match")) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == current {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatch returns the row versions named by the If-Match header of a write,
+// nil when the write is unconditional because the header is absent or "*".
+// Weak and foreign tags never match a strong comparison; when nothing else is
+// left the write cannot succeed, so it answers 412 and returns false.
+func ifMatch(w http.ResponseWriter, r *http.Request) ([]int64, bool) {
+	header, ok := r.Header["If-Match"]
+	if !ok {
+		return nil, true
+	}
+	var versions []int64
+	for _, tag := range entityTags(strings.Join(header, ",")) {
+		if tag == "*" {
+			return nil, true
+		}
+		unquoted, found := strings.CutPrefix(tag, `"`)
+		unquoted, closed := strings.CutSuffix(unquoted, `"`)
+		if !found || !closed {
+			continue
+		}
+		if version, err := strconv.ParseInt(unquoted, 10, 64); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	if versions == nil {
+		utils.WriteError(w, r, http.StatusPreconditionFailed, utils.CodePreconditionFailed, "If-Match names no current version of the resource")
+		return nil, false
+	}
+	return versions, true
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"backend/config"
+	"backend/middleware"
+	"backend/services"
+	"backend/utils"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestGetGameAnswersNotModified(t *testing.T) {
+	gameID := uuid.New()
+	supabase := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"` + gameID.String() + `","title":"Verbs","version":7}]`))
+	}))
+	defer supabase.Close()
+	h := &Handlers{games: services.NewGameService(services.NewSupabaseClient(config.Config{SupabaseURL: supabase.URL}))}
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/games/"+gameID.String(), nil)
+		req.SetPathValue("id", gameID.String())
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, uuid.New()))
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rr := httptest.NewRecorder()
+		h.GetGameHandler(rr, req)
+		return rr
+	}
+
+	rr := get("")
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"7"` {
+		t.Fatalf("expected the game with ETag \"7\", got %d %q", rr.Code, rr.Header().Get("ETag"))
+	}
+	if rr = get(`"6", W/"7"`); rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("expected 304 without a body, got %d %s", rr.Code, rr.Body)
+	}
+	if rr = get(`"6"`); rr.Code != http.StatusOK {
+		t.Errorf("expected the game for an old ETag, got %d", rr.Code)
+	}
+}
+
+func TestUpdateGameChecksIfMatch(t *testing.T) {
+	gameID := uuid.New()
+	supabase := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch && r.URL.Query().Get("version") == "eq.2" {
+			w.Write([]byte(`[{"id":"` + gameID.String() + `","title":"Nouns","version":3}]`))
+			return
+		}
+		if r.Method == http.MethodPatch {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"id":"` + gameID.String() + `","title":"Nouns","version":3}]`))
+	}))
+	defer supabase.Close()
+	h := &Handlers{games: services.NewGameService(services.NewSupabaseClient(config.Config{SupabaseURL: supabase.URL}))}
+
+	patch := func(ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPatch, "/games/"+gameID.String(), strings.NewReader(`{"title":"Nouns"}`))
+		req.SetPathValue("id", gameID.String())
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, uuid.New()))
+		req.Header.Set("If-Match", ifMatch)
+		rr := httptest.NewRecorder()
+		h.UpdateGameHandler(rr, req)
+		return rr
+	}
+
+	if rr := patch(`"2"`); rr.Code != http.StatusOK || rr.Header().Get("ETag") != `"3"` {
+		t.Errorf("expected the update with the new ETag, got %d %q", rr.Code, rr.Header().Get("ETag"))
+	}
+	rr := patch(`"1"`)
+	if rr.Code != http.StatusPreconditionFailed || problem(t, rr).Code != utils.CodePreconditionFailed {
+		t.Errorf("expected 412 for a stale ETag, got %d %s", rr.Code, rr.Body)
+	}
+	if rr := patch(`W/"2"`); rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("expected 412 for a weak ETag, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"backend/health"
+	"backend/utils"
+	"net/http"
+)
+
+// ReadinessResponse is the public readiness summary
+type ReadinessResponse struct {
+	Status string `json:"status"`
+}
+
+// ReadinessHandler reports whether the service can take traffic. Only the
+// overall status is returned so dependency details are not exposed publicly.
+func ReadinessHandler(readiness *health.Readiness) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := readiness.Report(r.Context())
+		utils.WriteJSONResponse(w, readinessStatusCode(report), ReadinessResponse{Status: report.Status})
+	}
+}
+
+// ReadinessDetailsHandler returns the full per-dependency report for admins
+func ReadinessDetailsHandler(readiness *health.Readiness) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := readiness.Report(r.Context())
+		utils.WriteJSONResponse(w, readinessStatusCode(report), report)
+	}
+}
+
+func readinessStatusCode(report health.Report) int {
+	if report.Status != health.StatusReady {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"backend/middleware"
+	"backend/models"
+	"backend/services"
+	"backend/utils"
+	"backend/validation"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Bounds of the search parameters: offset limits how deep clients may page through ranked results
+const (
+	maxSearchQuery  = 200
+	maxSearchOffset = 1000
+)
+
+// SearchGamesHandler searches the user's games by title and description,
+// returning ranked matches with facet counts by subject and difficulty
+func (h *Handlers) SearchGamesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		utils.WriteError(w, r, http.StatusUnauthorized, utils.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	query, err := h.parseSearch(r)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	result, err := h.search.SearchGames(r.Context(), userID, query)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to search games")
+		return
+	}
+	if result.Items == nil {
+		result.Items = []models.Game{}
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, result)
+}
+
+// parseSearch reads the q, language, subject_id, difficulty, limit and offset parameters
+func (h *Handlers) parseSearch(r *http.Request) (services.SearchQuery, error) {
+	params := r.URL.Query()
+	var errs validation.Errors
+	invalid := func(param, code, message string) {
+		errs = append(errs, utils.FieldError{Field: param, Code: code, Message: message})
+	}
+
+	query := services.SearchQuery{
+		Text:     strings.TrimSpace(params.Get("q")),
+		Language: params.Get("language"),
+		Limit:    h.pages.defaultLimit,
+	}
+	if query.Text == "" {
+		invalid("q", validation.RuleRequired, "is required")
+	} else if utf8.RuneCountInString(query.Text) > maxSearchQuery {
+		invalid("q", validation.RuleMax, "must be at most "+strconv.Itoa(maxSearchQuery)+" characters")
+	}
+	if query.Language != "" && !slices.Contains(services.SearchLanguages, query.Language) {
+		invalid("language", validation.RuleOneOf, "must be one of "+strings.Join(services.SearchLanguages, ", "))
+	}
+	if raw := params.Get("subject_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			invalid("subject_id", validation.RuleUUID, "must be a UUID")
+		}
+		query.SubjectID = &id
+	}
+	if raw := params.Get("difficulty"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 5 {
+			invalid("difficulty", "invalid_value", "must be an integer between 1 and 5")
+		}
+		query.Difficulty = n
+	}
+	if raw := params.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > h.pages.maxLimit {
+			invalid("limit", "invalid_value", "must be an integer between 1 and "+strconv.Itoa(h.pages.maxLimit))
+		}
+		query.Limit = n
+	}
+	if raw := params.Get("offset"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 || n > maxSearchOffset {
+			invalid("offset", "invalid_value", "must be an integer between 0 and "+strconv.Itoa(maxSearchOffset))
+		}
+		query.Offset = n
+	}
+
+	if len(errs) > 0 {
+		return query, errs
+	}
+	return query, nil
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"backend/middleware"
+	"backend/models"
+	"backend/pagination"
+	"backend/services"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSearchGamesHandler(t *testing.T) {
+	userID := uuid.New()
+	index := services.NewMemoryGameIndex()
+	index.Add(userID, models.Game{ID: uuid.New(), Title: "Irregular verbs", Difficulty: 2})
+	h := &Handlers{search: index, pages: NewPaginator(pagination.NewCodec("secret"), 0, 0)}
+
+	search := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/games/search?"+query, nil)
+		req = req.WithContext(context.WithValue(req.Context(), middleware.UserIDContextKey, userID))
+		rr := httptest.NewRecorder()
+		h.SearchGamesHandler(rr, req)
+		return rr
+	}
+
+	rr := search("q=irr")
+	var result services.SearchResult
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if rr.Code != http.StatusOK || result.Total != 1 || len(result.Facets.Difficulties) != 1 {
+		t.Errorf("expected one match with facets, got %d %s", rr.Code, rr.Body)
+	}
+
+	rr = search("q=&language=klingon&difficulty=9&offset=-1")
+	if p := problem(t, rr); rr.Code != http.StatusBadRequest || len(p.Errors) != 4 {
+		t.Errorf("expected four parameter errors, got %d %+v", rr.Code, p.Errors)
+	}
+}
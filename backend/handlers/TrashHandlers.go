@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"backend/middleware"
+	"backend/utils"
+	"log/slog"
+	"net/http"
+)
+
+// TrashedGamesHandler retrieves one page of the games the user moved to the trash
+func (h *Handlers) TrashedGamesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		utils.WriteError(w, r, http.StatusUnauthorized, utils.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	list, err := h.pages.parse(r, trashedGamesList)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	page, err := h.games.ListTrashedGames(r.Context(), userID, list.options)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to fetch trashed games")
+		return
+	}
+	writePage(w, r, h.pages, list, page)
+}
+
+// RestoreGameHandler takes one of the user's games out of the trash
+func (h *Handlers) RestoreGameHandler(w http.ResponseWriter, r *http.Request) {
+	gameID, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+	userID, ok := middleware.UserID(r.Context())
+	if !ok {
+		utils.WriteError(w, r, http.StatusUnauthorized, utils.CodeUnauthorized, "Unauthorized")
+		return
+	}
+
+	game, err := h.games.RestoreGame(r.Context(), gameID, userID)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to restore game", "game_id", gameID)
+		return
+	}
+	w.Header().Set("ETag", etag(game.Version))
+	utils.WriteJSONResponse(w, http.StatusOK, game)
+}
+
+// TrashedUsersHandler retrieves one page of the users in the trash for administrators
+func (h *Handlers) TrashedUsersHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := h.pages.parse(r, trashedUsersList)
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	page, err := h.users.ListTrashedUsers(r.Context(), list.options)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to fetch trashed users")
+		return
+	}
+	writePage(w, r, h.pages, list, page)
+}
+
+// RestoreUserHandler takes a user out of the trash and lifts the ban on their auth account
+func (h *Handlers) RestoreUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUUID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	// Step 1: Take the public.users row out of the trash. Users that are not in
+	// the trash stop here, so their ban is left alone.
+	user, err := h.users.RestoreUser(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to restore user", "user_id", userID)
+		return
+	}
+
+	// Step 2: Lift the ban on the auth.users account.
+	// Without the unban the user is moved back to the trash.
+	if err := h.users.SetAuthBanned(r.Context(), userID, false); err != nil {
+		if trashErr := h.users.DeleteUser(r.Context(), userID, nil); trashErr != nil {
+			slog.ErrorContext(r.Context(), "Failed to move user back to the trash after the unban failed", "user_id", userID, "err", trashErr)
+		}
+		writeServiceError(w, r, err, "Failed to unban auth user", "user_id", userID)
+		return
+	}
+	w.Header().Set("ETag", etag(user.Version))
+	utils.WriteJSONResponse(w, http.StatusOK, user)
+}
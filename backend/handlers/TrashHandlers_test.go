@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"backend/config"
+	"backend/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDeleteUserIsUndoneWhenTheBanFails(t *testing.T) {
+	userID := uuid.New()
+	var restored bool
+	supabase := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/auth/v1/admin/users/"+userID.String():
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.URL.Query().Get("deleted_at") == "not.is.null":
+			restored = true
+			w.Write([]byte(`[{"id":"` + userID.String() + `"}]`))
+		default:
+			w.Write([]byte(`[{"id":"` + userID.String() + `","deleted_at":"2026-10-19T12:00:00Z"}]`))
+		}
+	}))
+	defer supabase.Close()
+	h := &Handlers{users: services.NewUserService(services.NewSupabaseClient(config.Config{SupabaseURL: supabase.URL}))}
+
+	req := httptest.NewRequest(http.MethodDelete, "/users/"+userID.String(), nil)
+	req.SetPathValue("id", userID.String())
+	rr := httptest.NewRecorder()
+	h.DeleteUserByIDHandler(rr, req)
+	if rr.Code != http.StatusBadGateway || !restored {
+		t.Errorf("expected a 502 with the user taken out of the trash again, got %d, restored %v", rr.Code, restored)
+	}
+}
+
+func TestRestoreUserIsUndoneWhenTheUnbanFails(t *testing.T) {
+	userID := uuid.New()
+	var trashedAgain bool
+	supabase := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/auth/v1/admin/users/"+userID.String():
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.URL.Query().Get("deleted_at") == "is.null":
+			trashedAgain = true
+			w.Write([]byte(`[{"id":"` + userID.String() + `","deleted_at":"2026-10-19T12:00:00Z"}]`))
+		default:
+			w.Write([]byte(`[{"id":"` + userID.String() + `"}]`))
+		}
+	}))
+	defer supabase.Close()
+	h := &Handlers{users: services.NewUserService(services.NewSupabaseClient(config.Config{SupabaseURL: supabase.URL}))}
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/users/"+userID.String()+"/restore", nil)
+	req.SetPathValue("id", userID.String())
+	rr := httptest.NewRecorder()
+	h.RestoreUserHandler(rr, req)
+	if rr.Code != http.StatusBadGateway || !trashedAgain {
+		t.Errorf("expected a 502 with the user moved back to the trash, got %d, trashed again %v", rr.Code, trashedAgain)
+	}
+}
+
+func TestRestoreUserKeepsTheBanWhenTheUserIsNotInTheTrash(t *testing.T) {
+	userID := uuid.New()
+	var unbanned bool
+	supabase := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/auth/v1/admin/users/"+userID.String() {
+			unbanned = true
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer supabase.Close()
+	h := &Handlers{users: services.NewUserService(services.NewSupabaseClient(config.Config{SupabaseURL: supabase.URL}))}
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/users/"+userID.String()+"/restore", nil)
+	req.SetPathValue("id", userID.String())
+	rr := httptest.NewRecorder()
+	h.RestoreUserHandler(rr, req)
+	if rr.Code != http.StatusNotFound || unbanned {
+		t.Errorf("expected a 404 without touching the ban, got %d, unbanned %v", rr.Code, unbanned)
+	}
+}
@@ -1,22 +1,26 @@
 package handlers
 
 import (
-	"backend/models"
+	"backend/metrics"
 	"backend/services"
 	"backend/utils"
-	"bytes"
+	"backend/validation"
 	"encoding/json"
-	"fmt"
-	"io"
+	"errors"
+	"log/slog"
 	"net/http"
-	"os"
-	"time"
 )
 
 // Common structs for requests and responses
 type CreateUserRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,min=6,max=72"`
+}
+
+// LoginRequest holds the credentials of a password login
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
 }
 
 type SupabaseUser struct {
@@ -24,109 +28,67 @@ type SupabaseUser struct {
 	Email string `json:"email"`
 }
 
+// UpdateUserRequest changes the profile fields it contains. Roles mirror the app_role enum.
 type UpdateUserRequest struct {
-	Email string `json:"email,omitempty"`
-	Role  string `json:"role,omitempty"`
-}
-
-// Utility functions for CORS and request parsing
-func handlePreflight(w http.ResponseWriter) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, PUT, PATCH, GET, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-	w.WriteHeader(http.StatusOK)
-}
-
-func parseRequestBody[T any](r *http.Request) (T, error) {
-	var req T
-	bodyBytes, err := io.ReadAll(r.Body)
-	if err != nil {
-		return req, fmt.Errorf("failed to read request body: %w", err)
-	}
-	defer r.Body.Close()
-
-	if err := json.Unmarshal(bodyBytes, &req); err != nil {
-		return req, fmt.Errorf("failed to parse JSON: %w", err)
-	}
-	return req, nil
+	Email string `json:"email,omitempty" validate:"email,max=254"`
+	Role  string `json:"role,omitempty" validate:"oneof=viewer admin"`
 }
 
-// Supabase API helpers
-func callSupabaseAPI(method, url string, payload interface{}, headers map[string]string) (*http.Response, error) {
-	var body io.Reader
-	if payload != nil {
-		data, err := json.Marshal(payload)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal payload: %w", err)
-		}
-		body = bytes.NewBuffer(data)
+// CreateUserHandler
+func (h *Handlers) CreateUserHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeJSON[CreateUserRequest](r)
+	if err == nil {
+		err = validation.Struct(req)
 	}
-	req, err := http.NewRequest(method, url, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-	for key, value := range headers {
-		req.Header.Set(key, value)
+		writeBodyError(w, r, err)
+		return
 	}
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-	resp, err := client.Do(req)
+	user, err := h.users.CreateUser(r.Context(), req.Email, req.Password)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		writeServiceError(w, r, err, "Failed to create user")
+		return
 	}
 
-	return resp, nil
+	utils.WriteJSONResponse(w, http.StatusCreated, user)
 }
 
-// CreateUserHandler
-func CreateUserHandler(w http.ResponseWriter, r *http.Request) {
-    req, err := parseRequestBody[CreateUserRequest](r)
-    if err != nil {
-        utils.WriteError(w, http.StatusBadRequest, "Invalid request payload")
-        return
-    }
-
-    userService := services.NewUserService()
-    user, err := userService.CreateUser(req.Email, req.Password)
-    if err != nil {
-        utils.WriteError(w, http.StatusInternalServerError, "Failed to create user")
-        return
-    }
-
-    utils.WriteJSONResponse(w, http.StatusCreated, user)
-}
-
-
-func GetUserByIDHandler(w http.ResponseWriter, r *http.Request) {
-	userID := r.PathValue("id")
-	if userID == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+func (h *Handlers) GetUserByIDHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUUID(w, r, "id")
+	if !ok {
 		return
 	}
 
-	var users []models.User
-	err := services.Supabase.DB.From("users").Select("*").Eq("id", userID).Execute(&users)
-	if err != nil || len(users) == 0 {
-		http.Error(w, "User not found", http.StatusNotFound)
+	user, err := h.users.GetUserByID(r.Context(), userID)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to fetch user", "user_id", userID)
+		return
+	}
+	if notModified(w, r, user.Version) {
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(users[0])
+	json.NewEncoder(w).Encode(user)
 }
 
-func UpdateUserByIDHandler(w http.ResponseWriter, r *http.Request) {
-	userID := r.PathValue("id")
-	if userID == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+func (h *Handlers) UpdateUserByIDHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := pathUUID(w, r, "id")
+	if !ok {
 		return
 	}
 
-	updateReq, err := parseRequestBody[UpdateUserRequest](r)
-	if err != nil || (updateReq.Email == "" && updateReq.Role == "") {
-		http.Error(w, "No valid fields to update", http.StatusBadRequest)
+	updateReq, err := decodeJSON[UpdateUserRequest](r)
+	if err == nil {
+		err = validation.Partial(updateReq)
+	}
+	if err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+	if updateReq.Email == "" && updateReq.Role == "" {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeValidationFailed, "No valid fields to update")
 		return
 	}
 
@@ -138,56 +100,57 @@ func UpdateUserByIDHandler(w http.ResponseWriter, r *http.Request) {
 		updatePayload["role"] = updateReq.Role
 	}
 
-	var updatedUsers []map[string]interface{}
-	err = services.Supabase.DB.From("users").Update(updatePayload).Eq("id", userID).Execute(&updatedUsers)
-	if err != nil || len(updatedUsers) == 0 {
-		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+	versions, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	updatedUser, err := h.users.UpdateUser(r.Context(), userID, updatePayload, versions)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to update user", "user_id", userID)
 		return
 	}
 
 	if updateReq.Email != "" {
-		authURL := fmt.Sprintf("%s/auth/v1/admin/users/%s", os.Getenv("SUPABASE_URL"), userID)
-		headers := map[string]string{
-			"Content-Type":  "application/json",
-			"apikey":        os.Getenv("SUPABASE_KEY"),
-			"Authorization": "Bearer " + os.Getenv("SERVICE_ROLE_KEY"),
-		}
-		_, err := callSupabaseAPI(http.MethodPut, authURL, map[string]string{"email": updateReq.Email}, headers)
-		if err != nil {
-			http.Error(w, "Failed to update auth.users", http.StatusInternalServerError)
+		if err := h.users.UpdateAuthEmail(r.Context(), userID, updateReq.Email); err != nil {
+			writeServiceError(w, r, err, "Failed to update auth.users", "user_id", userID)
 			return
 		}
 	}
 
+	w.Header().Set("ETag", etag(updatedUser.Version))
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(updatedUsers[0])
+	json.NewEncoder(w).Encode(updatedUser)
 }
 
-func DeleteUserByIDHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) DeleteUserByIDHandler(w http.ResponseWriter, r *http.Request) {
 	// Extract {id} from the URL
-	userID := r.PathValue("id")
-	if userID == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+	userID, ok := pathUUID(w, r, "id")
+	if !ok {
 		return
 	}
 
-	// Step 1: Delete from public.users
-	err := services.Supabase.DB.From("users").Delete().Eq("id", userID).Execute(nil)
-	if err != nil {
-		http.Error(w, "Failed to delete user", http.StatusInternalServerError)
+	versions, ok := ifMatch(w, r)
+	if !ok {
 		return
 	}
 
-	// Step 2: Delete from auth.users using Supabase Admin API
-	authURL := fmt.Sprintf("%s/auth/v1/admin/users/%s", os.Getenv("SUPABASE_URL"), userID)
-	headers := map[string]string{
-		"apikey":        os.Getenv("SERVICE_ROLE_KEY"),
-		"Authorization": "Bearer " + os.Getenv("SERVICE_ROLE_KEY"),
+	// Step 1: Move the public.users row to the trash
+	err := h.users.DeleteUser(r.Context(), userID, versions)
+	if err != nil {
+		writeServiceError(w, r, err, "Failed to delete user", "user_id", userID)
+		return
 	}
-	_, err = callSupabaseAPI(http.MethodDelete, authURL, nil, headers)
+
+	// Step 2: Ban the auth.users account so it cannot log in while in the trash.
+	// Without the ban the user is taken out of the trash again.
+	err = h.users.SetAuthBanned(r.Context(), userID, true)
 	if err != nil {
-		http.Error(w, "Failed to delete auth user", http.StatusInternalServerError)
+		if _, restoreErr := h.users.RestoreUser(r.Context(), userID); restoreErr != nil {
+			slog.ErrorContext(r.Context(), "Failed to restore user after the ban failed", "user_id", userID, "err", restoreErr)
+		}
+		writeServiceError(w, r, err, "Failed to ban auth user", "user_id", userID)
 		return
 	}
 
@@ -195,49 +158,41 @@ func DeleteUserByIDHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func LoginHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		utils.WriteError(w, r, http.StatusMethodNotAllowed, utils.CodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
-	var credentials struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+	credentials, err := decodeJSON[LoginRequest](r)
+	if err == nil {
+		err = validation.Struct(credentials)
 	}
-	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+	if err != nil {
+		writeBodyError(w, r, err)
 		return
 	}
 
-	authURL := fmt.Sprintf("%s/auth/v1/token?grant_type=password", os.Getenv("SUPABASE_URL"))
-	headers := map[string]string{
-		"Content-Type": "application/json",
-		"apikey":       os.Getenv("SUPABASE_KEY"),
-	}
-	resp, err := callSupabaseAPI(http.MethodPost, authURL, credentials, headers)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+	session, err := h.users.Login(r.Context(), credentials.Email, credentials.Password)
+	if errors.Is(err, services.ErrInvalidCredentials) {
+		metrics.Logins.WithLabelValues(metrics.LoginFailed).Inc()
+		utils.WriteError(w, r, http.StatusUnauthorized, utils.CodeInvalidCredentials, "Invalid credentials")
 		return
 	}
-	defer resp.Body.Close()
-
-	var supabaseResp struct {
-		AccessToken string `json:"access_token"`
-		RefreshToken string `json:"refresh_token"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&supabaseResp); err != nil {
-		http.Error(w, "Failed to parse response", http.StatusInternalServerError)
+	if err != nil {
+		metrics.Logins.WithLabelValues(metrics.LoginFailed).Inc()
+		writeServiceError(w, r, err, "Login failed")
 		return
 	}
+	metrics.Logins.WithLabelValues(metrics.LoginSucceeded).Inc()
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(supabaseResp)
+	json.NewEncoder(w).Encode(session)
 }
 
-func LogoutHandler(w http.ResponseWriter, r *http.Request) {
+func (h *Handlers) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		utils.WriteError(w, r, http.StatusMethodNotAllowed, utils.CodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
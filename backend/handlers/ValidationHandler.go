@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"backend/models"
+	"backend/utils"
+	"backend/validation"
+	"net/http"
+)
+
+// requestSchemas are the request bodies whose validation rules are published to clients
+var requestSchemas = map[string]any{
+	"GameRequest":       models.GameRequest{},
+	"CreateUserRequest": CreateUserRequest{},
+	"UpdateUserRequest": UpdateUserRequest{},
+	"LoginRequest":      LoginRequest{},
+}
+
+// ValidationRulesHandler lists the validation rules of every request body so
+// clients can check input before sending it
+func ValidationRulesHandler(w http.ResponseWriter, r *http.Request) {
+	rules := make(map[string][]validation.FieldRules, len(requestSchemas))
+	for name, schema := range requestSchemas {
+		rules[name] = validation.Describe(schema)
+	}
+	utils.WriteJSONResponse(w, http.StatusOK, rules)
+}
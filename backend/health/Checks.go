@@ -0,0 +1,52 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HTTPCheck reports a dependency as up when url answers with a non-5xx status
+func HTTPCheck(name, url string, headers map[string]string) Checker {
+	client := &http.Client{}
+	return CheckFunc{CheckName: name, Func: func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return nil
+	}}
+}
+
+// Pinger is implemented by *sql.DB
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// DatabaseCheck reports the database as up when it answers a ping
+func DatabaseCheck(name string, db Pinger) Checker {
+	return CheckFunc{CheckName: name, Func: db.PingContext}
+}
+
+// SupabaseChecks returns the REST and auth reachability checks for a Supabase project
+func SupabaseChecks(supabaseURL, apiKey string) []Checker {
+	headers := map[string]string{"apikey": apiKey, "Authorization": "Bearer " + apiKey}
+	return []Checker{
+		HTTPCheck("supabase_rest", supabaseURL+"/rest/v1/", headers),
+		HTTPCheck("supabase_auth", supabaseURL+"/auth/v1/health", headers),
+	}
+}
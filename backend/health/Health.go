@@ -0,0 +1,160 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults used when the readiness configuration leaves them unset
+const (
+	DefaultCheckTimeout = 2 * time.Second
+	DefaultCacheTTL     = 5 * time.Second
+)
+
+// Status values reported by readiness checks
+const (
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+	StatusDraining = "draining"
+	StatusUp       = "up"
+	StatusDown     = "down"
+)
+
+// Checker is a dependency that must be reachable for the service to be ready
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// CheckFunc adapts a function into a Checker
+type CheckFunc struct {
+	CheckName string
+	Func      func(ctx context.Context) error
+}
+
+func (c CheckFunc) Name() string                    { return c.CheckName }
+func (c CheckFunc) Check(ctx context.Context) error { return c.Func(ctx) }
+
+// CheckResult is the outcome of one dependency check
+type CheckResult struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMS int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// Report is the detailed readiness report
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Readiness runs the registered checks with a timeout each, caching results
+// so frequent probes do not hammer the dependencies
+type Readiness struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+	draining atomic.Bool
+
+	mu     sync.Mutex
+	checks []*cachedCheck
+}
+
+type cachedCheck struct {
+	checker Checker
+	mu      sync.Mutex
+	result  CheckResult
+}
+
+// NewReadiness creates a readiness registry; zero durations use the defaults
+func NewReadiness(timeout, cacheTTL time.Duration, checks ...Checker) *Readiness {
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultCacheTTL
+	}
+	r := &Readiness{timeout: timeout, cacheTTL: cacheTTL}
+	for _, c := range checks {
+		r.Register(c)
+	}
+	return r
+}
+
+// Register adds a dependency check
+func (r *Readiness) Register(c Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, &cachedCheck{checker: c})
+}
+
+// SetDraining marks the service as shutting down so it reports not ready
+// while in-flight requests finish
+func (r *Readiness) SetDraining(draining bool) {
+	r.draining.Store(draining)
+}
+
+// Draining reports whether the service is shutting down
+func (r *Readiness) Draining() bool {
+	return r.draining.Load()
+}
+
+// Report runs every check concurrently, reusing results younger than the cache TTL
+func (r *Readiness) Report(ctx context.Context) Report {
+	r.mu.Lock()
+	checks := append([]*cachedCheck(nil), r.checks...)
+	r.mu.Unlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c *cachedCheck) {
+			defer wg.Done()
+			results[i] = r.run(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusReady, Checks: results}
+	for _, result := range results {
+		if result.Status != StatusUp {
+			report.Status = StatusNotReady
+		}
+	}
+	if r.Draining() {
+		report.Status = StatusDraining
+	}
+	return report
+}
+
+func (r *Readiness) run(ctx context.Context, c *cachedCheck) CheckResult {
+	// Holding the per-check lock makes concurrent probes wait for one run instead of each calling out
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.result.CheckedAt.IsZero() && time.Since(c.result.CheckedAt) < r.cacheTTL {
+		return c.result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := c.checker.Check(ctx)
+	result := CheckResult{
+		Name:      c.checker.Name(),
+		Status:    StatusUp,
+		LatencyMS: time.Since(start).Milliseconds(),
+		CheckedAt: start,
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	c.result = result
+	return result
+}
@@ -0,0 +1,84 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadinessCachesResults(t *testing.T) {
+	var calls atomic.Int32
+	check := CheckFunc{CheckName: "counter", Func: func(ctx context.Context) error {
+		calls.Add(1)
+		return nil
+	}}
+	readiness := NewReadiness(time.Second, time.Minute, check)
+
+	for i := 0; i < 3; i++ {
+		if report := readiness.Report(context.Background()); report.Status != StatusReady {
+			t.Fatalf("expected ready, got %s", report.Status)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected the check to run once within the cache TTL, ran %d times", calls.Load())
+	}
+}
+
+func TestReadinessTimesOutSlowChecks(t *testing.T) {
+	slow := CheckFunc{CheckName: "slow", Func: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	failing := CheckFunc{CheckName: "failing", Func: func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}}
+	readiness := NewReadiness(50*time.Millisecond, time.Minute, slow, failing)
+
+	start := time.Now()
+	report := readiness.Report(context.Background())
+	if time.Since(start) > time.Second {
+		t.Errorf("report took too long: %v", time.Since(start))
+	}
+	if report.Status != StatusNotReady {
+		t.Errorf("expected not_ready, got %s", report.Status)
+	}
+	for _, check := range report.Checks {
+		if check.Status != StatusDown || check.Error == "" {
+			t.Errorf("expected %s to be down with an error, got %+v", check.Name, check)
+		}
+	}
+}
+
+func TestReadinessDraining(t *testing.T) {
+	readiness := NewReadiness(0, 0)
+	readiness.SetDraining(true)
+	if status := readiness.Report(context.Background()).Status; status != StatusDraining {
+		t.Errorf("expected draining, got %s", status)
+	}
+}
+
+func TestSupabaseChecks(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("apikey") != "anon" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/auth/v1/health" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer upstream.Close()
+
+	report := NewReadiness(time.Second, time.Minute, SupabaseChecks(upstream.URL, "anon")...).Report(context.Background())
+	statuses := map[string]string{}
+	for _, check := range report.Checks {
+		statuses[check.Name] = check.Status
+	}
+	if statuses["supabase_rest"] != StatusUp || statuses["supabase_auth"] != StatusDown {
+		t.Errorf("unexpected check statuses: %v", statuses)
+	}
+}
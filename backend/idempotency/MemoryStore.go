@@ -0,0 +1,114 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// sweepInterval is how often MemoryStore drops expired keys
+const sweepInterval = time.Minute
+
+// MemoryStore is a Store in process memory. Keys are not shared between
+// instances and are lost on restart.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	Entry
+	expires time.Time
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{now: time.Now, entries: make(map[string]memoryEntry)}
+}
+
+// Reserve implements Store
+func (s *MemoryStore) Reserve(ctx context.Context, key, fingerprint string, lock time.Duration) (Entry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+	if existing, ok := s.entries[key]; ok && now.Before(existing.expires) {
+		entry := existing.Entry
+		entry.Token = ""
+		return entry, false, nil
+	}
+	entry := Entry{Fingerprint: fingerprint, Token: uuid.NewString()}
+	s.entries[key] = memoryEntry{Entry: entry, expires: now.Add(lock)}
+	return entry, true, nil
+}
+
+// Extend implements Store
+func (s *MemoryStore) Extend(ctx context.Context, key, token string, lock time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.held(key, token)
+	if !ok || entry.Response != nil {
+		return ErrNotHeld
+	}
+	entry.expires = s.now().Add(lock)
+	s.entries[key] = entry
+	return nil
+}
+
+// Complete implements Store
+func (s *MemoryStore) Complete(ctx context.Context, key, token string, resp Response, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.held(key, token)
+	if !ok {
+		return ErrNotHeld
+	}
+	entry.Response = &resp
+	entry.expires = s.now().Add(ttl)
+	s.entries[key] = entry
+	return nil
+}
+
+// Release implements Store
+func (s *MemoryStore) Release(ctx context.Context, key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.held(key, token); ok {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// held returns the entry of key if token reserved it. The caller holds s.mu.
+func (s *MemoryStore) held(key, token string) (memoryEntry, bool) {
+	entry, ok := s.entries[key]
+	return entry, ok && token != "" && entry.Token == token
+}
+
+// Len returns the number of keys held, including expired ones not yet swept
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// sweep drops the expired keys. The caller holds s.mu.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, entry := range s.entries {
+		if !now.Before(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
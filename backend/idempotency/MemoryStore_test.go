@@ -0,0 +1,91 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreReservesOnce(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	held, reserved, _ := store.Reserve(ctx, "k", "a", time.Minute)
+	if !reserved || held.Token == "" {
+		t.Fatal("expected a new key to be reserved with a token")
+	}
+	entry, reserved, _ := store.Reserve(ctx, "k", "b", time.Minute)
+	if reserved || entry.Fingerprint != "a" || entry.Response != nil || entry.Token != "" {
+		t.Fatalf("expected the in-flight entry of the first request without its token, got %+v %v", entry, reserved)
+	}
+
+	store.Complete(ctx, "k", held.Token, Response{Status: http.StatusCreated, Body: []byte("{}")}, time.Hour)
+	now = now.Add(30 * time.Minute)
+	entry, reserved, _ = store.Reserve(ctx, "k", "a", time.Minute)
+	if reserved || entry.Response == nil || entry.Response.Status != http.StatusCreated {
+		t.Fatalf("expected the stored response, got %+v %v", entry, reserved)
+	}
+
+	now = now.Add(time.Hour)
+	if _, reserved, _ := store.Reserve(ctx, "k", "c", time.Minute); !reserved {
+		t.Error("expected an expired key to be reserved again")
+	}
+}
+
+func TestMemoryStoreReleaseAndSweep(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	held, _, _ := store.Reserve(ctx, "released", "a", time.Minute)
+	store.Release(ctx, "released", held.Token)
+	if _, reserved, _ := store.Reserve(ctx, "released", "b", time.Minute); !reserved {
+		t.Error("expected a released key to be reserved again")
+	}
+
+	store.Reserve(ctx, "stale", "a", time.Second)
+	now = now.Add(2 * sweepInterval)
+	store.Reserve(ctx, "fresh", "a", time.Minute)
+	if n := store.Len(); n != 1 {
+		t.Errorf("expected expired keys to be swept, %d keys left", n)
+	}
+}
+
+func TestMemoryStoreOnlyLetsTheHolderActOnAKey(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	first, _, _ := store.Reserve(ctx, "k", "a", time.Minute)
+	now = now.Add(50 * time.Second)
+	if err := store.Extend(ctx, "k", first.Token, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(50 * time.Second)
+	if _, reserved, _ := store.Reserve(ctx, "k", "a", time.Minute); reserved {
+		t.Fatal("expected an extended lock to still hold the key")
+	}
+
+	// Once the lock expires another request claims the key, and the first can no longer touch it
+	now = now.Add(2 * time.Minute)
+	second, reserved, _ := store.Reserve(ctx, "k", "a", time.Minute)
+	if !reserved || second.Token == first.Token {
+		t.Fatalf("expected an expired lock to be claimed with a new token, got %+v %v", second, reserved)
+	}
+	if err := store.Extend(ctx, "k", first.Token, time.Minute); err != ErrNotHeld {
+		t.Errorf("expected Extend by the old holder to fail with ErrNotHeld, got %v", err)
+	}
+	if err := store.Complete(ctx, "k", first.Token, Response{Status: http.StatusCreated}, time.Hour); err != ErrNotHeld {
+		t.Errorf("expected Complete by the old holder to fail with ErrNotHeld, got %v", err)
+	}
+	store.Release(ctx, "k", first.Token)
+	entry, reserved, _ := store.Reserve(ctx, "k", "a", time.Minute)
+	if reserved || entry.Response != nil {
+		t.Errorf("expected the key to stay locked by the second request, got %+v %v", entry, reserved)
+	}
+}
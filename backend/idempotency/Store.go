@@ -0,0 +1,46 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrNotHeld is returned when a request acts on a key it no longer holds,
+// because its lock expired and another request claimed the key
+var ErrNotHeld = errors.New("idempotency key is not held by this request")
+
+// Response is the final response of a request, kept to be replayed to retries
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Entry is what a Store holds for an idempotency key
+type Entry struct {
+	Fingerprint string    // hash of the request that claimed the key
+	Response    *Response // nil while that request is in flight
+	// Token identifies the reservation. Reserve only sets it on the entry of a
+	// new reservation, and the holder passes it back to Extend, Complete and Release.
+	Token string
+}
+
+// Store keeps idempotency keys with the request that claimed them and its
+// response. Keys and fingerprints are opaque to the store. Implementations
+// must be safe for concurrent use, and Reserve must be atomic so that only one
+// of several concurrent requests with the same key claims it.
+type Store interface {
+	// Reserve claims key for a request with fingerprint until lock passes and
+	// returns the entry with its token. When the key is already held it returns
+	// the existing entry, without token, and false.
+	Reserve(ctx context.Context, key, fingerprint string, lock time.Duration) (Entry, bool, error)
+	// Extend keeps key locked for another lock while the request holding token runs
+	Extend(ctx context.Context, key, token string, lock time.Duration) error
+	// Complete stores the response of the request holding token until ttl passes
+	Complete(ctx context.Context, key, token string, resp Response, ttl time.Duration) error
+	// Release frees key without a response, so a retry runs the request again.
+	// It does nothing when token no longer holds key.
+	Release(ctx context.Context, key, token string) error
+}
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// defaultRedactKeys are attribute keys whose values are never written out
+var defaultRedactKeys = []string{
+	"password",
+	"token",
+	"access_token",
+	"refresh_token",
+	"authorization",
+	"apikey",
+	"api_key",
+	"secret",
+	"jwt_secret",
+	"service_role_key",
+	"cookie",
+	"set-cookie",
+	"email",
+}
+
+// sensitiveFragments mark any key containing them as sensitive, e.g. "new_password"
+var sensitiveFragments = []string{"password", "token", "secret"}
+
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`)
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/\-]+=*`)
+	// secretParamPattern matches credentials in query strings and form bodies, e.g. "?apikey=..."
+	secretParamPattern = regexp.MustCompile(`(?i)\b([A-Za-z_\-]*(?:apikey|api_key|token|password|secret|service_role_key))=[^&\s"']+`)
+)
+
+// Redactor masks sensitive attributes before they reach the log output
+type Redactor struct {
+	keys map[string]struct{}
+}
+
+// NewRedactor creates a Redactor covering the built-in keys plus extraKeys
+func NewRedactor(extraKeys []string) *Redactor {
+	r := &Redactor{keys: make(map[string]struct{})}
+	for _, key := range append(defaultRedactKeys, extraKeys...) {
+		r.keys[normalizeKey(key)] = struct{}{}
+	}
+	return r
+}
+
+// ReplaceAttr implements slog.HandlerOptions.ReplaceAttr. Attributes with a
+// sensitive key are replaced entirely; other string values are scrubbed of
+// anything that looks like an email address or a token. The log message goes
+// through here too, under slog.MessageKey, so it is scrubbed the same way.
+func (r *Redactor) ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if r.IsSensitiveKey(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Scrub(a.Value.String()))
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Scrub(err.Error()))
+		}
+	}
+	return a
+}
+
+// IsSensitiveKey reports whether values stored under key must be redacted
+func (r *Redactor) IsSensitiveKey(key string) bool {
+	key = normalizeKey(key)
+	if _, ok := r.keys[key]; ok {
+		return true
+	}
+	for _, fragment := range sensitiveFragments {
+		if strings.Contains(key, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scrub masks email addresses, JWTs, bearer credentials and secret query
+// parameters inside free text
+func Scrub(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	s = secretParamPattern.ReplaceAllString(s, "${1}="+redacted)
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	return emailPattern.ReplaceAllString(s, redacted)
+}
+
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.TrimSpace(key))
+}
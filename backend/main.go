@@ -1,36 +1,144 @@
 package main
 
 import (
-	"log"
-	"net/http"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"backend/app"
 	"backend/config"
-	"backend/middleware"
 	"backend/routes"
-	"backend/services"
+	"backend/rpc"
+	"backend/server"
+	"backend/tracing"
 )
 
 func main() {
-	// Load configuration
-	cfg := config.LoadConfig()
-	services.InitSupabase(cfg)
+	// Bulk subcommands run once against Supabase instead of serving
+	if len(os.Args) > 1 && (os.Args[1] == "import" || os.Args[1] == "export") {
+		os.Exit(runBulk(os.Args[1], os.Args[2:]))
+	}
+
+	// Load configuration: defaults, config file, environment, then flags
+	cfg, printConfig, err := loadConfig(flag.ExitOnError)
+	if printConfig {
+		cfg.Dump(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(2)
+	}
+	if printConfig {
+		return
+	}
+
+	// Build the config, shared HTTP client and services once for the whole process
+	application, err := app.New(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to start: %v\n", err)
+		os.Exit(1)
+	}
+	defer application.Close()
 
-	// Create a base multiplexer
-	mux := http.NewServeMux()
-	// Register public routes (no middleware)
-	routes.RegisterPublicRoutes(mux)
+	// Route both slog and the standard log package through the structured logger
+	slog.SetDefault(application.Logger)
 
-	// Create a sub-mux for secured routes
-	securedMux := http.NewServeMux()
-	routes.RegisterSecuredRoutes(securedMux)
+	// Export spans for incoming requests and outbound Supabase calls
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		slog.Error("Failed to set up tracing", "err", err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
 
-	// Wrap the secured mux in middleware
-	mux.Handle("/users/", middleware.ValidateJWT(securedMux))
+	// Route groups declare their own middleware; the router adds the global stack
+	handler, err := routes.NewRouter(application)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		os.Exit(2)
+	}
 
-	// Start the server
-	log.Println("Server is running on port 8080")
-	err := http.ListenAndServe(":8080", mux)
+	srv, err := server.New(cfg.Server, handler)
 	if err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+		slog.Error("Failed to configure server", "err", err)
+		os.Exit(1)
+	}
+	// Fail readiness as soon as shutdown starts so traffic drains away first
+	srv.OnShutdown(func() { application.Readiness.SetDraining(true) })
+
+	// Re-read the configuration on SIGHUP and apply the settings that are safe to change
+	go reloadOnHangup(application)
+
+	// Serve until SIGINT or SIGTERM, then drain in-flight requests
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Delete games and users for good once they have been in the trash for the retention period
+	go application.RunPurge(ctx)
+
+	// The gRPC API for internal services runs on its own port; if it fails, the HTTP server stops too
+	rpcDone := make(chan error, 1)
+	if cfg.GRPC.Addr != "" {
+		rpcServer := newRPCServer(application, srv)
+		go func() {
+			rpcDone <- rpcServer.Run(ctx, cfg.GRPC.Addr)
+			stop()
+		}()
+	} else {
+		rpcDone <- nil
+	}
+
+	err = srv.Run(ctx)
+	stop()
+	if err = errors.Join(err, <-rpcDone); err != nil {
+		slog.Error("Server stopped with error", "err", err)
+		shutdownTracing(context.Background())
+		application.Close()
+		os.Exit(1)
+	}
+}
+
+// newRPCServer builds the gRPC API on the services of application. It serves
+// the certificate of the HTTP server when that serves TLS.
+func newRPCServer(application *app.App, srv *server.Server) *rpc.Server {
+	cfg := application.Config()
+	return rpc.New(
+		rpc.Services{Games: application.Games, Users: application.Users, Results: application.Results},
+		application.Cursors,
+		rpc.Options{
+			JWTSecret:       cfg.JWTSecret,
+			Reflection:      cfg.GRPC.Reflection,
+			TLS:             srv.TLSConfig(),
+			DefaultPageSize: cfg.Pagination.DefaultLimit,
+			MaxPageSize:     cfg.Pagination.MaxLimit,
+			ShutdownTimeout: cfg.Server.ShutdownTimeout,
+		},
+	)
+}
+
+// loadConfig parses the command line and loads the configuration.
+// printConfig reports whether -print-config was given.
+func loadConfig(errorHandling flag.ErrorHandling) (cfg config.Config, printConfig bool, err error) {
+	flags := flag.NewFlagSet(os.Args[0], errorHandling)
+	printFlag := flags.Bool("print-config", false, "print the effective configuration with secrets masked and exit")
+	cfg, err = config.Load(flags, os.Args[1:])
+	return cfg, *printFlag, err
+}
+
+func reloadOnHangup(application *app.App) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	for range hangup {
+		cfg, _, err := loadConfig(flag.ContinueOnError)
+		if err != nil {
+			slog.Error("Configuration reload rejected, keeping the current settings", "err", err)
+			continue
+		}
+		application.Reload(cfg)
 	}
 }
@@ -0,0 +1,204 @@
+package metrics
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "ilang"
+
+// Registry holds every collector exposed on /metrics. A dedicated registry keeps
+// tests and other packages from polluting the global default one.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+// HTTP server metrics, labelled by route pattern rather than raw path to keep cardinality bounded
+var (
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests handled, by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency, by method, route pattern and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	HTTPInFlight = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+
+	HTTPDeprecated = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "deprecated_requests_total",
+		Help:      "HTTP requests to deprecated routes and unversioned paths, by method and route pattern.",
+	}, []string{"method", "route"})
+)
+
+// gRPC server metrics, labelled by full method name and status code
+var (
+	GRPCRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "requests_total",
+		Help:      "gRPC calls handled, by method and status code.",
+	}, []string{"method", "code"})
+
+	GRPCDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "grpc",
+		Name:      "request_duration_seconds",
+		Help:      "gRPC call latency, by method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "code"})
+)
+
+// SupabaseDuration tracks outbound calls to Supabase
+var SupabaseDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Subsystem: "supabase",
+	Name:      "request_duration_seconds",
+	Help:      "Outbound Supabase request latency, by table, operation and status code.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"table", "operation", "status"})
+
+// Resilience of the Supabase client
+var (
+	SupabaseRetries = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "supabase",
+		Name:      "retries_total",
+		Help:      "Supabase request attempts retried after a transient failure, by table and operation.",
+	}, []string{"table", "operation"})
+
+	SupabaseBreakerOpen = factory.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "supabase",
+		Name:      "circuit_open",
+		Help:      "1 while the Supabase circuit breaker is failing requests fast, otherwise 0.",
+	})
+)
+
+// Domain counters
+var (
+	Signups = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "signups_total",
+		Help:      "Users successfully signed up.",
+	})
+
+	Logins = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts, by result (succeeded or failed).",
+	}, []string{"result"})
+
+	GamesCreated = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "games_created_total",
+		Help:      "Games successfully created.",
+	})
+
+	TrashPurged = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "trash_purged_total",
+		Help:      "Rows deleted for good after their trash retention, by table (games or users).",
+	}, []string{"table"})
+)
+
+// Login results
+const (
+	LoginSucceeded = "succeeded"
+	LoginFailed    = "failed"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	// Initialise the login series so both results are exported from the start
+	Logins.WithLabelValues(LoginSucceeded)
+	Logins.WithLabelValues(LoginFailed)
+}
+
+// Handler serves the registry in the Prometheus text exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTP records a completed HTTP request
+func ObserveHTTP(method, route string, status int, elapsed time.Duration) {
+	HTTPRequests.WithLabelValues(method, route, statusLabel(status)).Inc()
+	HTTPDuration.WithLabelValues(method, route, statusLabel(status)).Observe(elapsed.Seconds())
+}
+
+// ObserveGRPC records one handled gRPC call
+func ObserveGRPC(method, code string, elapsed time.Duration) {
+	GRPCRequests.WithLabelValues(method, code).Inc()
+	GRPCDuration.WithLabelValues(method, code).Observe(elapsed.Seconds())
+}
+
+// ObserveSupabase records the latency of one outbound Supabase call.
+// A nil response means the request failed before a status was received.
+func ObserveSupabase(table, operation string, resp *http.Response, elapsed time.Duration) {
+	status := "error"
+	if resp != nil {
+		status = statusLabel(resp.StatusCode)
+	}
+	SupabaseDuration.WithLabelValues(table, operation, status).Observe(elapsed.Seconds())
+}
+
+// SupabaseLabels derives the table and operation labels from a Supabase request URL,
+// e.g. PATCH /rest/v1/games is ("games", "update") and POST /auth/v1/signup is ("auth", "signup")
+func SupabaseLabels(method string, u *url.URL) (table, operation string) {
+	path := strings.Trim(u.Path, "/")
+	switch {
+	case strings.HasPrefix(path, "rest/v1/"):
+		table, _, _ = strings.Cut(strings.TrimPrefix(path, "rest/v1/"), "/")
+		return table, restOperation(method)
+	case strings.HasPrefix(path, "auth/v1/"):
+		operation, _, _ = strings.Cut(strings.TrimPrefix(path, "auth/v1/"), "/")
+		if operation == "admin" {
+			operation += "_" + strings.ToLower(method)
+		}
+		return "auth", operation
+	}
+	return "other", strings.ToLower(method)
+}
+
+func restOperation(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return "select"
+	case http.MethodPost:
+		return "insert"
+	case http.MethodPatch, http.MethodPut:
+		return "update"
+	case http.MethodDelete:
+		return "delete"
+	}
+	return strings.ToLower(method)
+}
+
+func statusLabel(code int) string {
+	return strconv.Itoa(code)
+}
@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSupabaseLabels(t *testing.T) {
+	cases := []struct {
+		method, url, table, operation string
+	}{
+		{http.MethodGet, "https://x.supabase.co/rest/v1/games?id=eq.1", "games", "select"},
+		{http.MethodPatch, "https://x.supabase.co/rest/v1/users?id=eq.1", "users", "update"},
+		{http.MethodPost, "https://x.supabase.co/auth/v1/signup", "auth", "signup"},
+		{http.MethodPost, "https://x.supabase.co/auth/v1/token?grant_type=password", "auth", "token"},
+		{http.MethodDelete, "https://x.supabase.co/auth/v1/admin/users/abc", "auth", "admin_delete"},
+	}
+	for _, c := range cases {
+		u, _ := url.Parse(c.url)
+		table, operation := SupabaseLabels(c.method, u)
+		if table != c.table || operation != c.operation {
+			t.Errorf("SupabaseLabels(%s %s) = (%s, %s), want (%s, %s)", c.method, c.url, table, operation, c.table, c.operation)
+		}
+	}
+}
+
+func TestHandlerExposesTextFormat(t *testing.T) {
+	GamesCreated.Inc()
+
+	rr := httptest.NewRecorder()
+	Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("expected text exposition format, got %q", rr.Header().Get("Content-Type"))
+	}
+	for _, name := range []string{"ilang_games_created_total", "ilang_logins_total{result=\"failed\"}", "ilang_http_requests_in_flight"} {
+		if !strings.Contains(rr.Body.String(), name) {
+			t.Errorf("expected %s in metrics output", name)
+		}
+	}
+}
@@ -1,13 +1,14 @@
 package middleware
 
 import (
-	"backend/config"
+	"backend/utils"
 	"context"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 )
 
 type contextKey string
@@ -17,65 +18,114 @@ const (
 	RoleContextKey   contextKey = "role"
 )
 
-// Middleware to validate JWT
-func ValidateJWT(next http.Handler) http.Handler {
-	cfg := config.LoadConfig() // loading .env
+// ValidateJWT returns middleware that validates the bearer token against the Supabase JWT secret
+func ValidateJWT(secret string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return validateJWT(secret, next)
+	}
+}
 
+func validateJWT(secret string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header missing", http.StatusUnauthorized)
+		userID, role, err := Authenticate(secret, r.Header.Get("Authorization"))
+		if err != nil {
+			utils.WriteError(w, r, http.StatusUnauthorized, err.Code, err.Message)
 			return
 		}
 
-		// Extract the token from the "Bearer <token>" format
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
-			return
-		}
+		// Proceed to the next handler with userID and role in the request context
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), userID, role)))
+	})
+}
 
-		// Parse and validate the token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			secret := cfg.JWTSecret
-			if secret == "" {
-				return nil, fmt.Errorf("JWT secret is missing")
-			}
-			return []byte(secret), nil
-		})
+// AuthError is a rejected access token. Code and Message are fit for clients.
+type AuthError struct {
+	Code    string
+	Message string
+}
 
-		if err != nil || !token.Valid {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-			return
-		}
+func (e *AuthError) Error() string { return e.Message }
 
-		// Extract claims
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			http.Error(w, "Invalid token claims", http.StatusUnauthorized)
-			return
-		}
+// Authenticate validates the "Bearer <token>" value of an Authorization header
+// against the Supabase JWT secret and returns the user ID and role it names
+func Authenticate(secret, authHeader string) (uuid.UUID, string, *AuthError) {
+	if authHeader == "" {
+		return uuid.Nil, "", &AuthError{utils.CodeUnauthorized, "Authorization header missing"}
+	}
 
-		userID, ok := claims["sub"].(string)
-		if !ok || userID == "" {
-			http.Error(w, "Invalid token: 'sub' claim is missing", http.StatusUnauthorized)
-			return
-		}
+	// Extract the token from the "Bearer <token>" format
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+	if tokenString == authHeader {
+		return uuid.Nil, "", &AuthError{utils.CodeInvalidToken, "Invalid Authorization header format"}
+	}
 
-		role, ok := claims["role"].(string)
-		if !ok || role == "" {
-			http.Error(w, "Invalid token: 'role' claim is missing", http.StatusUnauthorized)
-			return
+	// Parse and validate the token
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
+		if secret == "" {
+			return nil, fmt.Errorf("JWT secret is missing")
+		}
+		return []byte(secret), nil
+	})
 
-		// Add userID and role to request context
-		ctx := context.WithValue(r.Context(), UserIDContextKey, userID)
-		ctx = context.WithValue(ctx, RoleContextKey, role)
+	if err != nil || !token.Valid {
+		return uuid.Nil, "", &AuthError{utils.CodeInvalidToken, "Invalid token"}
+	}
 
-		// Proceed to the next handler
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	// Extract claims
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return uuid.Nil, "", &AuthError{utils.CodeInvalidToken, "Invalid token claims"}
+	}
+
+	sub, ok := claims["sub"].(string)
+	if !ok || sub == "" {
+		return uuid.Nil, "", &AuthError{utils.CodeInvalidToken, "Invalid token: 'sub' claim is missing"}
+	}
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		return uuid.Nil, "", &AuthError{utils.CodeInvalidToken, "Invalid token: 'sub' claim is not a user ID"}
+	}
+
+	role, ok := claims["role"].(string)
+	if !ok || role == "" {
+		return uuid.Nil, "", &AuthError{utils.CodeInvalidToken, "Invalid token: 'role' claim is missing"}
+	}
+	return userID, role, nil
+}
+
+// WithPrincipal returns ctx carrying the authenticated user read by UserID and Role
+func WithPrincipal(ctx context.Context, userID uuid.UUID, role string) context.Context {
+	ctx = context.WithValue(ctx, UserIDContextKey, userID)
+	return context.WithValue(ctx, RoleContextKey, role)
+}
+
+// UserID returns the ID of the authenticated user set by ValidateJWT
+func UserID(ctx context.Context) (uuid.UUID, bool) {
+	userID, ok := ctx.Value(UserIDContextKey).(uuid.UUID)
+	return userID, ok
+}
+
+// Role returns the JWT role of the authenticated user set by ValidateJWT
+func Role(ctx context.Context) string {
+	role, _ := ctx.Value(RoleContextKey).(string)
+	return role
+}
+
+// RequireRole rejects requests whose JWT role is not one of roles. It must run after ValidateJWT.
+func RequireRole(roles ...string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role := Role(r.Context())
+			for _, allowed := range roles {
+				if role == allowed {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			utils.WriteError(w, r, http.StatusForbidden, utils.CodeForbidden, "Forbidden")
+		})
+	}
 }
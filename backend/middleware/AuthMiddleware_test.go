@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+func TestValidateJWTParsesUserID(t *testing.T) {
+	const secret = "test-secret"
+	want := uuid.MustParse("5803acaf-821a-4463-b8b4-15ac6e0e466a")
+
+	var got uuid.UUID
+	handler := ValidateJWT(secret)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = UserID(r.Context())
+	}))
+
+	for sub, status := range map[string]int{want.String(): http.StatusOK, "user-1": http.StatusUnauthorized} {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": sub, "role": "authenticated"}).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/games", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != status {
+			t.Errorf("sub %q: expected %d, got %d", sub, status, rr.Code)
+		}
+	}
+	if got != want {
+		t.Errorf("expected user ID %s in the context, got %s", want, got)
+	}
+}
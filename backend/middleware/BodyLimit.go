@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"backend/utils"
+	"fmt"
+	"net/http"
+)
+
+// MaxBodyBytes caps request bodies at limit bytes. Requests that announce a
+// larger body are rejected up front; handlers see an *http.MaxBytesError
+// when a body without a Content-Length runs over. A limit of 0 disables the cap.
+func MaxBodyBytes(limit int) Middleware {
+	return func(next http.Handler) http.Handler {
+		if limit <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > int64(limit) {
+				utils.WriteError(w, r, http.StatusRequestEntityTooLarge, utils.CodeBodyTooLarge,
+					fmt.Sprintf("The request body must not exceed %d bytes", limit))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, int64(limit))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
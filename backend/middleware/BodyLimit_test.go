@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"backend/utils"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxBodyBytes(t *testing.T) {
+	var readErr error
+	handler := MaxBodyBytes(8)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+	}))
+
+	// A declared length over the limit never reaches the handler
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/games", strings.NewReader(`{"title":"x"}`)))
+	if rr.Code != http.StatusRequestEntityTooLarge || rr.Header().Get("Content-Type") != utils.ProblemContentType {
+		t.Errorf("expected a 413 problem, got %d %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+
+	// A streamed body is cut off while the handler reads it
+	req := httptest.NewRequest(http.MethodPost, "/games", io.NopCloser(strings.NewReader(`{"title":"x"}`)))
+	req.ContentLength = -1
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	var maxErr *http.MaxBytesError
+	if !errors.As(readErr, &maxErr) {
+		t.Errorf("expected a MaxBytesError, got %v", readErr)
+	}
+}
@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"backend/config"
+	"backend/logging"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Defaults used when the corresponding CORS setting is empty
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions}
+	defaultCORSHeaders = []string{"Authorization", "Content-Type", logging.RequestIDHeader, "API-Version", "If-Match", "If-None-Match", IdempotencyKeyHeader}
+	defaultCORSExposed = []string{logging.RequestIDHeader, "X-Total-Count", "X-Next-Cursor", "Link", "API-Version", "Deprecation", "Sunset", "ETag", ReplayedHeader}
+)
+
+const defaultCORSMaxAge = 600
+
+// CORS answers preflight requests and adds CORS headers for allowed origins.
+// It runs before routing and authentication, because browsers send
+// preflights without credentials and method specific routes never match OPTIONS.
+func CORS(cfg config.CORSConfig) Middleware {
+	return NewCORSHandler(cfg).Middleware
+}
+
+// CORSHandler is the CORS middleware with a policy that can be replaced at runtime
+type CORSHandler struct {
+	policy atomic.Pointer[corsPolicy]
+}
+
+// NewCORSHandler creates a CORSHandler enforcing cfg
+func NewCORSHandler(cfg config.CORSConfig) *CORSHandler {
+	c := &CORSHandler{}
+	c.Reload(cfg)
+	return c
+}
+
+// Reload swaps in a policy built from cfg. Requests already past the check keep the old one.
+func (c *CORSHandler) Reload(cfg config.CORSConfig) {
+	c.policy.Store(newCORSPolicy(cfg))
+}
+
+// Middleware applies the current policy, see CORS
+func (c *CORSHandler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		policy := c.policy.Load()
+		w.Header().Add("Vary", "Origin")
+		allowed := policy.allowsOrigin(origin)
+		if allowed {
+			policy.writeOriginHeaders(w.Header(), origin)
+		}
+
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if !preflight {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		if allowed {
+			policy.writePreflightHeaders(w.Header())
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+type corsPolicy struct {
+	anyOrigin        bool
+	origins          map[string]struct{}
+	wildcards        []wildcardOrigin
+	methods          string
+	headers          string
+	exposed          string
+	allowCredentials bool
+	maxAge           string
+}
+
+// wildcardOrigin matches any subdomain of suffix for the given scheme,
+// e.g. "https://*.example.com" matches "https://app.example.com"
+type wildcardOrigin struct {
+	scheme string
+	suffix string
+}
+
+func newCORSPolicy(cfg config.CORSConfig) *corsPolicy {
+	p := &corsPolicy{
+		origins:          make(map[string]struct{}),
+		methods:          strings.Join(orDefault(cfg.AllowedMethods, defaultCORSMethods), ", "),
+		headers:          strings.Join(orDefault(cfg.AllowedHeaders, defaultCORSHeaders), ", "),
+		exposed:          strings.Join(orDefault(cfg.ExposedHeaders, defaultCORSExposed), ", "),
+		allowCredentials: cfg.AllowCredentials,
+		maxAge:           strconv.Itoa(defaultCORSMaxAge),
+	}
+	if cfg.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(cfg.MaxAge)
+	}
+
+	for _, origin := range cfg.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+		switch {
+		case origin == "*":
+			p.anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			scheme, host, _ := strings.Cut(origin, "://*")
+			p.wildcards = append(p.wildcards, wildcardOrigin{scheme: scheme + "://", suffix: host})
+		default:
+			p.origins[origin] = struct{}{}
+		}
+	}
+	return p
+}
+
+func (p *corsPolicy) allowsOrigin(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if _, ok := p.origins[origin]; ok {
+		return true
+	}
+	for _, w := range p.wildcards {
+		host, ok := strings.CutPrefix(origin, w.scheme)
+		if ok && len(host) > len(w.suffix) && strings.HasSuffix(host, w.suffix) && !strings.Contains(host, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *corsPolicy) writeOriginHeaders(h http.Header, origin string) {
+	// A literal "*" is not allowed together with credentials, so reflect the origin instead
+	if p.anyOrigin && !p.allowCredentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		h.Set("Access-Control-Allow-Origin", origin)
+	}
+	if p.allowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if p.exposed != "" {
+		h.Set("Access-Control-Expose-Headers", p.exposed)
+	}
+}
+
+func (p *corsPolicy) writePreflightHeaders(h http.Header) {
+	h.Set("Access-Control-Allow-Methods", p.methods)
+	h.Set("Access-Control-Allow-Headers", p.headers)
+	h.Set("Access-Control-Max-Age", p.maxAge)
+}
+
+func orDefault(values, defaults []string) []string {
+	if len(values) == 0 {
+		return defaults
+	}
+	return values
+}
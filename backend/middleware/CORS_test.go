@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"backend/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSPreflight(t *testing.T) {
+	cfg := config.CORSConfig{
+		AllowedOrigins:   []string{"https://app.ilang.dev", "https://*.preview.ilang.dev"},
+		AllowCredentials: true,
+		MaxAge:           300,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("PATCH /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	handler := CORS(cfg)(mux)
+
+	cases := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://app.ilang.dev", true},
+		{"https://pr-12.preview.ilang.dev", true},
+		{"https://preview.ilang.dev", false},
+		{"http://pr-12.preview.ilang.dev", false},
+		{"https://evil.example.com", false},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodOptions, "/users/42", nil)
+		req.Header.Set("Origin", c.origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPatch)
+		req.Header.Set("Access-Control-Request-Headers", "authorization, content-type")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNoContent {
+			t.Errorf("%s: expected preflight to be answered with 204, got %d", c.origin, rr.Code)
+		}
+		gotOrigin := rr.Header().Get("Access-Control-Allow-Origin")
+		if c.allowed && gotOrigin != c.origin {
+			t.Errorf("%s: expected origin to be allowed, got %q", c.origin, gotOrigin)
+		}
+		if !c.allowed && gotOrigin != "" {
+			t.Errorf("%s: expected origin to be rejected, got %q", c.origin, gotOrigin)
+		}
+		if c.allowed {
+			if rr.Header().Get("Access-Control-Allow-Credentials") != "true" {
+				t.Errorf("%s: expected credentials to be allowed", c.origin)
+			}
+			if rr.Header().Get("Access-Control-Allow-Headers") != "Authorization, Content-Type, X-Request-ID, API-Version, If-Match, If-None-Match, Idempotency-Key" {
+				t.Errorf("%s: unexpected allowed headers %q", c.origin, rr.Header().Get("Access-Control-Allow-Headers"))
+			}
+			if rr.Header().Get("Access-Control-Max-Age") != "300" {
+				t.Errorf("%s: unexpected max age %q", c.origin, rr.Header().Get("Access-Control-Max-Age"))
+			}
+		}
+	}
+}
+
+func TestCORSSimpleRequest(t *testing.T) {
+	handler := CORS(config.CORSConfig{AllowedOrigins: []string{"*"}})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/users", nil)
+	req.Header.Set("Origin", "https://anywhere.example")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Errorf("expected request to reach the handler, got %d", rr.Code)
+	}
+	if rr.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("expected wildcard origin, got %q", rr.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if rr.Header().Get("Access-Control-Expose-Headers") != "X-Request-ID, X-Total-Count, X-Next-Cursor, Link, API-Version, Deprecation, Sunset, ETag, Idempotent-Replayed" {
+		t.Errorf("expected the request ID, pagination, versioning, ETag and replay headers to be exposed, got %q", rr.Header().Get("Access-Control-Expose-Headers"))
+	}
+}
+
+func TestCORSReload(t *testing.T) {
+	cors := NewCORSHandler(config.CORSConfig{AllowedOrigins: []string{"https://old.ilang.dev"}})
+	handler := cors.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	allowOrigin := func(origin string) string {
+		req := httptest.NewRequest(http.MethodGet, "/games", nil)
+		req.Header.Set("Origin", origin)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	if got := allowOrigin("https://new.ilang.dev"); got != "" {
+		t.Fatalf("expected new origin to be rejected before reload, got %q", got)
+	}
+	cors.Reload(config.CORSConfig{AllowedOrigins: []string{"https://new.ilang.dev"}})
+	if got := allowOrigin("https://new.ilang.dev"); got != "https://new.ilang.dev" {
+		t.Errorf("expected new origin to be allowed after reload, got %q", got)
+	}
+	if got := allowOrigin("https://old.ilang.dev"); got != "" {
+		t.Errorf("expected old origin to be rejected after reload, got %q", got)
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import "net/http"
+
+// Middleware wraps an http.Handler with additional behaviour
+type Middleware func(http.Handler) http.Handler
+
+// Chain is an ordered middleware stack. The first middleware is the outermost,
+// so it sees the request first and the response last.
+type Chain []Middleware
+
+// NewChain creates a chain from the given middleware
+func NewChain(middleware ...Middleware) Chain {
+	return append(Chain(nil), middleware...)
+}
+
+// Append returns a new chain with extra middleware added to the inside of
+// the stack, leaving the receiver untouched so it can be shared
+func (c Chain) Append(middleware ...Middleware) Chain {
+	chain := make(Chain, 0, len(c)+len(middleware))
+	chain = append(chain, c...)
+	return append(chain, middleware...)
+}
+
+// Then wraps h with every middleware in the chain
+func (c Chain) Then(h http.Handler) http.Handler {
+	for i := len(c) - 1; i >= 0; i-- {
+		h = c[i](h)
+	}
+	return h
+}
+
+// ThenFunc is Then for plain handler functions
+func (c Chain) ThenFunc(fn http.HandlerFunc) http.Handler {
+	return c.Then(fn)
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func tag(name string, calls *[]string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	var calls []string
+	base := NewChain(tag("global", &calls))
+	group := base.Append(tag("group", &calls))
+	route := group.Append(tag("route", &calls))
+
+	handler := route.ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "handler")
+	})
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got := strings.Join(calls, ","); got != "global,group,route,handler" {
+		t.Errorf("unexpected middleware order: %s", got)
+	}
+	if len(base) != 1 || len(group) != 2 {
+		t.Errorf("Append must not modify the parent chain: base=%d group=%d", len(base), len(group))
+	}
+}
+
+func TestRecoverReturnsProblem(t *testing.T) {
+	handler := Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/games", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != utils.ProblemContentType {
+		t.Errorf("expected problem+json content type, got %q", ct)
+	}
+	var body utils.Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil || body.Code != utils.CodeInternal || body.Status != 500 || body.Instance != "/games" {
+		t.Errorf("expected internal_error problem, got %q (%v)", rr.Body.String(), err)
+	}
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"backend/metrics"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Deprecation announces that a route is going away
+type Deprecation struct {
+	Since  time.Time // when the route was deprecated
+	Sunset time.Time // when it stops being served, zero while no date is set
+	Link   string    // documentation of the replacement, optional
+}
+
+// WriteHeaders sets the Deprecation (RFC 9745) and Sunset (RFC 8594) headers,
+// and links the documentation when there is one
+func (d Deprecation) WriteHeaders(h http.Header) {
+	h.Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+	if !d.Sunset.IsZero() {
+		h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Link != "" {
+		h.Add("Link", "<"+d.Link+`>; rel="deprecation"`)
+	}
+}
+
+// Deprecate returns middleware announcing d on every response and counting the
+// requests by route pattern, so the route can be removed once they stop
+func Deprecate(d Deprecation) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d.WriteHeaders(w.Header())
+			metrics.HTTPDeprecated.WithLabelValues(r.Method, RoutePattern(r.Context())).Inc()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package middleware
+
+import (
+	"backend/config"
+	"backend/idempotency"
+	"backend/logging"
+	"backend/utils"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Headers of idempotent requests
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	ReplayedHeader       = "Idempotent-Replayed"
+)
+
+// maxIdempotencyKeyLength bounds the keys clients may send
+const maxIdempotencyKeyLength = 255
+
+// Idempotency lets clients retry a request safely by sending the same
+// Idempotency-Key header. The first request with a key runs and its final
+// response is stored for cfg.TTL; retries get that response replayed with
+// Idempotent-Replayed: true. Reusing a key for a different request is
+// rejected with 422, and a retry while the first request is still running
+// with 409; the key stays locked for as long as that request runs. Server
+// errors are not stored, so they can be retried. Requests without the header
+// run as usual.
+//
+// Keys are scoped to the caller, so it must run inside ValidateJWT on
+// secured routes. The body is read into memory to fingerprint it, so it is
+// meant for routes with small bodies, not streaming ones. A TTL of 0 disables it.
+func Idempotency(store idempotency.Store, cfg config.IdempotencyConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		if cfg.TTL <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !validIdempotencyKey(key) {
+				utils.WriteError(w, r, http.StatusBadRequest, utils.CodeBadRequest,
+					fmt.Sprintf("%s must be 1 to %d visible ASCII characters", IdempotencyKeyHeader, maxIdempotencyKeyLength))
+				return
+			}
+
+			// The body is part of the fingerprint, so it is read here and handed on
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				var maxErr *http.MaxBytesError
+				if errors.As(err, &maxErr) {
+					utils.WriteError(w, r, http.StatusRequestEntityTooLarge, utils.CodeBodyTooLarge,
+						fmt.Sprintf("The request body must not exceed %d bytes", maxErr.Limit))
+					return
+				}
+				utils.WriteError(w, r, http.StatusBadRequest, utils.CodeInvalidBody, "The request body could not be read")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := r.Context()
+			fingerprint := requestFingerprint(r, body)
+			scoped := idempotencyScope(r) + " " + key
+			entry, reserved, err := store.Reserve(ctx, scoped, fingerprint, cfg.LockTimeout)
+			if err != nil {
+				slog.ErrorContext(ctx, "Idempotency store failed", "err", err)
+				utils.WriteError(w, r, http.StatusServiceUnavailable, utils.CodeUnavailable, "The request cannot be deduplicated right now, try again later")
+				return
+			}
+			if !reserved {
+				switch {
+				case entry.Fingerprint != fingerprint:
+					utils.WriteError(w, r, http.StatusUnprocessableEntity, utils.CodeIdempotencyKeyReused,
+						fmt.Sprintf("The %s was already used for a different request", IdempotencyKeyHeader))
+				case entry.Response == nil:
+					w.Header().Set("Retry-After", "1")
+					utils.WriteError(w, r, http.StatusConflict, utils.CodeRequestInFlight,
+						fmt.Sprintf("A request with this %s is still being processed", IdempotencyKeyHeader))
+				default:
+					replay(w, *entry.Response)
+				}
+				return
+			}
+
+			// Headers set further out, such as the request ID, are not part of the stored response
+			outer := w.Header().Clone()
+			rec := &capturingWriter{ResponseWriter: w}
+			token := entry.Token
+			unlock := holdLock(ctx, store, scoped, token, cfg.LockTimeout)
+			completed := false
+			defer func() {
+				if !completed {
+					// The handler panicked; let a retry run it again
+					unlock()
+					store.Release(ctx, scoped, token)
+				}
+			}()
+			next.ServeHTTP(rec, r)
+			completed = true
+			unlock()
+
+			status := rec.Status()
+			if status >= http.StatusInternalServerError {
+				store.Release(ctx, scoped, token)
+				return
+			}
+			resp := idempotency.Response{Status: status, Header: handlerHeaders(w.Header(), outer), Body: rec.body.Bytes()}
+			if err := store.Complete(ctx, scoped, token, resp, cfg.TTL); err != nil {
+				slog.WarnContext(ctx, "Failed to store idempotent response", "err", err)
+				store.Release(ctx, scoped, token)
+			}
+		})
+	}
+}
+
+// holdLock extends the lock on key every half lock until the returned function
+// is called, so a key stays locked while its request runs however long it
+// takes. The lock only expires when the instance running the request dies.
+func holdLock(ctx context.Context, store idempotency.Store, key, token string, lock time.Duration) (unlock func()) {
+	if lock/2 <= 0 {
+		return func() {}
+	}
+	// The handler keeps running when the client goes away, so the lock must too
+	ctx = context.WithoutCancel(ctx)
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(lock / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := store.Extend(ctx, key, token, lock); err != nil {
+					slog.WarnContext(ctx, "Failed to extend idempotency lock", "err", err)
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-stopped
+	}
+}
+
+// validIdempotencyKey reports whether key is a short run of visible ASCII characters
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < '!' || key[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// idempotencyScope keeps the keys of different callers apart. Requests without
+// an access token cannot be told apart by caller, so their keys are shared by
+// everyone calling the same method and path, and a key reused there for a
+// different body is rejected like any other.
+func idempotencyScope(r *http.Request) string {
+	if userID, ok := UserID(r.Context()); ok {
+		return userID.String()
+	}
+	return "anonymous " + r.Method + " " + r.URL.Path
+}
+
+// requestFingerprint hashes what makes two requests the same: method, path and body
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.Path)
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// handlerHeaders returns the headers of current that were added or changed after outer was taken
+func handlerHeaders(current, outer http.Header) http.Header {
+	headers := make(http.Header)
+	for name, values := range current {
+		if name == logging.RequestIDHeader {
+			continue
+		}
+		if prev, ok := outer[name]; !ok || !slices.Equal(prev, values) {
+			headers[name] = append([]string(nil), values...)
+		}
+	}
+	return headers
+}
+
+// replay writes a stored response again
+func replay(w http.ResponseWriter, resp idempotency.Response) {
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.Header().Set("Content-Length", strconv.Itoa(len(resp.Body)))
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// capturingWriter passes a response through while keeping a copy of it
+type capturingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (c *capturingWriter) WriteHeader(status int) {
+	if c.status == 0 {
+		c.status = status
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *capturingWriter) Write(b []byte) (int, error) {
+	if c.status == 0 {
+		c.status = http.StatusOK
+	}
+	c.body.Write(b)
+	return c.ResponseWriter.Write(b)
+}
+
+// Status returns the response status, defaulting to 200 when nothing was written
+func (c *capturingWriter) Status() int {
+	if c.status == 0 {
+		return http.StatusOK
+	}
+	return c.status
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (c *capturingWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"backend/config"
+	"backend/idempotency"
+	"backend/logging"
+	"backend/utils"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var idempotencyConfig = config.IdempotencyConfig{TTL: time.Hour, LockTimeout: time.Minute}
+
+// idempotencyUser is the authenticated caller of idempotentPost
+var idempotencyUser = uuid.New()
+
+// idempotentPost sends a POST /games as idempotencyUser with body and, when set, key
+func idempotentPost(handler http.Handler, key, body string) *httptest.ResponseRecorder {
+	return idempotentPostAs(handler, idempotencyUser, key, body)
+}
+
+// idempotentPostAs sends a POST /games with body and, when set, key as user,
+// or without an access token when user is uuid.Nil
+func idempotentPostAs(handler http.Handler, user uuid.UUID, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/games", strings.NewReader(body))
+	if user != uuid.Nil {
+		req = req.WithContext(WithPrincipal(req.Context(), user, "authenticated"))
+	}
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	rr := httptest.NewRecorder()
+	rr.Header().Set(logging.RequestIDHeader, "outer")
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func problemCode(t *testing.T, rr *httptest.ResponseRecorder) string {
+	t.Helper()
+	var problem utils.Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("expected a problem, got %s", rr.Body)
+	}
+	return problem.Code
+}
+
+func TestIdempotencyReplaysTheFirstResponse(t *testing.T) {
+	var calls atomic.Int32
+	handler := Idempotency(idempotency.NewMemoryStore(), idempotencyConfig)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Location", "/games/1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}))
+
+	first := idempotentPost(handler, "k1", `{"title":"Verbs"}`)
+	retry := idempotentPost(handler, "k1", `{"title":"Verbs"}`)
+	if calls.Load() != 1 {
+		t.Fatalf("expected the handler to run once, ran %d times", calls.Load())
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || retry.Header().Get("Location") != "/games/1" {
+		t.Errorf("expected the first response again, got %d %v %s", retry.Code, retry.Header(), retry.Body)
+	}
+	if retry.Header().Get(ReplayedHeader) != "true" || first.Header().Get(ReplayedHeader) != "" {
+		t.Errorf("expected only the retry to be marked replayed")
+	}
+	if got := retry.Header().Values(logging.RequestIDHeader); len(got) != 1 || got[0] != "outer" {
+		t.Errorf("expected the retry to keep its own request ID, got %v", got)
+	}
+
+	if rr := idempotentPost(handler, "k1", `{"title":"Nouns"}`); rr.Code != http.StatusUnprocessableEntity || problemCode(t, rr) != utils.CodeIdempotencyKeyReused {
+		t.Errorf("expected 422 for a different body with the same key, got %d %s", rr.Code, rr.Body)
+	}
+	idempotentPost(handler, "", `{"title":"Verbs"}`)
+	idempotentPost(handler, "", `{"title":"Verbs"}`)
+	if calls.Load() != 3 {
+		t.Errorf("expected requests without a key to run every time, ran %d times", calls.Load())
+	}
+	if rr := idempotentPost(handler, "not a key", `{}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a key with spaces, got %d", rr.Code)
+	}
+}
+
+func TestIdempotencyLocksInFlightRequests(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := Idempotency(idempotency.NewMemoryStore(), idempotencyConfig)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- idempotentPost(handler, "k1", `{}`) }()
+	<-started
+	rr := idempotentPost(handler, "k1", `{}`)
+	if rr.Code != http.StatusConflict || problemCode(t, rr) != utils.CodeRequestInFlight || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 409 with Retry-After while the first request runs, got %d %v", rr.Code, rr.Header())
+	}
+	close(release)
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Errorf("expected the first request to complete, got %d", first.Code)
+	}
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	status := http.StatusBadGateway
+	var calls int
+	handler := Idempotency(idempotency.NewMemoryStore(), idempotencyConfig)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(status)
+	}))
+
+	idempotentPost(handler, "k1", `{}`)
+	status = http.StatusCreated
+	if rr := idempotentPost(handler, "k1", `{}`); rr.Code != http.StatusCreated || calls != 2 {
+		t.Errorf("expected the retry of a failed request to run again, got %d after %d calls", rr.Code, calls)
+	}
+}
+
+func TestIdempotencyKeepsCallersApart(t *testing.T) {
+	var calls atomic.Int32
+	handler := Idempotency(idempotency.NewMemoryStore(), idempotencyConfig)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	}))
+
+	// Another user may pick the same key for a different request
+	idempotentPost(handler, "k1", `{"title":"Verbs"}`)
+	if rr := idempotentPostAs(handler, uuid.New(), "k1", `{"title":"Nouns"}`); rr.Code != http.StatusCreated || rr.Body.String() != `{"title":"Nouns"}` {
+		t.Errorf("expected another user's key to run their own request, got %d %s", rr.Code, rr.Body)
+	}
+
+	// Callers without an access token share the keys of a route, so a reused key is refused
+	first := idempotentPostAs(handler, uuid.Nil, "k2", `{"email":"a@example.com"}`)
+	if rr := idempotentPostAs(handler, uuid.Nil, "k2", `{"email":"b@example.com"}`); rr.Code != http.StatusUnprocessableEntity || problemCode(t, rr) != utils.CodeIdempotencyKeyReused {
+		t.Errorf("expected 422 for an anonymous key reused with another body, got %d %s", rr.Code, rr.Body)
+	}
+	if retry := idempotentPostAs(handler, uuid.Nil, "k2", `{"email":"a@example.com"}`); retry.Header().Get(ReplayedHeader) != "true" || retry.Body.String() != first.Body.String() {
+		t.Errorf("expected an anonymous retry with the same body to be replayed, got %v %s", retry.Header(), retry.Body)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected 3 requests to run, ran %d", calls.Load())
+	}
+}
+
+func TestIdempotencyHoldsTheLockWhileTheRequestRuns(t *testing.T) {
+	cfg := config.IdempotencyConfig{TTL: time.Hour, LockTimeout: 50 * time.Millisecond}
+	var calls atomic.Int32
+	started, release := make(chan struct{}), make(chan struct{})
+	handler := Idempotency(idempotency.NewMemoryStore(), cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- idempotentPost(handler, "k1", `{}`) }()
+	<-started
+	// Outlive the lock timeout several times over
+	time.Sleep(4 * cfg.LockTimeout)
+	if rr := idempotentPost(handler, "k1", `{}`); rr.Code != http.StatusConflict {
+		t.Errorf("expected 409 while the first request still runs, got %d", rr.Code)
+	}
+	close(release)
+	<-done
+	if calls.Load() != 1 {
+		t.Errorf("expected the handler to run once, ran %d times", calls.Load())
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"backend/metrics"
+	"net/http"
+	"time"
+)
+
+// Metrics records request counts, latency and in-flight requests per route pattern
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		r, _ = withRouteInfo(r)
+		rec := newResponseRecorder(w)
+
+		metrics.HTTPInFlight.Inc()
+		defer metrics.HTTPInFlight.Dec()
+
+		next.ServeHTTP(rec, r)
+
+		metrics.ObserveHTTP(r.Method, RoutePattern(r.Context()), rec.Status(), time.Since(start))
+	})
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"backend/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsLabelsByRoutePattern(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /games/{id}", Route("GET /games/{id}")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})))
+	handler := Metrics(mux)
+
+	counter := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "/games/{id}", "418")
+	before := testutil.ToFloat64(counter)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/games/42", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/games/43", nil))
+
+	if got := testutil.ToFloat64(counter) - before; got != 2 {
+		t.Errorf("expected 2 requests recorded for /games/{id}, got %v", got)
+	}
+
+	unmatched := metrics.HTTPRequests.WithLabelValues(http.MethodGet, "unmatched", "404")
+	before = testutil.ToFloat64(unmatched)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/nope", nil))
+	if got := testutil.ToFloat64(unmatched) - before; got != 1 {
+		t.Errorf("expected unmatched request to be recorded once, got %v", got)
+	}
+}
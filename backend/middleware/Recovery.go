@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"backend/utils"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recover turns a panic in any downstream handler into a logged stack trace
+// and a problem+json 500 response instead of a dropped connection
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			// http.ErrAbortHandler is the documented way to abort a response; let the server handle it
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+
+			slog.ErrorContext(r.Context(), "recovered from panic",
+				"panic", err,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"stack", string(debug.Stack()),
+			)
+			utils.WriteError(w, r, http.StatusInternalServerError, utils.CodeInternal, "An unexpected error occurred")
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
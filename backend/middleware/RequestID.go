@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"backend/logging"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sync/atomic"
+	"time"
+)
+
+// validRequestID limits which incoming IDs are trusted, so callers cannot
+// inject arbitrary text into our logs or downstream headers
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:\-]{1,128}$`)
+
+// RequestID assigns every request an ID, honouring a well-formed incoming
+// X-Request-ID header. The ID is stored in the request context and echoed
+// back in the response headers.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := ResolveRequestID(r.Header.Get(logging.RequestIDHeader))
+		w.Header().Set(logging.RequestIDHeader, requestID)
+		ctx := logging.WithRequestID(r.Context(), requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ResolveRequestID returns incoming when it is a well-formed request ID and a new ID otherwise
+func ResolveRequestID(incoming string) string {
+	if validRequestID.MatchString(incoming) {
+		return incoming
+	}
+	return newRequestID()
+}
+
+// randRead fills request IDs; tests replace it to simulate a failing source
+var randRead = rand.Read
+
+// fallbackRequestIDs numbers the IDs made without random bytes
+var fallbackRequestIDs atomic.Uint64
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := randRead(b); err != nil {
+		// Still unique within this process, which is enough to correlate its logs
+		slog.Warn("Failed to read random bytes for a request ID", "err", err)
+		return fmt.Sprintf("%x-%x", time.Now().UnixNano(), fallbackRequestIDs.Add(1))
+	}
+	return hex.EncodeToString(b)
+}
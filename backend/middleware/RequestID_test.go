@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"errors"
+	"testing"
+)
+
+func TestNewRequestIDFallsBackWithoutRandomness(t *testing.T) {
+	randRead = func([]byte) (int, error) { return 0, errors.New("no entropy") }
+	defer func() { randRead = rand.Read }()
+
+	first, second := newRequestID(), newRequestID()
+	if first == "" || first == second || !validRequestID.MatchString(first) {
+		t.Errorf("expected distinct well-formed IDs, got %q and %q", first, second)
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type routeContextKey struct{}
+
+// routeInfo is filled in by the matched route so that outer middleware can
+// label requests by route pattern instead of by raw path
+type routeInfo struct {
+	pattern string
+}
+
+// withRouteInfo makes sure the request carries a route holder, reusing one
+// installed further out so every middleware sees the same pattern
+func withRouteInfo(r *http.Request) (*http.Request, *routeInfo) {
+	if info, ok := r.Context().Value(routeContextKey{}).(*routeInfo); ok {
+		return r, info
+	}
+	info := &routeInfo{}
+	return r.WithContext(context.WithValue(r.Context(), routeContextKey{}, info)), info
+}
+
+// Route returns middleware recording the registered pattern of the matched route.
+// Route groups apply it to every route they register.
+func Route(pattern string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if info, ok := r.Context().Value(routeContextKey{}).(*routeInfo); ok {
+				info.pattern = pattern
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RoutePattern returns the path of the matched route pattern, e.g. "/games/{id}",
+// or "unmatched" when no route handled the request
+func RoutePattern(ctx context.Context) string {
+	info, ok := ctx.Value(routeContextKey{}).(*routeInfo)
+	if !ok || info.pattern == "" {
+		return "unmatched"
+	}
+	// Drop the method prefix of patterns like "GET /games/{id}"
+	if _, path, found := strings.Cut(info.pattern, " "); found {
+		return path
+	}
+	return info.pattern
+}
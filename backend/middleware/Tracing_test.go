@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"backend/tracing"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingContinuesIncomingTraceIntoOutboundCalls(t *testing.T) {
+	exporter := tracing.UseInMemoryExporter()
+
+	var upstreamTraceparent string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamTraceparent = r.Header.Get("traceparent")
+	}))
+	defer upstream.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /games/{id}", Route("GET /games/{id}")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, upstream.URL+"/rest/v1/games", nil)
+		req, span := tracing.StartClientSpan(req, "games", "select")
+		resp, err := http.DefaultClient.Do(req)
+		tracing.EndClientSpan(span, resp, err)
+		if err == nil {
+			resp.Body.Close()
+		}
+	})))
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/games/42", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	Tracing(mux).ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected a server and a client span, got %d", len(spans))
+	}
+
+	client, server := spans[0], spans[1]
+	if server.Name != "GET /games/{id}" || server.SpanKind != trace.SpanKindServer {
+		t.Errorf("unexpected server span %q (%v)", server.Name, server.SpanKind)
+	}
+	if client.Name != "supabase games.select" || client.SpanKind != trace.SpanKindClient {
+		t.Errorf("unexpected client span %q (%v)", client.Name, client.SpanKind)
+	}
+	for _, span := range spans {
+		if span.SpanContext.TraceID().String() != traceID {
+			t.Errorf("span %q did not continue the incoming trace", span.Name)
+		}
+	}
+	if client.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("client span is not a child of the server span")
+	}
+	if !strings.Contains(upstreamTraceparent, traceID) || !strings.Contains(upstreamTraceparent, client.SpanContext.SpanID().String()) {
+		t.Errorf("traceparent not propagated to upstream, got %q", upstreamTraceparent)
+	}
+}
@@ -1,10 +1,16 @@
 package models
 
+import "github.com/google/uuid"
+
 type Game struct {
-    ID          string `json:"id"`
-    Title       string `json:"title"`
-    Description string `json:"description"`
-    SubjectID   string `json:"subject_id"`
-    Difficulty  int    `json:"difficulty_level"`
-    CreatedAt   string `json:"created_at"`
-}
\ No newline at end of file
+	ID          uuid.UUID  `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	SubjectID   *uuid.UUID `json:"subject_id"`
+	Difficulty  int        `json:"difficulty_level"`
+	Language    string     `json:"language"`               // Postgres text search configuration, e.g. "english"
+	ExternalKey string     `json:"external_key,omitempty"` // matches games in bulk imports; the ID unless imported with a key
+	CreatedAt   Timestamp  `json:"created_at"`
+	Version     int64      `json:"version"`              // incremented by every update, sent as the ETag
+	DeletedAt   *Timestamp `json:"deleted_at,omitempty"` // set while the game is in the trash
+}
@@ -0,0 +1,12 @@
+package models
+
+// GameRecord is one game in a bulk import or export file. Imports match games
+// by ExternalKey; the subject is given by name and created when missing.
+type GameRecord struct {
+	ExternalKey string `json:"external_key" validate:"required,max=100"`
+	Title       string `json:"title" validate:"required,max=200"`
+	Description string `json:"description" validate:"max=2000"`
+	Subject     string `json:"subject" validate:"max=100"`
+	Difficulty  int    `json:"difficulty_level" validate:"min=1,max=5"`
+	Language    string `json:"language" validate:"language"` // one of Languages
+}
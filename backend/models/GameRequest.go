@@ -1,8 +1,13 @@
 package models
 
+import "github.com/google/uuid"
+
+// GameRequest is the body of game create and update requests. Updates apply
+// the same rules to the fields they contain.
 type GameRequest struct {
-	Title       string `json:"title,omitempty"`
-	Description string `json:"description,omitempty"`
-	SubjectID   string `json:"subject_id,omitempty"`
-	Difficulty  int    `json:"difficulty_level,omitempty"`
-}
\ No newline at end of file
+	Title       string     `json:"title,omitempty" validate:"required,max=200"`
+	Description string     `json:"description,omitempty" validate:"max=2000"`
+	SubjectID   *uuid.UUID `json:"subject_id,omitempty" validate:"uuid"`
+	Difficulty  int        `json:"difficulty_level,omitempty" validate:"min=1,max=5"`
+	Language    string     `json:"language,omitempty" validate:"language"` // one of Languages
+}
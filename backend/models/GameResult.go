@@ -0,0 +1,13 @@
+package models
+
+import "github.com/google/uuid"
+
+// GameResult is the outcome of a completed game
+type GameResult struct {
+	ID             uuid.UUID `json:"id"`
+	UserID         uuid.UUID `json:"user_id"`
+	GameID         uuid.UUID `json:"game_id"`
+	Score          *int      `json:"score"`
+	CompletionTime *string   `json:"completion_time"` // Postgres interval, e.g. "00:04:31"
+	CompletedAt    Timestamp `json:"completed_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+// GameState is the saved progress of a user in a game they have not finished
+type GameState struct {
+	UserID      uuid.UUID       `json:"user_id"`
+	GameID      uuid.UUID       `json:"game_id"`
+	StateData   json.RawMessage `json:"state_data"` // shape is up to the game
+	LastUpdated Timestamp       `json:"last_updated"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "backend/validation"
+
+// Languages are the Postgres text search configurations games may be written in
+var Languages = []string{"simple", "danish", "dutch", "english", "finnish", "french", "german", "italian", "norwegian", "portuguese", "russian", "spanish", "swedish"}
+
+// LanguageRule is the validate rule of fields holding one of Languages
+const LanguageRule = "language"
+
+func init() {
+	validation.RegisterEnum(LanguageRule, Languages)
+}
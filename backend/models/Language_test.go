@@ -0,0 +1,17 @@
+package models
+
+import (
+	"backend/validation"
+	"errors"
+	"testing"
+)
+
+func TestGameLanguagesAreValidated(t *testing.T) {
+	var errs validation.Errors
+	if err := validation.Struct(GameRecord{ExternalKey: "v-1", Title: "Verbs", Language: "klingon"}); !errors.As(err, &errs) || errs[0].Code != validation.RuleOneOf {
+		t.Errorf("expected a oneof error for an unknown language, got %v", err)
+	}
+	if err := validation.Partial(GameRequest{Language: "danish"}); err != nil {
+		t.Errorf("expected a known language to pass, got %v", err)
+	}
+}
@@ -0,0 +1,10 @@
+package models
+
+import "github.com/google/uuid"
+
+// Subject is a topic that games belong to
+type Subject struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt Timestamp `json:"created_at"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimestampUnmarshal(t *testing.T) {
+	want := time.Date(2024, 11, 22, 10, 4, 5, 123456000, time.UTC)
+	cases := map[string]time.Time{
+		`"2024-11-22T10:04:05.123456Z"`:      want,
+		`"2024-11-22T11:04:05.123456+01:00"`: want,
+		`"2024-11-22T10:04:05.123456+00"`:    want,
+		`"2024-11-22 10:04:05.123456+00"`:    want,
+		`"2024-11-22 12:04:05.123456+02:00"`: want,
+		`"2024-11-22T10:04:05.123456"`:       want,
+		`"2024-11-22 10:04:05.123456"`:       want,
+		`"2024-11-22T10:04:05"`:              want.Truncate(time.Second),
+		`null`:                               {},
+	}
+	for input, expected := range cases {
+		var ts Timestamp
+		if err := json.Unmarshal([]byte(input), &ts); err != nil {
+			t.Errorf("%s: %v", input, err)
+			continue
+		}
+		if !ts.Equal(expected) {
+			t.Errorf("%s: expected %v, got %v", input, expected, ts.Time)
+		}
+	}
+
+	for _, input := range []string{`""`, `"yesterday"`, `"2024-13-01T00:00:00Z"`, `42`} {
+		var ts Timestamp
+		if err := json.Unmarshal([]byte(input), &ts); err == nil {
+			t.Errorf("%s: expected an error", input)
+		}
+	}
+}
+
+func TestTimestampRoundTrip(t *testing.T) {
+	type row struct {
+		CreatedAt Timestamp `json:"created_at"`
+	}
+	for _, original := range []row{
+		{Timestamp{time.Date(2024, 11, 22, 10, 4, 5, 123456000, time.UTC)}},
+		{Timestamp{time.Date(2024, 11, 22, 10, 4, 5, 0, time.FixedZone("", -5*3600))}},
+		{},
+	} {
+		data, err := json.Marshal(original)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded row
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		if !decoded.CreatedAt.Equal(original.CreatedAt.Time) {
+			t.Errorf("%s: expected %v, got %v", data, original.CreatedAt.Time, decoded.CreatedAt.Time)
+		}
+	}
+
+	data, _ := json.Marshal(row{})
+	if string(data) != `{"created_at":null}` {
+		t.Errorf("expected the zero timestamp to encode as null, got %s", data)
+	}
+}
@@ -1,39 +1,23 @@
 package models
 
-import (
-	"time"
-)
-
-type CustomTime time.Time
-
-func (ct *CustomTime) UnmarshalJSON(b []byte) error {
-	// Trim quotes around the timestamp string
-	s := string(b)
-	s = s[1 : len(s)-1]
-
-	// Attempt to parse the timestamp
-	t, err := time.Parse("2006-01-02T15:04:05.999999", s)
-	if err != nil {
-		return err
-	}
-
-	*ct = CustomTime(t)
-	return nil
-}
-
-func (ct CustomTime) MarshalJSON() ([]byte, error) {
-	t := time.Time(ct)
-	return []byte(t.Format(`"` + "2006-01-02T15:04:05.999999" + `"`)), nil
-}
+import "github.com/google/uuid"
 
 type User struct {
-	ID        string     `json:"id"`
+	ID        uuid.UUID  `json:"id"`
 	Email     string     `json:"email"`
-	CreatedAt CustomTime `json:"created_at"`
+	CreatedAt Timestamp  `json:"created_at"`
 	Role      string     `json:"role"`
+	Version   int64      `json:"version"`              // incremented by every update, sent as the ETag
+	DeletedAt *Timestamp `json:"deleted_at,omitempty"` // set while the user is in the trash
 }
 
 type SupabaseUser struct {
-	ID    string `json:"id"`
-	Email string `json:"email"`
-}
\ No newline at end of file
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+}
+
+// Session holds the tokens issued by Supabase auth on login
+type Session struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,161 @@
+package openapi
+
+import (
+	"backend/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// bearerScheme names the security scheme of routes that require an access token
+const bearerScheme = "bearerAuth"
+
+// Endpoint documents one route
+type Endpoint struct {
+	Summary       string
+	Description   string
+	Tag           string
+	Auth          bool // requires a Supabase access token
+	Query         []Parameter
+	Request       any      // body type, nil when the route takes no body
+	RequestTypes  []string // media types of the body, application/json when empty
+	Status        int      // success status, 200 when 0
+	Response      any      // success body type, nil for an empty response
+	ResponseTypes []string // media types of the success body, application/json when empty
+	Headers       map[string]Header
+	Deprecated    bool
+}
+
+// Builder assembles a Document from routes and their Endpoints
+type Builder struct {
+	doc     *Document
+	schemas *Schemas
+}
+
+// NewBuilder starts a document. Body types are described with schemas.
+func NewBuilder(info Info, schemas *Schemas) *Builder {
+	return &Builder{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   make(map[string]*PathItem),
+		},
+		schemas: schemas,
+	}
+}
+
+// Add documents the route method path. Path parameters are taken from the {name}
+// segments of path; every route may answer with a problem+json error.
+func (b *Builder) Add(method, path string, e Endpoint) {
+	op := &Operation{
+		OperationID: operationID(method, path),
+		Summary:     e.Summary,
+		Description: e.Description,
+		Parameters:  append(pathParameters(path), e.Query...),
+		Responses:   make(map[string]*Response),
+		Deprecated:  e.Deprecated,
+	}
+	if e.Tag != "" {
+		op.Tags = []string{e.Tag}
+	}
+
+	if e.Request != nil {
+		op.RequestBody = &RequestBody{Required: true, Content: b.content(e.Request, e.RequestTypes)}
+	}
+
+	status := e.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status), Headers: e.Headers}
+	if e.Response != nil {
+		success.Content = b.content(e.Response, e.ResponseTypes)
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	problem := map[string]MediaType{utils.ProblemContentType: {Schema: b.schemas.For(utils.Problem{})}}
+	if e.Auth {
+		op.Security = []map[string][]string{{bearerScheme: {}}}
+		op.Responses["401"] = &Response{Description: "Missing or invalid access token", Content: problem}
+	}
+	op.Responses["default"] = &Response{Description: "Error", Content: problem}
+
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		b.doc.Paths[path] = item
+	}
+	(*item)[strings.ToLower(method)] = op
+}
+
+// Document returns the document with the schemas of every added endpoint
+func (b *Builder) Document() *Document {
+	b.doc.Components = Components{
+		Schemas: b.schemas.Components(),
+		SecuritySchemes: map[string]SecurityScheme{
+			bearerScheme: {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "Supabase access token from POST /v1/login"},
+		},
+	}
+	return b.doc
+}
+
+// content describes a body of type v in each media type. Bodies that are not
+// JSON, such as CSV files, are described as plain strings.
+func (b *Builder) content(v any, mediaTypes []string) map[string]MediaType {
+	if len(mediaTypes) == 0 {
+		mediaTypes = []string{"application/json"}
+	}
+	content := make(map[string]MediaType, len(mediaTypes))
+	for _, mt := range mediaTypes {
+		if mt == "application/json" {
+			content[mt] = MediaType{Schema: b.schemas.For(v)}
+		} else {
+			content[mt] = MediaType{Schema: &Schema{Type: "string"}}
+		}
+	}
+	return content
+}
+
+// pathParameters documents the {name} segments of path. IDs are UUIDs.
+func pathParameters(path string) []Parameter {
+	var params []Parameter
+	for _, segment := range strings.Split(path, "/") {
+		name, ok := strings.CutPrefix(segment, "{")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSuffix(strings.TrimSuffix(name, "}"), "...")
+		schema := &Schema{Type: "string"}
+		if name == "id" || strings.HasSuffix(name, "_id") {
+			schema.Format = "uuid"
+		}
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	return params
+}
+
+// operationID derives an identifier such as getGamesById from a route
+func operationID(method, path string) string {
+	var id strings.Builder
+	id.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(path, "/") {
+		if name, ok := strings.CutPrefix(segment, "{"); ok {
+			id.WriteString("By")
+			segment = strings.TrimSuffix(name, "}")
+		}
+		upper := true
+		for _, r := range segment {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				upper = true
+				continue
+			}
+			if upper {
+				r = unicode.ToUpper(r)
+				upper = false
+			}
+			id.WriteRune(r)
+		}
+	}
+	return id.String()
+}
@@ -0,0 +1,43 @@
+package openapi
+
+import (
+	"backend/utils"
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"sync"
+)
+
+//go:embed docs.html
+var docsPage string
+
+var docsTemplate = template.Must(template.New("docs").Parse(docsPage))
+
+// Handler serves the document returned by build as JSON. The document is built
+// on the first request, once every route has been registered.
+func Handler(build func() *Document) http.Handler {
+	spec := sync.OnceValues(func() ([]byte, error) {
+		return json.MarshalIndent(build(), "", "  ")
+	})
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := spec()
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Failed to encode the OpenAPI document", "err", err)
+			utils.WriteError(w, r, http.StatusInternalServerError, utils.CodeInternal, "Failed to encode the OpenAPI document")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+}
+
+// UIHandler serves an interactive documentation page for the document at specURL.
+// The page loads Swagger UI from the jsDelivr CDN.
+func UIHandler(specURL string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		docsTemplate.Execute(w, struct{ SpecURL string }{specURL})
+	})
+}
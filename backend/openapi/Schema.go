@@ -0,0 +1,214 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"backend/validation"
+
+	"github.com/google/uuid"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// Schemas builds the schemas of Go values the way encoding/json encodes them.
+// Named structs become components that operations refer to by $ref. The
+// `validate` tags of struct fields (see package validation) become
+// required fields, bounds, formats and enums.
+type Schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+	defined    map[reflect.Type]*Schema
+}
+
+// NewSchemas creates an empty schema collection that knows uuid.UUID and time.Time
+func NewSchemas() *Schemas {
+	s := &Schemas{
+		components: make(map[string]*Schema),
+		names:      make(map[reflect.Type]string),
+		defined:    make(map[reflect.Type]*Schema),
+	}
+	s.Define(uuid.UUID{}, &Schema{Type: "string", Format: "uuid"})
+	s.Define(time.Time{}, &Schema{Type: "string", Format: "date-time"})
+	return s
+}
+
+// Define sets the schema of the type of v, for types that encode themselves
+// with a MarshalJSON or MarshalText method
+func (s *Schemas) Define(v any, schema *Schema) {
+	s.defined[reflect.TypeOf(v)] = schema
+}
+
+// For returns the schema of the type of v
+func (s *Schemas) For(v any) *Schema {
+	return s.schemaOf(reflect.TypeOf(v))
+}
+
+// Components returns the named struct schemas collected so far
+func (s *Schemas) Components() map[string]*Schema {
+	return s.components
+}
+
+func (s *Schemas) schemaOf(t reflect.Type) *Schema {
+	if defined, ok := s.defined[t]; ok {
+		copied := *defined
+		return &copied
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(s.schemaOf(t.Elem()))
+	case reflect.Interface:
+		return &Schema{}
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		return &Schema{}
+	}
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.objectOf(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + s.component(t)}
+	default:
+		return &Schema{}
+	}
+}
+
+// component registers the named struct t and returns its component name
+func (s *Schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	name := componentName(t.Name())
+	if _, taken := s.components[name]; taken {
+		// Same name in another package, e.g. handlers.SupabaseUser and models.SupabaseUser
+		name = componentName(path.Base(t.PkgPath())) + name
+	}
+	s.names[t] = name
+	s.components[name] = &Schema{} // placeholder for recursive types
+	*s.components[name] = *s.objectOf(t)
+	return name
+}
+
+// objectOf builds the object schema of the struct t
+func (s *Schemas) objectOf(t reflect.Type) *Schema {
+	object := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.addFields(object, t)
+
+	rules := validation.Describe(reflect.New(t).Interface())
+	for _, r := range rules {
+		property, ok := object.Properties[r.Field]
+		if !ok {
+			continue
+		}
+		if r.Required {
+			object.Required = append(object.Required, r.Field)
+		}
+		applyRules(property, r)
+	}
+	return object
+}
+
+// addFields adds the properties of the exported fields of t, including those of embedded structs
+func (s *Schemas) addFields(object *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(object, embedded)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		object.Properties[name] = s.schemaOf(f.Type)
+	}
+}
+
+// applyRules adds the bounds, format and enum of validation rules to a property schema
+func applyRules(property *Schema, r validation.FieldRules) {
+	switch r.Type {
+	case "string":
+		property.MinLength, property.MaxLength = r.Min, r.Max
+	case "array":
+		property.MinItems, property.MaxItems = r.Min, r.Max
+	default:
+		property.Minimum, property.Maximum = r.Min, r.Max
+	}
+	if r.Format != "" {
+		property.Format = r.Format
+	}
+	if len(r.Enum) > 0 {
+		property.Enum = r.Enum
+	}
+}
+
+// nullable allows null in addition to the values of schema
+func nullable(schema *Schema) *Schema {
+	switch t := schema.Type.(type) {
+	case string:
+		schema.Type = []string{t, "null"}
+		return schema
+	case nil:
+		if schema.Ref == "" {
+			return schema // already accepts anything
+		}
+	}
+	return &Schema{AnyOf: []*Schema{schema, {Type: "null"}}}
+}
+
+// componentName makes a Go type name, possibly generic, a valid component name
+func componentName(name string) string {
+	clean := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return -1
+	}, name)
+	if clean == "" {
+		return clean
+	}
+	return strings.ToUpper(clean[:1]) + clean[1:]
+}
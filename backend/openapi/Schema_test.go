@@ -0,0 +1,69 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type testBase struct {
+	ID uuid.UUID `json:"id"`
+}
+
+type testNode struct {
+	testBase
+	Name     string     `json:"name" validate:"required,max=20"`
+	Kind     string     `json:"kind,omitempty" validate:"oneof=leaf branch"`
+	Parent   *testNode  `json:"parent"`
+	Score    *int       `json:"score" validate:"min=0,max=100"`
+	Children []testNode `json:"children"`
+	Secret   string     `json:"-"`
+	internal string
+}
+
+func TestSchemasDescribeStructs(t *testing.T) {
+	s := NewSchemas()
+	ref := s.For([]testNode{})
+	if ref.Type != "array" || ref.Items.Ref != "#/components/schemas/TestNode" {
+		t.Fatalf("expected an array of references, got %+v", ref)
+	}
+
+	node := s.Components()["TestNode"]
+	var names []string
+	for name := range node.Properties {
+		names = append(names, name)
+	}
+	if len(names) != 6 || node.Properties["secret"] != nil || node.Properties["internal"] != nil {
+		t.Errorf("expected the embedded id and the five exported fields, got %v", names)
+	}
+	if id := node.Properties["id"]; id.Format != "uuid" {
+		t.Errorf("expected a uuid id, got %+v", id)
+	}
+	if !reflect.DeepEqual(node.Required, []string{"name"}) || *node.Properties["name"].MaxLength != 20 {
+		t.Errorf("expected the validate rules of name, got %+v %+v", node.Required, node.Properties["name"])
+	}
+	if kind := node.Properties["kind"]; !reflect.DeepEqual(kind.Enum, []string{"leaf", "branch"}) {
+		t.Errorf("expected the oneof values as an enum, got %+v", kind)
+	}
+	if score := node.Properties["score"]; !reflect.DeepEqual(score.Type, []string{"integer", "null"}) || *score.Maximum != 100 {
+		t.Errorf("expected a nullable bounded integer, got %+v", score)
+	}
+	if parent := node.Properties["parent"]; len(parent.AnyOf) != 2 || parent.AnyOf[0].Ref != "#/components/schemas/TestNode" {
+		t.Errorf("expected a nullable reference for the recursive field, got %+v", parent)
+	}
+}
+
+func TestOperationID(t *testing.T) {
+	cases := map[[2]string]string{
+		{"GET", "/games/{id}"}:       "getGamesById",
+		{"POST", "/games/import"}:    "postGamesImport",
+		{"GET", "/validation/rules"}: "getValidationRules",
+		{"GET", "/openapi.json"}:     "getOpenapiJson",
+	}
+	for route, want := range cases {
+		if got := operationID(route[0], route[1]); got != want {
+			t.Errorf("operationID(%s %s) = %q, want %q", route[0], route[1], got, want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package pagination
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	codec := NewCodec("secret")
+	created, id := "2024-11-22T10:00:00", "5803acaf-821a-4463-b8b4-15ac6e0e466a"
+	token := codec.Encode(Cursor{Sort: "-created_at,id", After: []*string{&created, nil, &id}})
+
+	cursor, err := codec.Decode(token, "-created_at,id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cursor.After) != 3 || *cursor.After[0] != created || cursor.After[1] != nil || *cursor.After[2] != id {
+		t.Errorf("cursor did not round-trip: %+v", cursor)
+	}
+}
+
+func TestCursorRejectsTampering(t *testing.T) {
+	codec := NewCodec("secret")
+	id := "g1"
+	token := codec.Encode(Cursor{Sort: "id", After: []*string{&id}})
+	forged := NewCodec("other").Encode(Cursor{Sort: "id", After: []*string{&id}})
+
+	payload, signature, _ := strings.Cut(token, ".")
+	cases := map[string]string{
+		"other key":    forged,
+		"edited":       payload + "x." + signature,
+		"unsigned":     payload,
+		"garbage":      "not a cursor",
+		"empty":        "",
+		"another sort": token,
+	}
+	for name, candidate := range cases {
+		sort := "id"
+		if name == "another sort" {
+			sort = "-id"
+		}
+		if _, err := codec.Decode(candidate, sort); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("%s: expected ErrInvalidCursor, got %v", name, err)
+		}
+	}
+}
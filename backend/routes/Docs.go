@@ -0,0 +1,153 @@
+package routes
+
+import (
+	"backend/bulk"
+	"backend/graph"
+	"backend/handlers"
+	"backend/health"
+	"backend/models"
+	"backend/openapi"
+	"backend/services"
+	"backend/validation"
+	"log/slog"
+	"maps"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// apiInfo describes the API in the OpenAPI document
+var apiInfo = openapi.Info{
+	Title:   "iLang Backend API",
+	Version: "1.0.0",
+	Description: "Routes are served under the prefix of their API version, e.g. /v1/games. " +
+		"The unversioned paths, e.g. /games, are deprecated and serve the version named in the API-Version request header, or the default version (v1) when it is absent. " +
+		"Errors are RFC 7807 problem+json documents with a stable code and, for invalid input, the failing fields.",
+}
+
+// graphQLDescription explains the GraphQL endpoint, whose schema is available through introspection
+const graphQLDescription = "Reads users, games, subjects, saved game states and results. Query the schema through introspection. " +
+	"Field errors are returned with status 200 and a code in their extensions; queries that are too deep or too complex are rejected with code query_too_complex before anything is read."
+
+// endpoints documents every route by pattern. TestEveryRouteIsDocumented fails
+// when a registered route has no entry here or an entry matches no route.
+func endpoints(h *handlers.Handlers) map[string]openapi.Endpoint {
+	return map[string]openapi.Endpoint{
+		// Public
+		"POST /v1/users": {Summary: "Register a user", Tag: "users",
+			Query: handlers.IdempotencyParameters, Request: handlers.CreateUserRequest{}, Status: http.StatusCreated, Response: models.SupabaseUser{}},
+		"POST /v1/login": {Summary: "Log in with email and password", Tag: "auth",
+			Request: handlers.LoginRequest{}, Response: models.Session{}},
+		"POST /v1/logout": {Summary: "Log out", Tag: "auth", Response: map[string]string{}},
+		"GET /v1/validation/rules": {Summary: "Validation rules of every request body", Tag: "meta",
+			Response: map[string][]validation.FieldRules{}},
+
+		// Users
+		"GET /v1/users/{id}": {Summary: "Get a user", Tag: "users", Auth: true,
+			Query: handlers.ReadParameters, Response: models.User{}, Headers: handlers.ETagHeaders},
+		"PATCH /v1/users/{id}": {Summary: "Update the email or role of a user", Tag: "users", Auth: true,
+			Query: handlers.WriteParameters, Request: handlers.UpdateUserRequest{}, Response: models.User{}, Headers: handlers.ETagHeaders},
+		"DELETE /v1/users/{id}": {Summary: "Move a user to the trash and ban their auth account", Tag: "users", Auth: true,
+			Query: handlers.WriteParameters, Status: http.StatusNoContent},
+		"GET /v1/admin/users": {Summary: "List users", Description: "Requires the admin role.", Tag: "users", Auth: true,
+			Query: h.ListParameters("users"), Response: []models.User{}, Headers: handlers.ListHeaders},
+		"GET /v1/admin/users/trash": {Summary: "List the users in the trash", Description: "Requires the admin role.", Tag: "users", Auth: true,
+			Query: h.ListParameters("trashed_users"), Response: []models.User{}, Headers: handlers.ListHeaders},
+		"POST /v1/admin/users/{id}/restore": {Summary: "Take a user out of the trash and unban their auth account", Description: "Requires the admin role.", Tag: "users", Auth: true,
+			Response: models.User{}, Headers: handlers.ETagHeaders},
+
+		// Games
+		"GET /v1/games": {Summary: "List the user's games", Tag: "games", Auth: true,
+			Query: h.ListParameters("games"), Response: []models.Game{}, Headers: handlers.ListHeaders},
+		"POST /v1/games": {Summary: "Create a game", Tag: "games", Auth: true,
+			Query: handlers.IdempotencyParameters, Request: models.GameRequest{}, Status: http.StatusCreated, Response: models.Game{}},
+		"GET /v1/games/search": {Summary: "Full-text search of the user's games with facets", Tag: "games", Auth: true,
+			Query: h.SearchParameters(), Response: services.SearchResult{}},
+		"GET /v1/games/export": {Summary: "Download all of the user's games", Tag: "games", Auth: true,
+			Query: handlers.ExportParameters, Response: []models.GameRecord{}, ResponseTypes: handlers.BulkTypes},
+		"POST /v1/games/import": {Summary: "Upsert games from a file by external key", Tag: "games", Auth: true,
+			Description: "Invalid rows are skipped and reported unless atomic is set, in which case nothing is written and the report errors are returned as a 422 problem.",
+			Query:       handlers.ImportParameters, Request: []models.GameRecord{}, RequestTypes: handlers.BulkTypes, Response: bulk.Report{}},
+		"GET /v1/games/{id}": {Summary: "Get a game", Tag: "games", Auth: true,
+			Query: handlers.ReadParameters, Response: models.Game{}, Headers: handlers.ETagHeaders},
+		"PATCH /v1/games/{id}": {Summary: "Update the fields of a game that are present", Tag: "games", Auth: true,
+			Query: handlers.WriteParameters, Request: models.GameRequest{}, Response: models.Game{}, Headers: handlers.ETagHeaders},
+		"DELETE /v1/games/{id}": {Summary: "Move a game to the trash", Tag: "games", Auth: true,
+			Query: handlers.WriteParameters, Status: http.StatusNoContent},
+		"GET /v1/games/trash": {Summary: "List the user's games in the trash", Tag: "games", Auth: true,
+			Query: h.ListParameters("trashed_games"), Response: []models.Game{}, Headers: handlers.ListHeaders},
+		"POST /v1/games/{id}/restore": {Summary: "Take a game out of the trash", Tag: "games", Auth: true,
+			Response: models.Game{}, Headers: handlers.ETagHeaders},
+
+		// Subjects and results
+		"GET /v1/subjects": {Summary: "List subjects", Tag: "subjects", Auth: true,
+			Query: h.ListParameters("subjects"), Response: []models.Subject{}, Headers: handlers.ListHeaders},
+		"GET /v1/results": {Summary: "List the user's game results", Tag: "results", Auth: true,
+			Query: h.ListParameters("results"), Response: []models.GameResult{}, Headers: handlers.ListHeaders},
+
+		// GraphQL
+		"GET /v1/graphql": {Summary: "Run a GraphQL query given as parameters", Tag: "graphql", Auth: true,
+			Description: graphQLDescription, Query: handlers.GraphQLParameters, Response: graph.Result{}},
+		"POST /v1/graphql": {Summary: "Run a GraphQL query", Tag: "graphql", Auth: true,
+			Description: graphQLDescription, Request: graph.Request{}, Response: graph.Result{}},
+
+		// Operations
+		"GET /healthz": {Summary: "Liveness probe", Tag: "ops", Response: handlers.HealthResponse{}},
+		"GET /readyz":  {Summary: "Readiness probe, 503 while a dependency is down or the server drains", Tag: "ops", Response: handlers.ReadinessResponse{}},
+		"GET /readyz/details": {Summary: "Readiness of every dependency", Description: "Requires the admin role.", Tag: "ops", Auth: true,
+			Response: health.Report{}},
+		"GET /metrics":      {Summary: "Prometheus metrics", Tag: "ops", Response: "", ResponseTypes: []string{"text/plain"}},
+		"GET /openapi.json": {Summary: "This OpenAPI document", Tag: "meta", Response: map[string]any{}},
+		"GET /docs":         {Summary: "Interactive API documentation", Tag: "meta", Response: "", ResponseTypes: []string{"text/html"}},
+	}
+}
+
+// buildSpec documents the routes of mux. Routes without an endpoint entry are
+// left out of the document and returned.
+func buildSpec(mux *Mux, h *handlers.Handlers) (*openapi.Document, []Route) {
+	schemas := openapi.NewSchemas()
+	// Timestamps encode themselves, as null when unset
+	schemas.Define(models.Timestamp{}, &openapi.Schema{Type: []string{"string", "null"}, Format: "date-time"})
+
+	docs := endpoints(h)
+	builder := openapi.NewBuilder(apiInfo, schemas)
+	var undocumented []Route
+	for _, route := range mux.Routes() {
+		endpoint, ok := docs[route.Pattern]
+		if !ok {
+			undocumented = append(undocumented, route)
+			continue
+		}
+		if route.Version != "" {
+			endpoint.Headers = maps.Clone(endpoint.Headers)
+			if endpoint.Headers == nil {
+				endpoint.Headers = make(map[string]openapi.Header)
+			}
+			endpoint.Headers[VersionHeader] = openapi.Header{Description: "API version that served the request", Schema: &openapi.Schema{Type: "string"}}
+		}
+		if d := route.Deprecation; d != nil {
+			endpoint.Deprecated = true
+			if !d.Sunset.IsZero() {
+				endpoint.Description = strings.TrimSpace(endpoint.Description + " Removed on " + d.Sunset.Format(time.DateOnly) + ".")
+			}
+		}
+		builder.Add(route.Method, route.Path, endpoint)
+	}
+	return builder.Document(), undocumented
+}
+
+// RegisterDocsRoutes serves the OpenAPI document of every route registered on
+// mux, so it must be called after the other groups. The docs UI is optional.
+func RegisterDocsRoutes(mux *Mux, h *handlers.Handlers, ui bool) {
+	docs := NewGroup(mux)
+	docs.Handle("GET /openapi.json", openapi.Handler(func() *openapi.Document {
+		doc, undocumented := buildSpec(mux, h)
+		for _, route := range undocumented {
+			slog.Warn("Route missing from the OpenAPI document", "route", route.Pattern)
+		}
+		return doc
+	}))
+	if ui {
+		docs.Handle("GET /docs", openapi.UIHandler("/openapi.json"))
+	}
+}
@@ -0,0 +1,92 @@
+package routes
+
+import (
+	"backend/handlers"
+	"backend/openapi"
+	"backend/pagination"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestEveryRouteIsDocumented fails when a route is registered without an
+// endpoint entry, or an entry is left behind for a route that is gone
+func TestEveryRouteIsDocumented(t *testing.T) {
+	router := newTestRouter(t)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected the document, got %d %s", rr.Code, rr.Body)
+	}
+	var doc openapi.Document
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != openapi.Version {
+		t.Errorf("expected OpenAPI %s, got %q", openapi.Version, doc.OpenAPI)
+	}
+
+	registered := make(map[string]bool)
+	for _, route := range router.Routes() {
+		registered[route.Pattern] = true
+		item, ok := doc.Paths[route.Path]
+		if !ok || (*item)[strings.ToLower(route.Method)] == nil {
+			t.Errorf("%s is missing from the OpenAPI document; add it to endpoints in Docs.go", route.Pattern)
+		}
+	}
+
+	h := handlers.New(nil, nil, nil, nil, nil, nil, handlers.NewPaginator(pagination.NewCodec("secret"), 0, 0))
+	for pattern := range endpoints(h) {
+		if !registered[pattern] {
+			t.Errorf("endpoints documents %s, which is not registered", pattern)
+		}
+	}
+}
+
+// TestDocumentedSecurityMatchesRoutes checks that exactly the routes documented
+// as requiring a token reject anonymous requests
+func TestDocumentedSecurityMatchesRoutes(t *testing.T) {
+	router := newTestRouter(t)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var doc openapi.Document
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, route := range router.Routes() {
+		path := strings.ReplaceAll(route.Path, "{id}", "5803acaf-821a-4463-b8b4-15ac6e0e466a")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(route.Method, path, strings.NewReader("{")))
+
+		op := (*doc.Paths[route.Path])[strings.ToLower(route.Method)]
+		if documented, rejected := len(op.Security) > 0, rr.Code == http.StatusUnauthorized; documented != rejected {
+			t.Errorf("%s: documented as requiring a token: %v, anonymous request got %d", route.Pattern, documented, rr.Code)
+		}
+	}
+}
+
+func TestDocumentDescribesBodies(t *testing.T) {
+	router := newTestRouter(t)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var doc openapi.Document
+	if err := json.Unmarshal(rr.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	request := doc.Components.Schemas["GameRequest"]
+	if request == nil || len(request.Required) != 1 || request.Required[0] != "title" {
+		t.Fatalf("expected GameRequest to require title, got %+v", request)
+	}
+	if d := request.Properties["difficulty_level"]; *d.Minimum != 1 || *d.Maximum != 5 {
+		t.Errorf("expected difficulty bounds from the validate tag, got %+v", d)
+	}
+	list := (*doc.Paths["/v1/games"])["get"]
+	if _, ok := list.Responses["200"].Headers["X-Next-Cursor"]; !ok || len(list.Parameters) < 3 {
+		t.Errorf("expected pagination parameters and headers on GET /v1/games, got %+v", list)
+	}
+}
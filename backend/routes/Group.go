@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"backend/middleware"
+	"net/http"
+	"strings"
+)
+
+// Group registers routes on a mux behind a shared middleware stack
+type Group struct {
+	mux         *Mux
+	chain       middleware.Chain
+	version     *Version
+	deprecation *middleware.Deprecation
+}
+
+// NewGroup creates a route group whose routes are wrapped in the given middleware
+func NewGroup(mux *Mux, mws ...middleware.Middleware) *Group {
+	return &Group{mux: mux, chain: middleware.NewChain(mws...)}
+}
+
+// Group creates a nested group that runs the parent's middleware before its own
+func (g *Group) Group(mws ...middleware.Middleware) *Group {
+	nested := *g
+	nested.chain = g.chain.Append(mws...)
+	return &nested
+}
+
+// Deprecated creates a nested group whose routes announce d in their responses
+// and are marked deprecated in the API documentation
+func (g *Group) Deprecated(d middleware.Deprecation) *Group {
+	nested := g.Group(middleware.Deprecate(d))
+	nested.deprecation = &d
+	return nested
+}
+
+// Handle registers handler for pattern, under the version prefix of the group
+// if it has one. Route specific middleware runs inside the group stack.
+func (g *Group) Handle(pattern string, handler http.Handler, mws ...middleware.Middleware) {
+	route := Route{Path: pattern, Deprecation: g.deprecation}
+	if method, path, ok := strings.Cut(pattern, " "); ok {
+		route.Method, route.Path = method, strings.TrimSpace(path)
+	}
+	if g.version != nil {
+		route.Version = g.version.Name
+		route.Path = g.version.Prefix() + route.Path
+	}
+	route.Pattern = route.Path
+	if route.Method != "" {
+		route.Pattern = route.Method + " " + route.Path
+	}
+
+	// Record the pattern first so requests rejected by group middleware are still labelled
+	chain := middleware.NewChain(middleware.Route(route.Pattern)).Append(g.chain...).Append(mws...)
+	g.mux.handle(route, chain.Then(handler))
+}
+
+// HandleFunc registers a handler function for pattern
+func (g *Group) HandleFunc(pattern string, handler http.HandlerFunc, mws ...middleware.Middleware) {
+	g.Handle(pattern, handler, mws...)
+}
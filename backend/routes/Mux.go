@@ -0,0 +1,97 @@
+package routes
+
+import (
+	"backend/middleware"
+	"backend/utils"
+	"net/http"
+	"strings"
+)
+
+// Route is one registered method and path pattern
+type Route struct {
+	Method      string // empty when the pattern matches every method
+	Path        string
+	Pattern     string
+	Version     string                  // API version whose prefix the path starts with, empty for unversioned routes
+	Deprecation *middleware.Deprecation // set when the route announces its removal
+}
+
+// Mux is a ServeMux that keeps a table of its routes and answers requests
+// matching no route with problem+json instead of the plain text defaults
+type Mux struct {
+	mux    *http.ServeMux
+	routes []Route
+	compat *Compat
+}
+
+// NewMux creates an empty Mux
+func NewMux() *Mux {
+	return &Mux{mux: http.NewServeMux()}
+}
+
+// Handle registers handler for pattern and records the route
+func (m *Mux) Handle(pattern string, handler http.Handler) {
+	route := Route{Path: pattern, Pattern: pattern}
+	if method, path, ok := strings.Cut(pattern, " "); ok {
+		route.Method, route.Path = method, strings.TrimSpace(path)
+	}
+	m.handle(route, handler)
+}
+
+func (m *Mux) handle(route Route, handler http.Handler) {
+	m.mux.Handle(route.Pattern, handler)
+	m.routes = append(m.routes, route)
+}
+
+// Routes returns the registered routes in registration order
+func (m *Mux) Routes() []Route {
+	return append([]Route(nil), m.routes...)
+}
+
+// ServeUnversioned serves requests whose path matches no route from the routes
+// of an API version, as configured by c
+func (m *Mux) ServeUnversioned(c Compat) {
+	m.compat = &c
+}
+
+func (m *Mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := m.mux.Handler(r); pattern != "" {
+		m.mux.ServeHTTP(w, r)
+		return
+	}
+	if m.compat != nil && m.compat.serve(m, w, r) {
+		return
+	}
+	m.writeUnmatched(w, r, r)
+}
+
+// writeUnmatched answers r, which matches no route, with a problem. The
+// ServeMux decides between 404 and 405 for target, which is r unless the
+// request was rewritten to a versioned path.
+func (m *Mux) writeUnmatched(w http.ResponseWriter, r, target *http.Request) {
+	status, allow := m.unmatchedStatus(target)
+	if status == http.StatusMethodNotAllowed {
+		w.Header().Set("Allow", allow)
+		utils.WriteError(w, r, http.StatusMethodNotAllowed, utils.CodeMethodNotAllowed, r.Method+" is not supported for this route")
+		return
+	}
+	utils.WriteError(w, r, http.StatusNotFound, utils.CodeRouteNotFound, "No route matches "+r.URL.Path)
+}
+
+// unmatchedStatus returns the status the ServeMux would answer r with, and
+// the methods it allows for 405
+func (m *Mux) unmatchedStatus(r *http.Request) (int, string) {
+	fallback := &fallbackWriter{header: http.Header{}}
+	m.mux.ServeHTTP(fallback, r)
+	return fallback.status, fallback.header.Get("Allow")
+}
+
+// fallbackWriter records the status the ServeMux would send and discards its body
+type fallbackWriter struct {
+	header http.Header
+	status int
+}
+
+func (f *fallbackWriter) Header() http.Header         { return f.header }
+func (f *fallbackWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (f *fallbackWriter) WriteHeader(status int)      { f.status = status }
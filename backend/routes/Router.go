@@ -0,0 +1,112 @@
+package routes
+
+import (
+	"backend/app"
+	"backend/config"
+	"backend/middleware"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// apiVersions are the API versions the router serves, oldest first: one for
+// each name in config.APIVersions, which api.default_version is validated against
+var apiVersions = servedVersions(config.APIVersions)
+
+// versionDeprecations are set when a whole API version is deprecated
+var versionDeprecations = map[string]*middleware.Deprecation{}
+
+func servedVersions(names []string) []Version {
+	versions := make([]Version, len(names))
+	for i, name := range names {
+		versions[i] = Version{Name: name, Deprecation: versionDeprecations[name]}
+	}
+	return versions
+}
+
+// unversionedDeprecated is when the unversioned paths were deprecated in favour of /v1
+var unversionedDeprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// Router is the application handler together with its route table
+type Router struct {
+	handler http.Handler
+	mux     *Mux
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.handler.ServeHTTP(w, r)
+}
+
+// Routes returns every registered route
+func (rt *Router) Routes() []Route {
+	return rt.mux.Routes()
+}
+
+// BodyLimits cap request bodies: Import on bulk import routes, Default everywhere else
+type BodyLimits struct {
+	Default middleware.Middleware
+	Import  middleware.Middleware
+}
+
+// NewRouter builds the application handler with all route groups registered
+func NewRouter(a *app.App) (*Router, error) {
+	// The global stack runs for every request, including ones that match no route
+	globalMiddleware := middleware.NewChain(
+		middleware.RequestID,
+		middleware.Tracing,
+		middleware.AccessLog,
+		middleware.Metrics,
+		middleware.Recover,
+		a.CORS.Middleware,
+	)
+
+	auth := middleware.ValidateJWT(a.Config().JWTSecret)
+	// Bodies are capped per group since bulk imports may be much larger than other requests
+	limits := BodyLimits{
+		Default: middleware.MaxBodyBytes(a.Config().Server.MaxBodyBytes),
+		Import:  middleware.MaxBodyBytes(a.Config().Server.MaxImportBytes),
+	}
+
+	// Retried POSTs with the same Idempotency-Key get the first response instead of running again
+	idempotent := middleware.Idempotency(a.Idempotency, a.Config().Idempotency)
+
+	mux := NewMux()
+	groups := make(map[string]*Group, len(apiVersions))
+	for _, v := range apiVersions {
+		groups[v.Name] = NewVersionGroup(mux, v)
+	}
+	// Each version registers its routes on its group here. Routes a new version
+	// leaves unchanged are registered again with the same handlers; changed ones
+	// get new handlers.
+	v1 := groups[V1.Name]
+	RegisterPublicRoutes(v1, a.Handlers, limits, idempotent)
+	RegisterSecuredRoutes(v1, a.Handlers, auth, limits, idempotent)
+	RegisterGraphQLRoutes(v1, a.Graph, auth, limits)
+
+	if api := a.Config().API; api.UnversionedPaths {
+		defaultVersion, err := findVersion(api.DefaultVersion)
+		if err != nil {
+			return nil, err
+		}
+		mux.ServeUnversioned(Compat{
+			Versions:    apiVersions,
+			Default:     defaultVersion,
+			Deprecation: middleware.Deprecation{Since: unversionedDeprecated, Sunset: api.Sunset()},
+		})
+	}
+
+	RegisterOpsRoutes(mux, a.Readiness, auth)
+	RegisterDocsRoutes(mux, a.Handlers, a.Config().Server.DocsUI)
+
+	return &Router{handler: globalMiddleware.Then(mux), mux: mux}, nil
+}
+
+// findVersion returns the served version called name
+func findVersion(name string) (Version, error) {
+	for _, v := range apiVersions {
+		if v.Name == name {
+			return v, nil
+		}
+	}
+	return Version{}, fmt.Errorf("api.default_version: %q is not a served API version", name)
+}
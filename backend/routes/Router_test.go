@@ -0,0 +1,238 @@
+package routes
+
+import (
+	"backend/app"
+	"backend/config"
+	"backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const testJWTSecret = "router-test-secret"
+
+func newTestRouter(t *testing.T) *Router {
+	t.Helper()
+
+	// Every Supabase call fails, so authenticated requests reach the service error path
+	return newTestRouterWith(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"code":"XX000","message":"boom"}`))
+	}))
+}
+
+// newTestRouterWith builds a router whose Supabase calls are served by supabase
+func newTestRouterWith(t *testing.T, supabase http.Handler) *Router {
+	t.Helper()
+
+	server := httptest.NewServer(supabase)
+	t.Cleanup(server.Close)
+
+	a, err := app.New(config.Config{
+		SupabaseURL: server.URL,
+		JWTSecret:   testJWTSecret,
+		Supabase: config.SupabaseClientConfig{
+			Timeout:         time.Second,
+			RetryAttempts:   1,
+			BreakerFailures: 100,
+			BreakerCooldown: time.Second,
+		},
+		Health:     config.HealthConfig{CheckTimeout: time.Second, CacheTTL: time.Second},
+		Log:        config.LogConfig{Level: "error"},
+		Server:     config.ServerConfig{DocsUI: true},
+		API:        config.APIConfig{UnversionedPaths: true, DefaultVersion: "v1"},
+		Pagination: config.PaginationConfig{DefaultLimit: 25, MaxLimit: 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { a.Close() })
+	router, err := NewRouter(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return router
+}
+
+func testToken(t *testing.T) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":  "5803acaf-821a-4463-b8b4-15ac6e0e466a",
+		"role": "authenticated",
+	}).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// TestErrorResponsesAreProblems walks every registered route, anonymously and
+// with a token, and asserts that every error response is application/problem+json
+func TestErrorResponsesAreProblems(t *testing.T) {
+	router := newTestRouter(t)
+	token := testToken(t)
+
+	routes := router.Routes()
+	if len(routes) == 0 {
+		t.Fatal("expected the router to record its routes")
+	}
+
+	for _, route := range routes {
+		// The readiness probe reports its checks as plain JSON on purpose
+		if route.Pattern == "GET /readyz" {
+			continue
+		}
+		path := strings.ReplaceAll(route.Path, "{id}", "5803acaf-821a-4463-b8b4-15ac6e0e466a")
+
+		for _, auth := range []string{"", "Bearer not-a-jwt", "Bearer " + token} {
+			req := httptest.NewRequest(route.Method, path, strings.NewReader("{"))
+			req.Header.Set("Content-Type", "application/json")
+			if auth != "" {
+				req.Header.Set("Authorization", auth)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code < 400 {
+				continue
+			}
+			if ct := rr.Header().Get("Content-Type"); ct != utils.ProblemContentType {
+				t.Errorf("%s with auth %q: status %d has Content-Type %q, body %s", route.Pattern, auth, rr.Code, ct, rr.Body)
+			}
+		}
+	}
+}
+
+func TestUnmatchedRoutesAreProblems(t *testing.T) {
+	router := newTestRouter(t)
+
+	cases := []struct {
+		method, path string
+		status       int
+		code         string
+	}{
+		{http.MethodGet, "/nope", http.StatusNotFound, utils.CodeRouteNotFound},
+		{http.MethodPut, "/games", http.StatusMethodNotAllowed, utils.CodeMethodNotAllowed},
+	}
+	for _, tc := range cases {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+
+		if rr.Code != tc.status {
+			t.Errorf("%s %s: expected %d, got %d", tc.method, tc.path, tc.status, rr.Code)
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != utils.ProblemContentType {
+			t.Errorf("%s %s: expected problem+json, got %q", tc.method, tc.path, ct)
+		}
+		if !strings.Contains(rr.Body.String(), `"code":"`+tc.code+`"`) {
+			t.Errorf("%s %s: expected code %s, got %s", tc.method, tc.path, tc.code, rr.Body)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/games", nil))
+	if allow := rr.Header().Get("Allow"); !strings.Contains(allow, http.MethodPost) {
+		t.Errorf("expected the Allow header to list POST, got %q", allow)
+	}
+}
+
+func TestEveryServedVersionHasRoutes(t *testing.T) {
+	routes := map[string]int{}
+	for _, route := range newTestRouter(t).Routes() {
+		routes[route.Version]++
+	}
+	for _, name := range config.APIVersions {
+		if routes[name] == 0 {
+			t.Errorf("config.APIVersions lists %s, but the router registers no routes for it", name)
+		}
+	}
+	if _, err := findVersion("v2"); err == nil {
+		t.Error("expected an error for a version that is not served")
+	}
+}
+
+// fakeTrash serves the games and game_results tables to the extent trashing a
+// game and listing results need, honouring the games!inner() embedded filter
+type fakeTrash struct {
+	mu      sync.Mutex
+	trashed map[string]bool // game ID to whether it is in the trash
+	results map[string]string
+}
+
+func (f *fakeTrash) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	query := r.URL.Query()
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodPatch && r.URL.Path == "/rest/v1/games":
+		id := strings.TrimPrefix(query.Get("id"), "eq.")
+		if f.trashed[id] || query.Get("deleted_at") != "is.null" {
+			w.Write([]byte(`[]`))
+			return
+		}
+		f.trashed[id] = true
+		w.Write([]byte(`[{"id":"` + id + `","deleted_at":"2026-10-19T12:00:00Z"}]`))
+	case r.Method == http.MethodGet && r.URL.Path == "/rest/v1/game_results":
+		onlyLive := query.Get("games.deleted_at") == "is.null" && strings.Contains(query.Get("select"), "games!inner()")
+		rows := []string{}
+		for id, gameID := range f.results {
+			if onlyLive && f.trashed[gameID] {
+				continue
+			}
+			rows = append(rows, `{"id":"`+id+`","game_id":"`+gameID+`","completed_at":"2026-10-19T12:00:00Z"}`)
+		}
+		w.Write([]byte("[" + strings.Join(rows, ",") + "]"))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":"PGRST205","message":"unexpected call"}`))
+	}
+}
+
+func TestTrashedGamesHideTheirResults(t *testing.T) {
+	kept, trashedGame := uuid.NewString(), uuid.NewString()
+	keptResult, trashedResult := uuid.NewString(), uuid.NewString()
+	router := newTestRouterWith(t, &fakeTrash{
+		trashed: map[string]bool{},
+		results: map[string]string{keptResult: kept, trashedResult: trashedGame},
+	})
+	token := testToken(t)
+
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	// resultIDs lists the results of the caller from REST and from GraphQL
+	resultIDs := func() (rest, graph string) {
+		return send(http.MethodGet, "/v1/results", "").Body.String(),
+			send(http.MethodPost, "/v1/graphql", `{"query":"{ results { nodes { id } } }"}`).Body.String()
+	}
+
+	rest, graph := resultIDs()
+	if !strings.Contains(rest, trashedResult) || !strings.Contains(graph, trashedResult) {
+		t.Fatalf("expected the result to be listed before its game is trashed, got %s and %s", rest, graph)
+	}
+
+	if rr := send(http.MethodDelete, "/v1/games/"+trashedGame, ""); rr.Code != http.StatusNoContent {
+		t.Fatalf("expected the game to be trashed, got %d %s", rr.Code, rr.Body)
+	}
+	rest, graph = resultIDs()
+	if strings.Contains(rest, trashedResult) || !strings.Contains(rest, keptResult) {
+		t.Errorf("expected /v1/results to leave out the results of the trashed game, got %s", rest)
+	}
+	if strings.Contains(graph, trashedResult) || !strings.Contains(graph, keptResult) {
+		t.Errorf("expected GraphQL results to leave out the results of the trashed game, got %s", graph)
+	}
+}
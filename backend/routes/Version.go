@@ -0,0 +1,130 @@
+package routes
+
+import (
+	"backend/metrics"
+	"backend/middleware"
+	"backend/utils"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// VersionHeader names the API version of a response, and lets clients choose
+// the version that serves unversioned paths
+const VersionHeader = "API-Version"
+
+// Version is a generation of the API whose routes are served under /<Name>.
+// Versions are served side by side, each with its own route registrations.
+type Version struct {
+	Name        string                  // path prefix and API-Version value, e.g. "v1"
+	Deprecation *middleware.Deprecation // set once the whole version is deprecated
+}
+
+// V1 is the first versioned API, with the routes that used to be served at the root
+var V1 = Version{Name: "v1"}
+
+// Prefix returns the path prefix of the version's routes, e.g. "/v1"
+func (v Version) Prefix() string {
+	return "/" + v.Name
+}
+
+// NewVersionGroup creates a route group that serves its routes under the prefix
+// of v and names v in the API-Version header of every response
+func NewVersionGroup(mux *Mux, v Version, mws ...middleware.Middleware) *Group {
+	g := NewGroup(mux, append([]middleware.Middleware{announceVersion(v.Name)}, mws...)...)
+	g.version = &v
+	if v.Deprecation != nil {
+		g = g.Deprecated(*v.Deprecation)
+	}
+	return g
+}
+
+func announceVersion(name string) middleware.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(VersionHeader, name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Compat serves unversioned paths, such as /games, from the routes of an API
+// version, such as /v1/games, so that clients keep working while they move to
+// versioned paths. Clients pick the version with the API-Version header.
+type Compat struct {
+	Versions    []Version              // versions the API-Version header may name
+	Default     Version                // version used when the header is absent
+	Deprecation middleware.Deprecation // announced on every unversioned response
+}
+
+// serve answers r from the version the request negotiates and reports whether
+// it did. Paths that match no route of that version are left to the Mux.
+func (c *Compat) serve(m *Mux, w http.ResponseWriter, r *http.Request) bool {
+	version, ok := c.negotiate(r.Header.Get(VersionHeader))
+	if !ok {
+		utils.WriteError(w, r, http.StatusBadRequest, utils.CodeUnknownVersion,
+			fmt.Sprintf("%s must be one of %s", VersionHeader, strings.Join(c.names(), ", ")))
+		return true
+	}
+
+	versioned := withPathPrefix(r, version.Prefix())
+	_, pattern := m.mux.Handler(versioned)
+	if pattern == "" {
+		if status, _ := m.unmatchedStatus(versioned); status != http.StatusMethodNotAllowed {
+			return false
+		}
+	}
+
+	h := w.Header()
+	h.Add("Vary", VersionHeader)
+	c.Deprecation.WriteHeaders(h)
+	h.Add("Link", "<"+versioned.URL.EscapedPath()+`>; rel="successor-version"`)
+	if pattern == "" {
+		m.writeUnmatched(w, r, versioned)
+		return true
+	}
+
+	m.mux.ServeHTTP(w, versioned)
+	route := strings.TrimPrefix(middleware.RoutePattern(r.Context()), version.Prefix())
+	metrics.HTTPDeprecated.WithLabelValues(r.Method, route).Inc()
+	return true
+}
+
+// negotiate returns the version named by an API-Version header value such as
+// "v1" or "1", or the default one when the value is empty
+func (c *Compat) negotiate(value string) (Version, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return c.Default, true
+	}
+	if !strings.HasPrefix(value, "v") {
+		value = "v" + value
+	}
+	for _, v := range c.Versions {
+		if v.Name == value {
+			return v, true
+		}
+	}
+	return Version{}, false
+}
+
+func (c *Compat) names() []string {
+	names := make([]string, len(c.Versions))
+	for i, v := range c.Versions {
+		names[i] = v.Name
+	}
+	return names
+}
+
+// withPathPrefix returns a shallow copy of r whose path starts with prefix
+func withPathPrefix(r *http.Request, prefix string) *http.Request {
+	u := *r.URL
+	u.Path = prefix + r.URL.Path
+	if r.URL.RawPath != "" {
+		u.RawPath = prefix + r.URL.RawPath
+	}
+	prefixed := new(http.Request)
+	*prefixed = *r
+	prefixed.URL = &u
+	return prefixed
+}
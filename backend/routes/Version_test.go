@@ -0,0 +1,151 @@
+package routes
+
+import (
+	"backend/middleware"
+	"backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newVersionedMux serves GET /games in v1 and v2, and GET /games/legacy only in
+// v1 where it is deprecated. Unversioned paths default to v1.
+func newVersionedMux() *Mux {
+	v2 := Version{Name: "v2"}
+	mux := NewMux()
+	reply := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(body)) }
+	}
+
+	v1 := NewVersionGroup(mux, V1)
+	v1.HandleFunc("GET /games", reply("v1 games"))
+	v1.Deprecated(middleware.Deprecation{
+		Since:  time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Sunset: time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Link:   "https://example.com/migrate",
+	}).HandleFunc("GET /games/legacy", reply("legacy"))
+	NewVersionGroup(mux, v2).HandleFunc("GET /games", reply("v2 games"))
+
+	mux.ServeUnversioned(Compat{
+		Versions:    []Version{V1, v2},
+		Default:     V1,
+		Deprecation: middleware.Deprecation{Since: unversionedDeprecated},
+	})
+	return mux
+}
+
+func TestVersionsAreServedSideBySide(t *testing.T) {
+	mux := newVersionedMux()
+
+	for _, version := range []string{"v1", "v2"} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/"+version+"/games", nil))
+		if rr.Body.String() != version+" games" {
+			t.Errorf("/%s/games: expected %s games, got %d %s", version, version, rr.Code, rr.Body)
+		}
+		if got := rr.Header().Get(VersionHeader); got != version {
+			t.Errorf("/%s/games: expected %s %s, got %q", version, VersionHeader, version, got)
+		}
+		if rr.Header().Get("Deprecation") != "" {
+			t.Errorf("/%s/games: expected no Deprecation header", version)
+		}
+	}
+
+	routes := mux.Routes()
+	if routes[0].Pattern != "GET /v1/games" || routes[0].Version != "v1" || routes[2].Pattern != "GET /v2/games" {
+		t.Errorf("expected versioned patterns in the route table, got %+v", routes)
+	}
+}
+
+func TestDeprecatedRouteAnnouncesSunset(t *testing.T) {
+	mux := newVersionedMux()
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/games/legacy", nil))
+
+	if got := rr.Header().Get("Deprecation"); got != "@1767225600" {
+		t.Errorf("expected the deprecation date as a structured date, got %q", got)
+	}
+	if got := rr.Header().Get("Sunset"); got != "Fri, 01 Jan 2027 00:00:00 GMT" {
+		t.Errorf("expected the sunset as an HTTP date, got %q", got)
+	}
+	if got := rr.Header().Get("Link"); got != `<https://example.com/migrate>; rel="deprecation"` {
+		t.Errorf("expected a deprecation link, got %q", got)
+	}
+	if route := mux.Routes()[1]; route.Deprecation == nil {
+		t.Errorf("expected the route table to record the deprecation, got %+v", route)
+	}
+}
+
+func TestUnversionedPathsNegotiateVersion(t *testing.T) {
+	mux := newVersionedMux()
+
+	cases := []struct {
+		header, body, successor string
+	}{
+		{"", "v1 games", "/v1/games"},
+		{"v2", "v2 games", "/v2/games"},
+		{"2", "v2 games", "/v2/games"},
+		{" V1 ", "v1 games", "/v1/games"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/games?limit=5", nil)
+		if tc.header != "" {
+			req.Header.Set(VersionHeader, tc.header)
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+
+		if rr.Body.String() != tc.body {
+			t.Errorf("header %q: expected %q, got %d %s", tc.header, tc.body, rr.Code, rr.Body)
+		}
+		if rr.Header().Get("Deprecation") == "" || rr.Header().Get("Vary") != VersionHeader {
+			t.Errorf("header %q: expected the unversioned path to be deprecated and vary by version, got %v", tc.header, rr.Header())
+		}
+		if got := rr.Header().Get("Link"); got != "<"+tc.successor+`>; rel="successor-version"` {
+			t.Errorf("header %q: expected a link to %s, got %q", tc.header, tc.successor, got)
+		}
+	}
+}
+
+func TestUnversionedPathErrors(t *testing.T) {
+	mux := newVersionedMux()
+
+	cases := []struct {
+		method, path, header string
+		status               int
+		code                 string
+	}{
+		{http.MethodGet, "/games", "v3", http.StatusBadRequest, utils.CodeUnknownVersion},
+		{http.MethodPost, "/games", "", http.StatusMethodNotAllowed, utils.CodeMethodNotAllowed},
+		{http.MethodGet, "/games/legacy", "v2", http.StatusNotFound, utils.CodeRouteNotFound},
+		{http.MethodGet, "/v3/games", "", http.StatusNotFound, utils.CodeRouteNotFound},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.header != "" {
+			req.Header.Set(VersionHeader, tc.header)
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+
+		if rr.Code != tc.status || !strings.Contains(rr.Body.String(), `"code":"`+tc.code+`"`) {
+			t.Errorf("%s %s with %q: expected %d %s, got %d %s", tc.method, tc.path, tc.header, tc.status, tc.code, rr.Code, rr.Body)
+		}
+		if tc.status == http.StatusNotFound && !strings.Contains(rr.Body.String(), "No route matches "+tc.path) {
+			t.Errorf("%s %s: expected the requested path in the problem, got %s", tc.method, tc.path, rr.Body)
+		}
+	}
+}
+
+func TestUnversionedPathsCanBeTurnedOff(t *testing.T) {
+	mux := NewMux()
+	NewVersionGroup(mux, V1).HandleFunc("GET /games", func(w http.ResponseWriter, r *http.Request) {})
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/games", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without ServeUnversioned, got %d", rr.Code)
+	}
+}
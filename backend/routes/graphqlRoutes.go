@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"backend/graph"
+	"backend/handlers"
+	"backend/middleware"
+)
+
+// RegisterGraphQLRoutes registers the GraphQL endpoint on api, the group of an
+// API version. It takes the same access token as the secured routes.
+func RegisterGraphQLRoutes(api *Group, server *graph.Server, auth middleware.Middleware, limits BodyLimits) {
+	secured := api.Group(auth, limits.Default)
+	secured.HandleFunc("GET /graphql", handlers.GraphQLHandler(server))
+	secured.HandleFunc("POST /graphql", handlers.GraphQLHandler(server))
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"backend/handlers"
+	"backend/health"
+	"backend/metrics"
+	"backend/middleware"
+)
+
+// RegisterOpsRoutes registers the operational endpoints used by probes and scrapers
+func RegisterOpsRoutes(mux *Mux, readiness *health.Readiness, auth middleware.Middleware) {
+	ops := NewGroup(mux)
+	ops.HandleFunc("GET /healthz", handlers.HealthHandler)
+	ops.HandleFunc("GET /readyz", handlers.ReadinessHandler(readiness))
+	ops.Handle("GET /metrics", metrics.Handler())
+
+	admin := NewGroup(mux, auth, middleware.RequireRole("admin"))
+	admin.HandleFunc("GET /readyz/details", handlers.ReadinessDetailsHandler(readiness))
+}
@@ -2,11 +2,16 @@ package routes
 
 import (
 	"backend/handlers"
-	"net/http"
+	"backend/middleware"
 )
 
-func RegisterPublicRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("POST /users", handlers.CreateUserHandler)
-	mux.HandleFunc("POST /login", handlers.LoginHandler)
-	mux.HandleFunc("POST /logout", handlers.LogoutHandler)
+// RegisterPublicRoutes registers the routes that need no access token on api,
+// the group of an API version. idempotent runs on the routes that create resources.
+func RegisterPublicRoutes(api *Group, h *handlers.Handlers, limits BodyLimits, idempotent middleware.Middleware) {
+	public := api.Group(limits.Default)
+
+	public.HandleFunc("POST /users", h.CreateUserHandler, idempotent)
+	public.HandleFunc("POST /login", h.LoginHandler)
+	public.HandleFunc("POST /logout", h.LogoutHandler)
+	public.HandleFunc("GET /validation/rules", handlers.ValidationRulesHandler)
 }
@@ -2,11 +2,39 @@ package routes
 
 import (
 	"backend/handlers"
-	"net/http"
+	"backend/middleware"
 )
 
-func RegisterSecuredRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("GET /users/{id}", handlers.GetUserByIDHandler)
-	mux.HandleFunc("PATCH /users/{id}", handlers.UpdateUserByIDHandler)
-	mux.HandleFunc("DELETE /users/{id}", handlers.DeleteUserByIDHandler)
+// RegisterSecuredRoutes registers the routes that require an access token on
+// api, the group of an API version. idempotent runs on the routes that create resources.
+func RegisterSecuredRoutes(api *Group, h *handlers.Handlers, auth middleware.Middleware, limits BodyLimits, idempotent middleware.Middleware) {
+	authenticated := api.Group(auth)
+	secured := authenticated.Group(limits.Default)
+
+	secured.HandleFunc("GET /users/{id}", h.GetUserByIDHandler)
+	secured.HandleFunc("PATCH /users/{id}", h.UpdateUserByIDHandler)
+	secured.HandleFunc("DELETE /users/{id}", h.DeleteUserByIDHandler)
+
+	secured.HandleFunc("GET /games", h.GamesHandler)
+	secured.HandleFunc("POST /games", h.CreateGameHandler, idempotent)
+	secured.HandleFunc("GET /games/search", h.SearchGamesHandler)
+	secured.HandleFunc("GET /games/export", h.ExportGamesHandler)
+	secured.HandleFunc("GET /games/{id}", h.GetGameHandler)
+	secured.HandleFunc("PATCH /games/{id}", h.UpdateGameHandler)
+	secured.HandleFunc("DELETE /games/{id}", h.DeleteGameHandler)
+	secured.HandleFunc("GET /games/trash", h.TrashedGamesHandler)
+	secured.HandleFunc("POST /games/{id}/restore", h.RestoreGameHandler)
+
+	// Imports upsert by external key, so retries are safe without idempotent,
+	// which would buffer the whole file to fingerprint it
+	bulk := authenticated.Group(limits.Import)
+	bulk.HandleFunc("POST /games/import", h.ImportGamesHandler)
+
+	secured.HandleFunc("GET /subjects", h.ListSubjectsHandler)
+	secured.HandleFunc("GET /results", h.ListResultsHandler)
+
+	admin := secured.Group(middleware.RequireRole("admin"))
+	admin.HandleFunc("GET /admin/users", h.ListUsersHandler)
+	admin.HandleFunc("GET /admin/users/trash", h.TrashedUsersHandler)
+	admin.HandleFunc("POST /admin/users/{id}/restore", h.RestoreUserHandler)
 }
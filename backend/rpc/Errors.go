@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"backend/services"
+	"backend/utils"
+	"context"
+	"errors"
+	"log/slog"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain names this API in the ErrorInfo of errors
+const errorDomain = "ilang"
+
+// coded returns an error with code and, in an ErrorInfo detail, the stable
+// error code of package utils as reason, so clients can switch on the same
+// codes as REST clients
+func coded(code codes.Code, reason, message string, details ...*errdetails.BadRequest_FieldViolation) error {
+	st := status.New(code, message)
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: errorDomain})
+	if err != nil {
+		return st.Err()
+	}
+	if len(details) > 0 {
+		if withViolations, err := withDetails.WithDetails(&errdetails.BadRequest{FieldViolations: details}); err == nil {
+			withDetails = withViolations
+		}
+	}
+	return withDetails.Err()
+}
+
+// serviceError logs err with attrs and maps it to the status reported to the
+// client. Like the REST handlers, client errors carry the upstream explanation
+// when there is one and server errors only say what failed.
+func serviceError(ctx context.Context, err error, failure string, attrs ...any) error {
+	code, reason, message := codes.Internal, utils.CodeInternal, failure
+	var upstream *services.UpstreamError
+	switch {
+	case errors.Is(err, services.ErrInvalidCredentials):
+		code, reason, message = codes.Unauthenticated, utils.CodeInvalidCredentials, "Invalid email or password"
+	case errors.Is(err, services.ErrNotFound):
+		code, reason, message = codes.NotFound, utils.CodeNotFound, "The requested resource does not exist"
+	case errors.Is(err, services.ErrConflict):
+		code, reason = codes.AlreadyExists, utils.CodeConflict
+		if errors.As(err, &upstream) {
+			message = upstream.Message
+		}
+	case errors.Is(err, services.ErrPreconditionFailed):
+		code, reason, message = codes.FailedPrecondition, utils.CodePreconditionFailed, "The resource has changed since it was read"
+	case errors.Is(err, services.ErrValidation):
+		code, reason = codes.InvalidArgument, utils.CodeValidationFailed
+		if errors.As(err, &upstream) {
+			message = upstream.Message
+		}
+	case errors.Is(err, services.ErrUnauthorized):
+		code, reason, message = codes.Unauthenticated, utils.CodeUnauthorized, "The request is not authorized"
+	case errors.Is(err, services.ErrCircuitOpen):
+		code, reason, message = codes.Unavailable, utils.CodeUnavailable, "Supabase is unavailable, try again later"
+	case errors.Is(err, services.ErrUpstream):
+		reason = utils.CodeUpstreamError
+	}
+
+	level := slog.LevelWarn
+	if serverError(code) {
+		level = slog.LevelError
+	}
+	slog.Log(ctx, level, failure, append(attrs, "code", code.String(), "err", err)...)
+	return coded(code, reason, message)
+}
+
+// serverError reports whether code blames the server rather than the caller
+func serverError(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unavailable, codes.Unknown, codes.DataLoss, codes.Unimplemented:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package rpc
+
+import (
+	"backend/middleware"
+	"backend/models"
+	"backend/rpc/ilangv1"
+	"backend/utils"
+	"backend/validation"
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// gameServer implements ilangv1.GameServiceServer with the handlers' rules for games
+type gameServer struct {
+	ilangv1.UnimplementedGameServiceServer
+	games GameStore
+	pages pager
+}
+
+func (s *gameServer) ListGames(ctx context.Context, req *ilangv1.ListGamesRequest) (*ilangv1.ListGamesResponse, error) {
+	userID, _ := middleware.UserID(ctx)
+	opts, err := s.pages.options(req.PageSize, req.PageToken, gamesSort)
+	if err != nil {
+		return nil, err
+	}
+	subjectID, err := parseOptionalID("subject_id", req.SubjectId)
+	if err != nil {
+		return nil, err
+	}
+	opts.Filters = eqFilter(opts.Filters, "subject_id", subjectID)
+	opts.Filters = eqFilter(opts.Filters, "difficulty_level", req.Difficulty)
+
+	page, err := s.games.FetchGames(ctx, userID, opts)
+	if err != nil {
+		return nil, serviceError(ctx, err, "Failed to fetch games")
+	}
+	resp := &ilangv1.ListGamesResponse{NextPageToken: s.pages.nextToken(page.Next, gamesSort)}
+	for _, game := range page.Items {
+		resp.Games = append(resp.Games, gameMessage(game))
+	}
+	return resp, nil
+}
+
+func (s *gameServer) GetGame(ctx context.Context, req *ilangv1.GetGameRequest) (*ilangv1.Game, error) {
+	userID, _ := middleware.UserID(ctx)
+	gameID, err := parseID("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	game, err := s.games.FetchGameByID(ctx, gameID, userID)
+	if err != nil {
+		return nil, serviceError(ctx, err, "Failed to fetch game", "game_id", gameID)
+	}
+	return gameMessage(game), nil
+}
+
+func (s *gameServer) CreateGame(ctx context.Context, req *ilangv1.CreateGameRequest) (*ilangv1.Game, error) {
+	subjectID, err := parseOptionalID("subject_id", req.SubjectId)
+	if err != nil {
+		return nil, err
+	}
+	game := models.GameRequest{
+		Title:       req.Title,
+		Description: req.Description,
+		SubjectID:   subjectID,
+		Difficulty:  int(req.Difficulty),
+		Language:    req.Language,
+	}
+	if err := checkGame(validation.Struct(game)); err != nil {
+		return nil, err
+	}
+
+	created, err := s.games.CreateGame(ctx, game)
+	if err != nil {
+		return nil, serviceError(ctx, err, "Failed to create game")
+	}
+	return gameMessage(created), nil
+}
+
+func (s *gameServer) UpdateGame(ctx context.Context, req *ilangv1.UpdateGameRequest) (*ilangv1.Game, error) {
+	userID, _ := middleware.UserID(ctx)
+	gameID, err := parseID("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	subjectID, err := parseOptionalID("subject_id", req.SubjectId)
+	if err != nil {
+		return nil, err
+	}
+	update := models.GameRequest{
+		Title:       req.GetTitle(),
+		Description: req.GetDescription(),
+		SubjectID:   subjectID,
+		Difficulty:  int(req.GetDifficulty()),
+		Language:    req.GetLanguage(),
+	}
+	if err := checkGame(validation.Partial(update)); err != nil {
+		return nil, err
+	}
+	if update == (models.GameRequest{}) {
+		return nil, coded(codes.InvalidArgument, utils.CodeValidationFailed, "No valid fields to update")
+	}
+
+	updated, err := s.games.UpdateGameByID(ctx, gameID, userID, update, nil)
+	if err != nil {
+		return nil, serviceError(ctx, err, "Failed to update game", "game_id", gameID)
+	}
+	return gameMessage(updated), nil
+}
+
+func (s *gameServer) DeleteGame(ctx context.Context, req *ilangv1.DeleteGameRequest) (*emptypb.Empty, error) {
+	userID, _ := middleware.UserID(ctx)
+	gameID, err := parseID("id", req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.games.DeleteGameByID(ctx, gameID, userID, nil); err != nil {
+		return nil, serviceError(ctx, err, "Failed to delete game", "game_id", gameID)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// checkGame reports the result of validating a game request
+func checkGame(err error) error {
+	var invalid validation.Errors
+	if errors.As(err, &invalid) {
+		return validationError(invalid)
+	}
+	return err
+}
+
+func gameMessage(g models.Game) *ilangv1.Game {
+	return &ilangv1.Game{
+		Id:          g.ID.String(),
+		Title:       g.Title,
+		Description: g.Description,
+		SubjectId:   optionalID(g.SubjectID),
+		Difficulty:  int32(g.Difficulty),
+		Language:    g.Language,
+		ExternalKey: g.ExternalKey,
+		CreatedAt:   timestamp(g.CreatedAt),
+	}
+}
+
+func optionalID(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+// timestamp converts ts, nil when it is not set
+func timestamp(ts models.Timestamp) *timestamppb.Timestamp {
+	if ts.IsZero() {
+		return nil
+	}
+	return timestamppb.New(ts.Time)
+}
@@ -0,0 +1,143 @@
+package rpc
+
+import (
+	"backend/logging"
+	"backend/metrics"
+	"backend/middleware"
+	"backend/utils"
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// publicServices may be called without an access token, so load balancers and
+// tools like grpcurl can reach them
+var publicServices = []string{"/grpc.health.v1.Health/", "/grpc.reflection."}
+
+// observeUnary assigns the call a request ID, then logs and counts it like AccessLog and Metrics do for HTTP
+func observeUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	ctx = withRequestID(ctx)
+	resp, err := handler(ctx, req)
+	observe(ctx, info.FullMethod, err, start)
+	return resp, err
+}
+
+func observeStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	ctx := withRequestID(ss.Context())
+	err := handler(srv, &contextStream{ss, ctx})
+	observe(ctx, info.FullMethod, err, start)
+	return err
+}
+
+// withRequestID takes the request ID from the x-request-id metadata like the
+// RequestID middleware does from the header, and sends it back
+func withRequestID(ctx context.Context) context.Context {
+	var incoming string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(logging.RequestIDHeader); len(values) > 0 {
+			incoming = values[0]
+		}
+	}
+	requestID := middleware.ResolveRequestID(incoming)
+	grpc.SetHeader(ctx, metadata.Pairs(logging.RequestIDHeader, requestID))
+	return logging.WithRequestID(ctx, requestID)
+}
+
+func observe(ctx context.Context, method string, err error, start time.Time) {
+	elapsed := time.Since(start)
+	code := status.Code(err)
+	metrics.ObserveGRPC(method, code.String(), elapsed)
+
+	level := slog.LevelInfo
+	if serverError(code) {
+		level = slog.LevelError
+	}
+	slog.LogAttrs(ctx, level, "call completed",
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("latency", elapsed),
+	)
+}
+
+// recoverUnary turns a panic into an Internal error like the Recover middleware
+func recoverUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = recovered(ctx, info.FullMethod, p)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func recoverStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = recovered(ss.Context(), info.FullMethod, p)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+func recovered(ctx context.Context, method string, p any) error {
+	slog.ErrorContext(ctx, "recovered from panic", "panic", p, "method", method, "stack", string(debug.Stack()))
+	return coded(codes.Internal, utils.CodeInternal, "An unexpected error occurred")
+}
+
+// authenticateUnary validates the access token in the authorization metadata
+// with the checks of ValidateJWT and adds the caller to the context
+func authenticateUnary(secret string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, secret, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func authenticateStream(secret string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), secret, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ss, ctx})
+	}
+}
+
+func authenticate(ctx context.Context, secret, method string) (context.Context, error) {
+	for _, prefix := range publicServices {
+		if strings.HasPrefix(method, prefix) {
+			return ctx, nil
+		}
+	}
+
+	var authorization string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) > 0 {
+			authorization = values[0]
+		}
+	}
+	userID, role, authErr := middleware.Authenticate(secret, authorization)
+	if authErr != nil {
+		return ctx, coded(codes.Unauthenticated, authErr.Code, authErr.Message)
+	}
+	return middleware.WithPrincipal(ctx, userID, role), nil
+}
+
+// contextStream is a ServerStream with a replaced context
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
@@ -0,0 +1,109 @@
+package rpc
+
+import (
+	"backend/pagination"
+	"backend/services"
+	"backend/utils"
+	"backend/validation"
+	"strconv"
+
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+)
+
+// Fixed sort orders of the list calls, matching the REST defaults so page
+// tokens and REST cursors are interchangeable
+var (
+	gamesSort   = []services.SortKey{{Column: "created_at", Descending: true}, {Column: "id", Descending: true}}
+	usersSort   = []services.SortKey{{Column: "created_at", Descending: true}, {Column: "id", Descending: true}}
+	resultsSort = []services.SortKey{{Column: "completed_at", Descending: true}, {Column: "id", Descending: true}}
+)
+
+// pager turns page sizes and tokens into list options and back
+type pager struct {
+	cursors     *pagination.Codec
+	defaultSize int
+	maxSize     int
+}
+
+// options reads the page_size and page_token of a list request. Rows are not
+// counted since the responses carry no total.
+func (p pager) options(pageSize int32, pageToken string, sort []services.SortKey) (services.ListOptions, error) {
+	opts := services.ListOptions{Sort: sort, Limit: p.defaultSize, NoCount: true}
+	var violations []*errdetails.BadRequest_FieldViolation
+	if pageSize < 0 || int(pageSize) > p.maxSize {
+		violations = append(violations, violation("page_size", "must be between 0 and "+strconv.Itoa(p.maxSize)))
+	} else if pageSize > 0 {
+		opts.Limit = int(pageSize)
+	}
+	if pageToken != "" {
+		cursor, err := p.cursors.Decode(pageToken, services.FormatSort(sort))
+		if err != nil || len(cursor.After) != len(sort) {
+			violations = append(violations, violation("page_token", "is not a token of this list"))
+		}
+		opts.After = cursor.After
+	}
+	if violations != nil {
+		return opts, invalidArgument(violations...)
+	}
+	return opts, nil
+}
+
+// nextToken returns the token of the page after the one ending at next, empty on the last page
+func (p pager) nextToken(next []*string, sort []services.SortKey) string {
+	if next == nil {
+		return ""
+	}
+	return p.cursors.Encode(pagination.Cursor{Sort: services.FormatSort(sort), After: next})
+}
+
+// eqFilter filters on column when the optional field value is set
+func eqFilter[T any](filters []services.Filter, column string, value *T) []services.Filter {
+	if value == nil {
+		return filters
+	}
+	return append(filters, services.Filter{Column: column, Operator: services.OpEq, Value: *value})
+}
+
+// parseID reads the UUID in the request field named field
+func parseID(field, raw string) (uuid.UUID, error) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, invalidArgument(violation(field, "must be a UUID"))
+	}
+	return id, nil
+}
+
+// parseOptionalID reads an optional UUID field, nil when it is not set
+func parseOptionalID(field string, raw *string) (*uuid.UUID, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	id, err := parseID(field, *raw)
+	return &id, err
+}
+
+func violation(field, description string) *errdetails.BadRequest_FieldViolation {
+	return &errdetails.BadRequest_FieldViolation{Field: field, Description: description}
+}
+
+func invalidArgument(violations ...*errdetails.BadRequest_FieldViolation) error {
+	return coded(codes.InvalidArgument, utils.CodeValidationFailed, "The request has invalid fields", violations...)
+}
+
+// protoFields names the request fields whose JSON name in package models differs
+var protoFields = map[string]string{"difficulty_level": "difficulty"}
+
+// validationError reports the field errors of package validation as field violations
+func validationError(errs validation.Errors) error {
+	violations := make([]*errdetails.BadRequest_FieldViolation, len(errs))
+	for i, e := range errs {
+		field := e.Field
+		if renamed, ok := protoFields[field]; ok {
+			field = renamed
+		}
+		violations[i] = violation(field, e.Message)
+	}
+	return invalidArgument(violations...)
+}
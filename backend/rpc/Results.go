@@ -0,0 +1,53 @@
+package rpc
+
+import (
+	"backend/middleware"
+	"backend/models"
+	"backend/rpc/ilangv1"
+	"context"
+)
+
+// resultServer implements ilangv1.ResultServiceServer
+type resultServer struct {
+	ilangv1.UnimplementedResultServiceServer
+	results ResultStore
+	pages   pager
+}
+
+func (s *resultServer) ListResults(ctx context.Context, req *ilangv1.ListResultsRequest) (*ilangv1.ListResultsResponse, error) {
+	userID, _ := middleware.UserID(ctx)
+	opts, err := s.pages.options(req.PageSize, req.PageToken, resultsSort)
+	if err != nil {
+		return nil, err
+	}
+	gameID, err := parseOptionalID("game_id", req.GameId)
+	if err != nil {
+		return nil, err
+	}
+	opts.Filters = eqFilter(opts.Filters, "game_id", gameID)
+
+	page, err := s.results.ListResults(ctx, userID, opts)
+	if err != nil {
+		return nil, serviceError(ctx, err, "Failed to fetch results")
+	}
+	resp := &ilangv1.ListResultsResponse{NextPageToken: s.pages.nextToken(page.Next, resultsSort)}
+	for _, result := range page.Items {
+		resp.Results = append(resp.Results, resultMessage(result))
+	}
+	return resp, nil
+}
+
+func resultMessage(r models.GameResult) *ilangv1.GameResult {
+	msg := &ilangv1.GameResult{
+		Id:             r.ID.String(),
+		UserId:         r.UserID.String(),
+		GameId:         r.GameID.String(),
+		CompletionTime: r.CompletionTime,
+		CompletedAt:    timestamp(r.CompletedAt),
+	}
+	if r.Score != nil {
+		score := int32(*r.Score)
+		msg.Score = &score
+	}
+	return msg
+}
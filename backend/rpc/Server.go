@@ -0,0 +1,136 @@
+// Package rpc serves the gRPC API defined in proto/ilang/v1 for internal
+// services. It calls the same services as the REST handlers and accepts the
+// same Supabase access tokens.
+package rpc
+
+//go:generate protoc -I ../proto --go_out=. --go_opt=module=backend/rpc --go-grpc_out=. --go-grpc_opt=module=backend/rpc ilang/v1/ilang.proto
+
+import (
+	"backend/models"
+	"backend/pagination"
+	"backend/rpc/ilangv1"
+	"backend/services"
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// GameStore manages the games of a user
+type GameStore interface {
+	FetchGames(ctx context.Context, userID uuid.UUID, opts services.ListOptions) (services.Page[models.Game], error)
+	FetchGameByID(ctx context.Context, gameID, userID uuid.UUID) (models.Game, error)
+	CreateGame(ctx context.Context, game models.GameRequest) (models.Game, error)
+	UpdateGameByID(ctx context.Context, gameID, userID uuid.UUID, updateData models.GameRequest, ifMatch []int64) (models.Game, error)
+	DeleteGameByID(ctx context.Context, gameID, userID uuid.UUID, ifMatch []int64) error
+}
+
+// UserStore reads user accounts
+type UserStore interface {
+	GetUserByID(ctx context.Context, userID uuid.UUID) (models.User, error)
+	ListUsers(ctx context.Context, opts services.ListOptions) (services.Page[models.User], error)
+}
+
+// ResultStore reads the game results of a user
+type ResultStore interface {
+	ListResults(ctx context.Context, userID uuid.UUID, opts services.ListOptions) (services.Page[models.GameResult], error)
+}
+
+// Services are the services the RPCs call
+type Services struct {
+	Games   GameStore
+	Users   UserStore
+	Results ResultStore
+}
+
+// Options configure a Server
+type Options struct {
+	JWTSecret       string
+	Reflection      bool        // serve the reflection service
+	TLS             *tls.Config // serve TLS when set
+	DefaultPageSize int         // page size of a list call without one
+	MaxPageSize     int
+	ShutdownTimeout time.Duration // how long in-flight calls may drain
+}
+
+// Server is the gRPC server with the API, health and reflection services
+type Server struct {
+	grpc   *grpc.Server
+	health *health.Server
+	opts   Options
+}
+
+// New registers the services of the API. Page tokens are signed with
+// cursors, so they are interchangeable with the REST list cursors.
+func New(s Services, cursors *pagination.Codec, opts Options) *Server {
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(observeUnary, recoverUnary, authenticateUnary(opts.JWTSecret)),
+		grpc.ChainStreamInterceptor(observeStream, recoverStream, authenticateStream(opts.JWTSecret)),
+	}
+	if opts.TLS != nil {
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(opts.TLS)))
+	}
+
+	server := &Server{grpc: grpc.NewServer(serverOpts...), health: health.NewServer(), opts: opts}
+	pages := pager{cursors: cursors, defaultSize: opts.DefaultPageSize, maxSize: opts.MaxPageSize}
+	ilangv1.RegisterGameServiceServer(server.grpc, &gameServer{games: s.Games, pages: pages})
+	ilangv1.RegisterUserServiceServer(server.grpc, &userServer{users: s.Users, pages: pages})
+	ilangv1.RegisterResultServiceServer(server.grpc, &resultServer{results: s.Results, pages: pages})
+
+	healthpb.RegisterHealthServer(server.grpc, server.health)
+	for name := range server.grpc.GetServiceInfo() {
+		server.health.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+	if opts.Reflection {
+		reflection.Register(server.grpc)
+	}
+	return server
+}
+
+// Run listens on addr and serves until ctx is cancelled, then drains in-flight
+// calls for up to the shutdown timeout. It returns nil after a clean shutdown.
+func (s *Server) Run(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve is Run on an existing listener
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- s.grpc.Serve(ln) }()
+	slog.Info("gRPC server is running", "addr", ln.Addr().String(), "tls", s.opts.TLS != nil, "reflection", s.opts.Reflection)
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Health checks fail first so clients move to other instances
+	s.health.Shutdown()
+	stopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(s.opts.ShutdownTimeout):
+		s.grpc.Stop()
+		return errors.New("graceful gRPC shutdown did not finish")
+	}
+	slog.Info("gRPC server stopped")
+	return nil
+}
@@ -0,0 +1,257 @@
+package rpc
+
+import (
+	"backend/models"
+	"backend/pagination"
+	"backend/rpc/ilangv1"
+	"backend/services"
+	"backend/utils"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testSecret = "test-secret"
+
+// fakeStore serves games, users and results from memory
+type fakeStore struct {
+	games   []models.Game
+	users   []models.User
+	listed  services.ListOptions
+	created models.GameRequest
+}
+
+func (f *fakeStore) FetchGames(ctx context.Context, userID uuid.UUID, opts services.ListOptions) (services.Page[models.Game], error) {
+	f.listed = opts
+	page := services.Page[models.Game]{Items: f.games, Total: -1}
+	if opts.Limit < len(f.games) {
+		last := f.games[opts.Limit-1].ID.String()
+		page.Items, page.Next = f.games[:opts.Limit], []*string{&last, &last}
+	}
+	return page, nil
+}
+
+func (f *fakeStore) FetchGameByID(ctx context.Context, gameID, userID uuid.UUID) (models.Game, error) {
+	for _, game := range f.games {
+		if game.ID == gameID {
+			return game, nil
+		}
+	}
+	return models.Game{}, fmt.Errorf("game %s: %w", gameID, services.ErrNotFound)
+}
+
+func (f *fakeStore) CreateGame(ctx context.Context, game models.GameRequest) (models.Game, error) {
+	f.created = game
+	return models.Game{ID: uuid.New(), Title: game.Title, Difficulty: game.Difficulty}, nil
+}
+
+func (f *fakeStore) UpdateGameByID(ctx context.Context, gameID, userID uuid.UUID, update models.GameRequest, ifMatch []int64) (models.Game, error) {
+	return models.Game{ID: gameID, Title: update.Title}, nil
+}
+
+func (f *fakeStore) DeleteGameByID(ctx context.Context, gameID, userID uuid.UUID, ifMatch []int64) error {
+	return fmt.Errorf("%w: connection reset by db.internal", services.ErrUpstream)
+}
+
+func (f *fakeStore) GetUserByID(ctx context.Context, userID uuid.UUID) (models.User, error) {
+	for _, user := range f.users {
+		if user.ID == userID {
+			return user, nil
+		}
+	}
+	return models.User{}, services.ErrNotFound
+}
+
+func (f *fakeStore) ListUsers(ctx context.Context, opts services.ListOptions) (services.Page[models.User], error) {
+	return services.Page[models.User]{Items: f.users, Total: -1}, nil
+}
+
+func (f *fakeStore) ListResults(ctx context.Context, userID uuid.UUID, opts services.ListOptions) (services.Page[models.GameResult], error) {
+	f.listed = opts
+	return services.Page[models.GameResult]{Total: -1}, nil
+}
+
+// dial serves store over an in-memory listener and returns a connected client
+func dial(t *testing.T, store *fakeStore) *grpc.ClientConn {
+	t.Helper()
+	server := New(Services{Games: store, Users: store, Results: store}, pagination.NewCodec("secret"), Options{
+		JWTSecret:       testSecret,
+		Reflection:      true,
+		DefaultPageSize: 2,
+		MaxPageSize:     10,
+		ShutdownTimeout: time.Second,
+	})
+	ln := bufconn.Listen(1 << 20)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx, ln) }()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("expected a clean shutdown, got %v", err)
+		}
+	})
+	return conn
+}
+
+// withToken returns a context that sends an access token for userID and role
+func withToken(t *testing.T, userID uuid.UUID, role string) context.Context {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": userID.String(), "role": role}).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+// reason returns the stable error code in the ErrorInfo of err
+func reason(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+func TestCallsRequireAnAccessToken(t *testing.T) {
+	conn := dial(t, &fakeStore{})
+	games := ilangv1.NewGameServiceClient(conn)
+
+	_, err := games.ListGames(context.Background(), &ilangv1.ListGamesRequest{})
+	if status.Code(err) != codes.Unauthenticated || reason(err) != utils.CodeUnauthorized {
+		t.Errorf("expected Unauthenticated without a token, got %v", err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer nope")
+	_, err = games.ListGames(ctx, &ilangv1.ListGamesRequest{})
+	if status.Code(err) != codes.Unauthenticated || reason(err) != utils.CodeInvalidToken {
+		t.Errorf("expected Unauthenticated for a bad token, got %v", err)
+	}
+
+	health, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: "ilang.v1.GameService"})
+	if err != nil || health.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected the health service to answer without a token, got %v %v", health, err)
+	}
+}
+
+func TestListGamesPagesWithTokens(t *testing.T) {
+	store := &fakeStore{}
+	for i := 0; i < 3; i++ {
+		store.games = append(store.games, models.Game{ID: uuid.New(), Title: "Game"})
+	}
+	games := ilangv1.NewGameServiceClient(dial(t, store))
+	ctx := withToken(t, uuid.New(), "user")
+
+	difficulty := int32(3)
+	first, err := games.ListGames(ctx, &ilangv1.ListGamesRequest{Difficulty: &difficulty})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first.Games) != 2 || first.NextPageToken == "" {
+		t.Fatalf("expected a page of 2 with a next token, got %v", first)
+	}
+	if len(store.listed.Filters) != 1 || store.listed.Filters[0].Column != "difficulty_level" || !store.listed.NoCount {
+		t.Errorf("expected a difficulty filter and no count, got %+v", store.listed)
+	}
+
+	if _, err := games.ListGames(ctx, &ilangv1.ListGamesRequest{PageToken: first.NextPageToken}); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.listed.After) != 2 {
+		t.Errorf("expected the keyset of the token, got %+v", store.listed)
+	}
+
+	_, err = games.ListGames(ctx, &ilangv1.ListGamesRequest{PageSize: 50, PageToken: "bogus"})
+	if status.Code(err) != codes.InvalidArgument || len(violations(err)) != 2 {
+		t.Errorf("expected violations for page_size and page_token, got %v", err)
+	}
+}
+
+func TestGameErrorsMapToCodes(t *testing.T) {
+	store := &fakeStore{}
+	games := ilangv1.NewGameServiceClient(dial(t, store))
+	ctx := withToken(t, uuid.New(), "user")
+
+	_, err := games.GetGame(ctx, &ilangv1.GetGameRequest{Id: uuid.NewString()})
+	if status.Code(err) != codes.NotFound || reason(err) != utils.CodeNotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+
+	_, err = games.CreateGame(ctx, &ilangv1.CreateGameRequest{Difficulty: 9})
+	fields := violations(err)
+	if status.Code(err) != codes.InvalidArgument || fields["title"] == "" || fields["difficulty"] == "" {
+		t.Errorf("expected violations for title and difficulty, got %v %v", err, fields)
+	}
+	if _, err := games.CreateGame(ctx, &ilangv1.CreateGameRequest{Title: "Verbs", Difficulty: 2}); err != nil || store.created.Title != "Verbs" {
+		t.Errorf("expected the game to be created, got %v %+v", err, store.created)
+	}
+
+	_, err = games.UpdateGame(ctx, &ilangv1.UpdateGameRequest{Id: uuid.NewString()})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for an empty update, got %v", err)
+	}
+
+	_, err = games.DeleteGame(ctx, &ilangv1.DeleteGameRequest{Id: uuid.NewString()})
+	if status.Code(err) != codes.Internal || reason(err) != utils.CodeUpstreamError || status.Convert(err).Message() != "Failed to delete game" {
+		t.Errorf("expected Internal without upstream details, got %v", err)
+	}
+}
+
+func TestOtherUsersRequireAdmin(t *testing.T) {
+	caller, other := uuid.New(), uuid.New()
+	store := &fakeStore{users: []models.User{{ID: caller, Email: "me@example.com"}, {ID: other, Email: "other@example.com"}}}
+	users := ilangv1.NewUserServiceClient(dial(t, store))
+
+	me, err := users.GetUser(withToken(t, caller, "user"), &ilangv1.GetUserRequest{})
+	if err != nil || me.Email != "me@example.com" {
+		t.Errorf("expected the caller, got %v %v", me, err)
+	}
+	_, err = users.GetUser(withToken(t, caller, "user"), &ilangv1.GetUserRequest{Id: other.String()})
+	if status.Code(err) != codes.PermissionDenied || reason(err) != utils.CodeForbidden {
+		t.Errorf("expected PermissionDenied for another user, got %v", err)
+	}
+	_, err = users.ListUsers(withToken(t, caller, "user"), &ilangv1.ListUsersRequest{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied listing users, got %v", err)
+	}
+
+	list, err := users.ListUsers(withToken(t, caller, "admin"), &ilangv1.ListUsersRequest{})
+	if err != nil || len(list.Users) != 2 {
+		t.Errorf("expected an admin to list users, got %v %v", list, err)
+	}
+}
+
+// violations returns the field violations of err by field
+func violations(err error) map[string]string {
+	fields := make(map[string]string)
+	for _, detail := range status.Convert(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, v := range badRequest.FieldViolations {
+				fields[v.Field] = v.Description
+			}
+		}
+	}
+	return fields
+}
@@ -0,0 +1,66 @@
+package rpc
+
+import (
+	"backend/middleware"
+	"backend/models"
+	"backend/rpc/ilangv1"
+	"backend/utils"
+	"context"
+
+	"google.golang.org/grpc/codes"
+)
+
+// userServer implements ilangv1.UserServiceServer. Only administrators may read other users.
+type userServer struct {
+	ilangv1.UnimplementedUserServiceServer
+	users UserStore
+	pages pager
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *ilangv1.GetUserRequest) (*ilangv1.User, error) {
+	userID, _ := middleware.UserID(ctx)
+	if req.Id != "" {
+		id, err := parseID("id", req.Id)
+		if err != nil {
+			return nil, err
+		}
+		if id != userID && middleware.Role(ctx) != "admin" {
+			return nil, errForbidden
+		}
+		userID = id
+	}
+
+	user, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, serviceError(ctx, err, "Failed to fetch user", "user_id", userID)
+	}
+	return userMessage(user), nil
+}
+
+func (s *userServer) ListUsers(ctx context.Context, req *ilangv1.ListUsersRequest) (*ilangv1.ListUsersResponse, error) {
+	if middleware.Role(ctx) != "admin" {
+		return nil, errForbidden
+	}
+	opts, err := s.pages.options(req.PageSize, req.PageToken, usersSort)
+	if err != nil {
+		return nil, err
+	}
+	opts.Filters = eqFilter(opts.Filters, "role", req.Role)
+
+	page, err := s.users.ListUsers(ctx, opts)
+	if err != nil {
+		return nil, serviceError(ctx, err, "Failed to fetch users")
+	}
+	resp := &ilangv1.ListUsersResponse{NextPageToken: s.pages.nextToken(page.Next, usersSort)}
+	for _, user := range page.Items {
+		resp.Users = append(resp.Users, userMessage(user))
+	}
+	return resp, nil
+}
+
+// errForbidden is returned to callers without the admin role
+var errForbidden = coded(codes.PermissionDenied, utils.CodeForbidden, "Forbidden")
+
+func userMessage(u models.User) *ilangv1.User {
+	return &ilangv1.User{Id: u.ID.String(), Email: u.Email, Role: u.Role, CreatedAt: timestamp(u.CreatedAt)}
+}
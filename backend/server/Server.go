@@ -0,0 +1,172 @@
+package server
+
+import (
+	"backend/config"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Defaults applied to zero values in config.ServerConfig
+const (
+	DefaultAddr              = ":8080"
+	DefaultReadTimeout       = 15 * time.Second
+	DefaultReadHeaderTimeout = 5 * time.Second
+	DefaultWriteTimeout      = 30 * time.Second
+	DefaultIdleTimeout       = 120 * time.Second
+	DefaultShutdownTimeout   = 20 * time.Second
+	DefaultMaxHeaderBytes    = 1 << 20
+)
+
+// Server is the production HTTP server: it applies timeouts, listens on TCP
+// or a Unix socket, optionally serves TLS and drains gracefully on shutdown
+type Server struct {
+	cfg        config.ServerConfig
+	httpServer *http.Server
+	certs      *certReloader
+	onShutdown []func()
+}
+
+// New creates a server for handler. When TLS files are configured the
+// certificate is loaded immediately so a bad key pair fails at startup.
+func New(cfg config.ServerConfig, handler http.Handler) (*Server, error) {
+	cfg = withDefaults(cfg)
+
+	s := &Server{
+		cfg: cfg,
+		httpServer: &http.Server{
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			MaxHeaderBytes:    cfg.MaxHeaderBytes,
+			ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+		},
+	}
+
+	if cfg.TLSCertFile != "" || cfg.TLSKeyFile != "" {
+		if cfg.TLSCertFile == "" || cfg.TLSKeyFile == "" {
+			return nil, errors.New("both TLS_CERT_FILE and TLS_KEY_FILE must be set to enable TLS")
+		}
+		certs, err := newCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		s.certs = certs
+		s.httpServer.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certs.GetCertificate,
+		}
+	}
+
+	return s, nil
+}
+
+// TLSConfig returns the TLS configuration of the server, nil when it serves
+// plain HTTP. Other listeners may use it to serve the same certificate.
+func (s *Server) TLSConfig() *tls.Config {
+	return s.httpServer.TLSConfig
+}
+
+// OnShutdown registers f to run as soon as shutdown begins, before the drain
+// delay and before in-flight requests are drained, e.g. to fail readiness checks
+func (s *Server) OnShutdown(f func()) {
+	s.onShutdown = append(s.onShutdown, f)
+}
+
+// Run listens and serves until ctx is cancelled, then stops accepting new
+// connections and waits up to the shutdown timeout for in-flight requests.
+// It returns nil after a clean shutdown.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := s.listen()
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve is Run on an existing listener
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		if s.certs != nil {
+			serveErr <- s.httpServer.ServeTLS(ln, "", "")
+		} else {
+			serveErr <- s.httpServer.Serve(ln)
+		}
+	}()
+	slog.Info("Server is running", "addr", ln.Addr().String(), "network", ln.Addr().Network(), "tls", s.certs != nil)
+
+	select {
+	case err := <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down, draining in-flight requests", "timeout", s.cfg.ShutdownTimeout)
+	for _, f := range s.onShutdown {
+		f()
+	}
+	// Keep serving while load balancers notice the failing readiness probe
+	if s.cfg.DrainDelay > 0 {
+		time.Sleep(s.cfg.DrainDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
+		s.httpServer.Close()
+		return fmt.Errorf("graceful shutdown did not finish: %w", err)
+	}
+	slog.Info("Server stopped")
+	return nil
+}
+
+func (s *Server) listen() (net.Listener, error) {
+	if s.cfg.SocketPath == "" {
+		return net.Listen("tcp", s.cfg.Addr)
+	}
+
+	// Remove a socket left behind by a previous process that did not exit cleanly
+	if info, err := os.Stat(s.cfg.SocketPath); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(s.cfg.SocketPath); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+	return net.Listen("unix", s.cfg.SocketPath)
+}
+
+func withDefaults(cfg config.ServerConfig) config.ServerConfig {
+	if cfg.Addr == "" {
+		cfg.Addr = DefaultAddr
+	}
+	if cfg.ReadTimeout == 0 {
+		cfg.ReadTimeout = DefaultReadTimeout
+	}
+	if cfg.ReadHeaderTimeout == 0 {
+		cfg.ReadHeaderTimeout = DefaultReadHeaderTimeout
+	}
+	if cfg.WriteTimeout == 0 {
+		cfg.WriteTimeout = DefaultWriteTimeout
+	}
+	if cfg.IdleTimeout == 0 {
+		cfg.IdleTimeout = DefaultIdleTimeout
+	}
+	if cfg.ShutdownTimeout == 0 {
+		cfg.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if cfg.MaxHeaderBytes == 0 {
+		cfg.MaxHeaderBytes = DefaultMaxHeaderBytes
+	}
+	return cfg
+}
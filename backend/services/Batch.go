@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// maxBatchIDs caps the IDs of one in=(...) filter so the request URL stays short.
+// Larger batches are split into several requests.
+const maxBatchIDs = 100
+
+// selectByIDs fetches the rows of the query built by base whose column is one
+// of ids, in any order. IDs matching no row are left out.
+func selectByIDs[T any](ctx context.Context, c *SupabaseClient, base func() *Query, column string, ids []uuid.UUID) ([]T, error) {
+	var rows []T
+	for start := 0; start < len(ids); start += maxBatchIDs {
+		chunk := ids[start:min(start+maxBatchIDs, len(ids))]
+		values := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			values[i] = id
+		}
+		page, err := selectRows[T](ctx, c, base().In(column, values...))
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, page...)
+	}
+	return rows, nil
+}
+
+// selectRows fetches every row matching query
+func selectRows[T any](ctx context.Context, c *SupabaseClient, query *Query) ([]T, error) {
+	endpoint, err := query.URL(c.cfg.SupabaseURL)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.setTableHeaders(req)
+
+	resp, err := c.do(req, query.Table(), "select")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse("postgrest", resp, body); err != nil {
+		return nil, err
+	}
+	var rows []T
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"backend/config"
+
+	"github.com/google/uuid"
+)
+
+func TestSelectByIDsSplitsLargeBatches(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		query := r.URL.Query()
+		if query.Get("user_id") != "eq.00000000-0000-0000-0000-000000000001" {
+			t.Errorf("expected the base filter, got %s", r.URL.RawQuery)
+		}
+		ids := strings.Split(strings.TrimSuffix(strings.TrimPrefix(query.Get("id"), "in.("), ")"), ",")
+		if len(ids) > maxBatchIDs {
+			t.Errorf("expected at most %d IDs per request, got %d", maxBatchIDs, len(ids))
+		}
+		w.Write([]byte(`[{"id":"` + strings.Trim(ids[0], `"`) + `","title":"first of batch"}]`))
+	}))
+	defer server.Close()
+
+	ids := make([]uuid.UUID, maxBatchIDs+1)
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+	games := NewGameService(NewSupabaseClient(config.Config{SupabaseURL: server.URL}))
+	rows, err := games.FetchGamesByIDs(context.Background(), uuid.MustParse("00000000-0000-0000-0000-000000000001"), ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 2 || len(rows) != 2 || rows[1].ID != ids[maxBatchIDs] {
+		t.Errorf("expected one request per %d IDs, got %d requests and rows %+v", maxBatchIDs, requests.Load(), rows)
+	}
+}
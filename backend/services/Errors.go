@@ -0,0 +1,162 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Domain errors returned by the services. Handlers map them to HTTP statuses
+// with errors.Is, so the underlying PostgREST or GoTrue detail stays wrapped.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrUpstream     = errors.New("upstream failure")
+
+	// ErrPreconditionFailed is returned by conditional writes to a row that
+	// is no longer at one of the expected versions
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// UpstreamError is an error response from Supabase, classified into one of the domain errors
+type UpstreamError struct {
+	Kind    error  // ErrNotFound, ErrConflict, ErrValidation, ErrUnauthorized or ErrUpstream
+	Service string // "postgrest" or "gotrue"
+	Status  int
+	Code    string // Postgres/PostgREST code such as 23505, or GoTrue error_code such as email_exists
+	Message string
+}
+
+func (e *UpstreamError) Error() string {
+	msg := fmt.Sprintf("%s: %s returned %d", e.Kind, e.Service, e.Status)
+	if e.Code != "" {
+		msg += " (" + e.Code + ")"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Kind
+}
+
+// postgrestCodes classifies Postgres and PostgREST error codes
+var postgrestCodes = map[string]error{
+	"23505":    ErrConflict,     // unique_violation
+	"23503":    ErrValidation,   // foreign_key_violation
+	"23502":    ErrValidation,   // not_null_violation
+	"23514":    ErrValidation,   // check_violation
+	"22P02":    ErrValidation,   // invalid_text_representation, e.g. a malformed uuid
+	"22001":    ErrValidation,   // string_data_right_truncation
+	"22003":    ErrValidation,   // numeric_value_out_of_range
+	"22007":    ErrValidation,   // invalid_datetime_format
+	"22008":    ErrValidation,   // datetime_field_overflow
+	"42501":    ErrUnauthorized, // insufficient_privilege, e.g. a row level security denial
+	"PGRST116": ErrNotFound,     // singular response with no rows
+	"PGRST301": ErrUnauthorized, // JWT invalid
+	"PGRST302": ErrUnauthorized, // anonymous access disabled
+	// Schema mismatches are bugs on our side, not bad input
+	"42703":    ErrUpstream, // undefined_column
+	"42P01":    ErrUpstream, // undefined_table
+	"PGRST204": ErrUpstream, // column not found in the schema cache
+}
+
+// gotrueCodes classifies GoTrue error_code values
+var gotrueCodes = map[string]error{
+	"email_exists":          ErrConflict,
+	"user_already_exists":   ErrConflict,
+	"phone_exists":          ErrConflict,
+	"weak_password":         ErrValidation,
+	"validation_failed":     ErrValidation,
+	"email_address_invalid": ErrValidation,
+	"bad_json":              ErrValidation,
+	"invalid_credentials":   ErrUnauthorized,
+	"invalid_grant":         ErrUnauthorized,
+	"bad_jwt":               ErrUnauthorized,
+	"no_authorization":      ErrUnauthorized,
+	"not_admin":             ErrUnauthorized,
+	"user_not_found":        ErrNotFound,
+}
+
+// upstreamBody covers the error shapes of PostgREST ({code, message}) and
+// GoTrue ({error_code, msg} or the older OAuth style {error, error_description})
+type upstreamBody struct {
+	Code             json.RawMessage `json:"code"`
+	Message          string          `json:"message"`
+	ErrorCode        string          `json:"error_code"`
+	Msg              string          `json:"msg"`
+	Error            string          `json:"error"`
+	ErrorDescription string          `json:"error_description"`
+}
+
+// newUpstreamError classifies a non-2xx Supabase response by its error code,
+// falling back to the HTTP status when the code is unknown
+func newUpstreamError(service string, status int, body []byte) *UpstreamError {
+	e := &UpstreamError{Service: service, Status: status}
+
+	var parsed upstreamBody
+	if json.Unmarshal(body, &parsed) == nil {
+		// PostgREST codes are strings, GoTrue repeats the HTTP status as a number
+		var code string
+		if json.Unmarshal(parsed.Code, &code) == nil {
+			e.Code = code
+		}
+		for _, c := range []string{parsed.ErrorCode, parsed.Error} {
+			if e.Code == "" {
+				e.Code = c
+			}
+		}
+		for _, m := range []string{parsed.Message, parsed.Msg, parsed.ErrorDescription} {
+			if e.Message == "" {
+				e.Message = m
+			}
+		}
+	} else if len(body) > 0 {
+		e.Message = string(body)
+	}
+
+	codes := postgrestCodes
+	if service == "gotrue" {
+		codes = gotrueCodes
+	}
+	if kind, ok := codes[e.Code]; ok {
+		e.Kind = kind
+		return e
+	}
+	e.Kind = kindForStatus(status)
+	return e
+}
+
+func kindForStatus(status int) error {
+	switch status {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return ErrValidation
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict:
+		return ErrConflict
+	default:
+		return ErrUpstream
+	}
+}
+
+// checkResponse returns nil for a 2xx status, otherwise the classified upstream error
+func checkResponse(service string, resp *http.Response, body []byte) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	return newUpstreamError(service, resp.StatusCode, body)
+}
+
+// notFound wraps ErrNotFound with the resource that was looked up
+func notFound(resource, id string) error {
+	return fmt.Errorf("%s %s: %w", resource, strconv.Quote(id), ErrNotFound)
+}
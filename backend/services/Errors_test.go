@@ -0,0 +1,68 @@
+package services
+
+import (
+	"backend/config"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNewUpstreamErrorClassifies(t *testing.T) {
+	cases := []struct {
+		name    string
+		service string
+		status  int
+		body    string
+		want    error
+		code    string
+	}{
+		{"duplicate row", "postgrest", 409, `{"code":"23505","message":"duplicate key value violates unique constraint"}`, ErrConflict, "23505"},
+		{"malformed uuid", "postgrest", 400, `{"code":"22P02","message":"invalid input syntax for type uuid"}`, ErrValidation, "22P02"},
+		{"no rows", "postgrest", 406, `{"code":"PGRST116","message":"JSON object requested, multiple (or no) rows returned"}`, ErrNotFound, "PGRST116"},
+		{"rls denial", "postgrest", 403, `{"code":"42501","message":"new row violates row-level security policy"}`, ErrUnauthorized, "42501"},
+		{"unknown column", "postgrest", 400, `{"code":"42703","message":"column games.foo does not exist"}`, ErrUpstream, "42703"},
+		{"email taken", "gotrue", 422, `{"code":422,"error_code":"email_exists","msg":"Email address already registered"}`, ErrConflict, "email_exists"},
+		{"weak password", "gotrue", 422, `{"code":422,"error_code":"weak_password","msg":"Password should be at least 6 characters"}`, ErrValidation, "weak_password"},
+		{"oauth style", "gotrue", 400, `{"error":"invalid_grant","error_description":"Invalid login credentials"}`, ErrUnauthorized, "invalid_grant"},
+		{"gateway", "postgrest", 502, `<html>Bad Gateway</html>`, ErrUpstream, ""},
+		{"user missing", "gotrue", 404, `{"code":404,"error_code":"user_not_found","msg":"User not found"}`, ErrNotFound, "user_not_found"},
+	}
+	for _, c := range cases {
+		err := newUpstreamError(c.service, c.status, []byte(c.body))
+		if !errors.Is(err, c.want) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, err.Kind)
+		}
+		if err.Code != c.code {
+			t.Errorf("%s: expected code %q, got %q", c.name, c.code, err.Code)
+		}
+	}
+}
+
+func TestCreateUserReportsDuplicateEmail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"code":422,"error_code":"email_exists","msg":"Email address already registered"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewUserService(NewSupabaseClient(config.Config{SupabaseURL: server.URL})).CreateUser(context.Background(), "a@b.c", "secret123")
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("expected ErrConflict, got %v", err)
+	}
+}
+
+func TestFetchGameByIDReportsMissingGame(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	_, err := NewGameService(NewSupabaseClient(config.Config{SupabaseURL: server.URL})).FetchGameByID(context.Background(), uuid.New(), uuid.New())
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -1,75 +1,68 @@
 package services
 
 import (
-	"backend/config"
+	"backend/metrics"
 	"backend/models"
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+
+	"github.com/google/uuid"
 )
 
-// FetchGames retrieves a list of games from the Supabase database
-func FetchGames(userID string) ([]models.Game, error) {
-	cfg := config.LoadConfig()
-	// Define the Supabase REST API URL for the games table for a specific user
-	url := fmt.Sprintf("%s/rest/v1/games?user_id=eq.%s", cfg.SupabaseURL, userID)
+// GameService reads and writes the games table through the Supabase REST API
+type GameService struct {
+	*SupabaseClient
+}
 
-	// Create a new HTTP request
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	// Add required headers for Supabase API
-	req.Header.Set("apikey", cfg.SupabaseKey)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", cfg.SupabaseKey))
-	// Send the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	// Check for non-200 status codes
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.New("failed to fetch games from Supabase")
-	}
-	// Parse the response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	var games []models.Game
-	if err := json.Unmarshal(body, &games); err != nil {
-		return nil, err
-	}
-	return games, nil
+// NewGameService creates a GameService that calls Supabase through client
+func NewGameService(client *SupabaseClient) *GameService {
+	return &GameService{client}
 }
 
-// CreateGame creates a new game in the Supabase database
-func CreateGame(title, description, subjectID string, difficulty int) (models.Game, error) {
-	cfg := config.LoadConfig()
+// FetchGames retrieves one page of the games of a user
+func (s *GameService) FetchGames(ctx context.Context, userID uuid.UUID, opts ListOptions) (Page[models.Game], error) {
+	return listPage[models.Game](ctx, s.SupabaseClient, func() *Query {
+		return live(From("games").Eq("user_id", userID))
+	}, opts)
+}
 
-	// Define the Supabase REST API URL for the games table
-	url := fmt.Sprintf("%s/rest/v1/games", cfg.SupabaseURL)
+// FetchGamesByIDs retrieves the games of a user with the given IDs in one
+// request per 100 IDs. IDs of missing, trashed and other users' games are left out.
+func (s *GameService) FetchGamesByIDs(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]models.Game, error) {
+	return selectByIDs[models.Game](ctx, s.SupabaseClient, func() *Query {
+		return live(From("games").Eq("user_id", userID))
+	}, "id", ids)
+}
+
+// CreateGame creates a new game in the Supabase database. Fields left empty take their column defaults.
+func (s *GameService) CreateGame(ctx context.Context, game models.GameRequest) (models.Game, error) {
+	cfg := s.cfg
 
-	// Prepare the request body
-	gameData := map[string]interface{}{
-		"title":            title,
-		"description":      description,
-		"subject_id":       subjectID,
-		"difficulty_level": difficulty,
+	// Define the Supabase REST API URL for the games table
+	url, err := From("games").URL(cfg.SupabaseURL)
+	if err != nil {
+		return models.Game{}, err
 	}
-	body, err := json.Marshal(gameData)
+
+	// Prepare the request body. The game's ID is also its external key, so an
+	// export of it imported again updates it instead of creating a copy.
+	id := uuid.New()
+	body, err := json.Marshal(struct {
+		models.GameRequest
+		ID          uuid.UUID `json:"id"`
+		ExternalKey string    `json:"external_key"`
+	}{game, id, id.String()})
 	if err != nil {
 		return models.Game{}, err
 	}
 
 	// Create a new HTTP request
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	req, err := s.newRequest(ctx, "POST", url, bytes.NewBuffer(body))
 	if err != nil {
 		return models.Game{}, err
 	}
@@ -81,8 +74,7 @@ func CreateGame(title, description, subjectID string, difficulty int) (models.Ga
 	req.Header.Set("Prefer", "return=representation") // Ensures Supabase returns the created row
 
 	// Send the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.do(req, "games", "insert")
 	if err != nil {
 		return models.Game{}, err
 	}
@@ -91,43 +83,45 @@ func CreateGame(title, description, subjectID string, difficulty int) (models.Ga
 	// Read the response body
 	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Printf("Error reading response body: %v\n", err)
 		return models.Game{}, fmt.Errorf("failed to read Supabase response: %v", err)
 	}
 
-	// Log the raw response for debugging
-	log.Printf("Response Body: %s\n", string(responseBody))
+	slog.DebugContext(ctx, "supabase create game response", "status", resp.StatusCode, "bytes", len(responseBody))
 
-	// Check for non-201 status codes
-	if resp.StatusCode != http.StatusCreated {
-		return models.Game{}, fmt.Errorf("failed to create game in Supabase: %s", string(responseBody))
+	// Check for non-2xx status codes
+	if err := checkResponse("postgrest", resp, responseBody); err != nil {
+		return models.Game{}, err
 	}
 
 	// Decode the response body into a slice of Game objects
 	var createdGames []models.Game
 	if len(responseBody) > 0 {
 		if err := json.Unmarshal(responseBody, &createdGames); err != nil {
-			log.Printf("Error decoding response body: %v\n", err)
 			return models.Game{}, fmt.Errorf("failed to decode Supabase response: %v", err)
 		}
 	}
 
 	// Ensure at least one game is returned
 	if len(createdGames) == 0 {
-		return models.Game{}, fmt.Errorf("no game created, empty response from Supabase")
+		return models.Game{}, fmt.Errorf("no game created, empty response from Supabase: %w", ErrUpstream)
 	}
 
+	metrics.GamesCreated.Inc()
+
 	// Return the first game from the array
 	return createdGames[0], nil
 }
 
 // FetchGameByID retrieves a single game by its ID from the Supabase database
-func FetchGameByID(gameID string, userID string) (models.Game, error) {
-	cfg := config.LoadConfig()
+func (s *GameService) FetchGameByID(ctx context.Context, gameID, userID uuid.UUID) (models.Game, error) {
+	cfg := s.cfg
 	// Define the Supabase REST API URL for the games table
-	url := fmt.Sprintf("%s/rest/v1/games?id=eq.%s&user_id=eq.%s", cfg.SupabaseURL, gameID, userID)
+	url, err := live(From("games").Eq("id", gameID).Eq("user_id", userID)).URL(cfg.SupabaseURL)
+	if err != nil {
+		return models.Game{}, err
+	}
 	// Create a new HTTP request
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := s.newRequest(ctx, "GET", url, nil)
 	if err != nil {
 		return models.Game{}, err
 	}
@@ -135,39 +129,41 @@ func FetchGameByID(gameID string, userID string) (models.Game, error) {
 	req.Header.Set("apikey", cfg.SupabaseKey)
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", cfg.SupabaseKey))
 	// Send the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.do(req, "games", "select")
 	if err != nil {
 		return models.Game{}, err
 	}
 	defer resp.Body.Close()
-	// Check for non-200 status codes
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return models.Game{}, fmt.Errorf("failed to fetch game: %s", string(body))
-	}
 	// Parse the response body
 	var games []models.Game
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return models.Game{}, err
 	}
+	if err := checkResponse("postgrest", resp, body); err != nil {
+		return models.Game{}, err
+	}
 	if err := json.Unmarshal(body, &games); err != nil {
 		return models.Game{}, err
 	}
-	// Ensure the game exists
+	// A game in the trash or owned by another user is reported as missing too
 	if len(games) == 0 {
-		return models.Game{}, errors.New("game not found or not owned by user")
+		return models.Game{}, notFound("game", gameID.String())
 	}
 	return games[0], nil
 }
 
-// UpdateGameByID updates a game in the Supabase database by its ID
-func UpdateGameByID(gameID string, userID string, updateData models.GameRequest) (models.Game, error) {
-	cfg := config.LoadConfig()
+// UpdateGameByID updates a game in the Supabase database by its ID. With
+// ifMatch it only updates a game at one of those versions and fails with
+// ErrPreconditionFailed for a game at another version.
+func (s *GameService) UpdateGameByID(ctx context.Context, gameID, userID uuid.UUID, updateData models.GameRequest, ifMatch []int64) (models.Game, error) {
+	cfg := s.cfg
 
 	// Define the Supabase REST API URL for the games table
-	url := fmt.Sprintf("%s/rest/v1/games?id=eq.%s&user_id=eq.%s", cfg.SupabaseURL, gameID, userID)
+	url, err := matchVersions(live(From("games").Eq("id", gameID).Eq("user_id", userID)), ifMatch).URL(cfg.SupabaseURL)
+	if err != nil {
+		return models.Game{}, err
+	}
 
 	// Marshal the update data into JSON
 	body, err := json.Marshal(updateData)
@@ -176,7 +172,7 @@ func UpdateGameByID(gameID string, userID string, updateData models.GameRequest)
 	}
 
 	// Create a new HTTP request
-	req, err := http.NewRequest("PATCH", url, bytes.NewBuffer(body))
+	req, err := s.newRequest(ctx, "PATCH", url, bytes.NewBuffer(body))
 	if err != nil {
 		return models.Game{}, err
 	}
@@ -188,66 +184,52 @@ func UpdateGameByID(gameID string, userID string, updateData models.GameRequest)
 	req.Header.Set("Prefer", "return=representation") // Ensures Supabase returns the updated row
 
 	// Send the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.do(req, "games", "update")
 	if err != nil {
 		return models.Game{}, err
 	}
 	defer resp.Body.Close()
 
-	// Check for non-200 status codes
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return models.Game{}, fmt.Errorf("failed to update game: %s", string(body))
-	}
-
 	// Parse the response body
 	var updatedGames []models.Game
 	body, err = io.ReadAll(resp.Body)
 	if err != nil {
 		return models.Game{}, err
 	}
+	if err := checkResponse("postgrest", resp, body); err != nil {
+		return models.Game{}, err
+	}
 	if err := json.Unmarshal(body, &updatedGames); err != nil {
 		return models.Game{}, err
 	}
 
-	// Ensure the game exists
+	// No row matched the game ID, owner and versions
 	if len(updatedGames) == 0 {
-		return models.Game{}, errors.New("no game updated, empty response from Supabase")
+		return models.Game{}, s.missedGameWrite(ctx, gameID, userID, ifMatch)
 	}
 
 	return updatedGames[0], nil
 }
 
-// DeleteGameByID deletes a game by its ID from the Supabase database
-func DeleteGameByID(gameID string, userID string) error {
-	cfg := config.LoadConfig()
-
-	// Define the Supabase REST API URL for the games table
-	url := fmt.Sprintf("%s/rest/v1/games?id=eq.%s&user_id=eq.%s", cfg.SupabaseURL, gameID, userID)
-
-	// Create a new HTTP request
-	req, err := http.NewRequest("DELETE", url, nil)
-	if err != nil {
+// DeleteGameByID moves a game to the trash. ifMatch makes the delete
+// conditional like in UpdateGameByID.
+func (s *GameService) DeleteGameByID(ctx context.Context, gameID, userID uuid.UUID, ifMatch []int64) error {
+	var deleted []models.Game
+	query := matchVersions(live(From("games").Eq("id", gameID).Eq("user_id", userID)), ifMatch)
+	if err := s.callTable(ctx, http.MethodPatch, query, trashPatch(), &deleted); err != nil {
 		return err
 	}
-
-	// Add required headers for Supabase API
-	req.Header.Set("apikey", cfg.SupabaseKey)
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", cfg.SupabaseKey))
-
-	// Send the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
+	// No row matched the game ID, owner and versions
+	if len(deleted) == 0 {
+		return s.missedGameWrite(ctx, gameID, userID, ifMatch)
 	}
-	defer resp.Body.Close()
-
-	// Check for non-200 status codes
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return errors.New("failed to delete game from Supabase")
-	}
-
 	return nil
-}
\ No newline at end of file
+}
+
+// missedGameWrite explains a conditional write that matched no game
+func (s *GameService) missedGameWrite(ctx context.Context, gameID, userID uuid.UUID, ifMatch []int64) error {
+	return missedWrite("game", gameID, ifMatch, func() (int64, error) {
+		game, err := s.FetchGameByID(ctx, gameID, userID)
+		return game.Version, err
+	})
+}
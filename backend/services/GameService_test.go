@@ -1,25 +1,79 @@
 package services
 
 import (
-	"log"
+	"backend/config"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+
+	"github.com/google/uuid"
 )
 
 func TestFetchGames(t *testing.T) {
-	userID := "5803acaf-821a-4463-b8b4-15ac6e0e466a"
+	userID := uuid.MustParse("5803acaf-821a-4463-b8b4-15ac6e0e466a")
 	// Mock the HTTP response from Supabase
-	// server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-	// 	w.WriteHeader(http.StatusOK)
-	// 	w.Write([]byte(`[{"id":"1","title":"Game 1"}]`))
-	// }))
-	// defer server.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/v1/games" || r.URL.Query().Get("user_id") != "eq."+userID.String() {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		if r.Header.Get("apikey") != "anon-key" {
+			t.Errorf("expected apikey header, got %q", r.Header.Get("apikey"))
+		}
+		if got := r.URL.Query().Get("order"); got != "created_at.desc.nullslast,id.desc.nullslast" {
+			t.Errorf("unexpected order %q", got)
+		}
+		if got := r.URL.Query().Get("limit"); got != "2" {
+			t.Errorf("expected one extra row to be requested, got limit %q", got)
+		}
+		w.Header().Set("Content-Range", "0-1/7")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[
+			{"id":"0b9a3c1e-6f1d-4f7e-9a59-2f0c8b1d7e01","title":"Game 1","subject_id":null,"created_at":"2024-11-22T10:04:05.123456"},
+			{"id":"0b9a3c1e-6f1d-4f7e-9a59-2f0c8b1d7e02","title":"Game 2","subject_id":"8c4f6a2b-1d3e-4f5a-9b7c-0d1e2f3a4b5c","created_at":"2024-11-22T10:04:05+00:00"}
+		]`))
+	}))
+	defer server.Close()
 
-	games, err := FetchGames(userID)
+	cfg := config.Config{SupabaseURL: server.URL, SupabaseKey: "anon-key"}
+	opts := ListOptions{Sort: []SortKey{{"created_at", true}, {"id", true}}, Limit: 1}
+	page, err := NewGameService(NewSupabaseClient(cfg)).FetchGames(context.Background(), userID, opts)
 	if err != nil {
 		t.Fatalf("FetchGames failed: %v", err)
 	}
-	log.Println(games)
-	if len(games) != 2 {
-		t.Errorf("FetchGames failed: %v", err)
+	if len(page.Items) != 1 || page.Total != 7 {
+		t.Fatalf("expected 1 of 7 games, got %d of %d", len(page.Items), page.Total)
+	}
+	if game := page.Items[0]; game.SubjectID != nil || game.CreatedAt.IsZero() {
+		t.Errorf("unexpected decoded game %+v", game)
+	}
+	if len(page.Next) != 2 || *page.Next[0] != "2024-11-22T10:04:05.123456" || *page.Next[1] != "0b9a3c1e-6f1d-4f7e-9a59-2f0c8b1d7e01" {
+		t.Errorf("expected the keyset of the last returned game, got %v", page.Next)
+	}
+}
+
+func TestFetchGamesReusesConnections(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			connections.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	service := NewGameService(NewSupabaseClient(config.Config{SupabaseURL: server.URL}))
+	for i := 0; i < 5; i++ {
+		if _, err := service.FetchGames(context.Background(), uuid.New(), ListOptions{Sort: []SortKey{{"id", false}}, Limit: 10}); err != nil {
+			t.Fatalf("FetchGames failed: %v", err)
+		}
+	}
+	if n := connections.Load(); n != 1 {
+		t.Errorf("expected the shared client to reuse one connection, got %d", n)
 	}
 }
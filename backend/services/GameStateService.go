@@ -0,0 +1,26 @@
+package services
+
+import (
+	"backend/models"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// GameStateService reads the game_states table through the Supabase REST API
+type GameStateService struct {
+	*SupabaseClient
+}
+
+// NewGameStateService creates a GameStateService that calls Supabase through client
+func NewGameStateService(client *SupabaseClient) *GameStateService {
+	return &GameStateService{client}
+}
+
+// FetchStates retrieves the saved states of a user in the given games. Games
+// the user has no state in and games in the trash are left out.
+func (s *GameStateService) FetchStates(ctx context.Context, userID uuid.UUID, gameIDs []uuid.UUID) ([]models.GameState, error) {
+	return selectByIDs[models.GameState](ctx, s.SupabaseClient, func() *Query {
+		return ofLiveGames(From("game_states").Eq("user_id", userID))
+	}, "game_id", gameIDs)
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"backend/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// exportPageSize is the number of rows fetched per request while exporting
+const exportPageSize = 500
+
+// ImportResult counts the games an import created and updated
+type ImportResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+}
+
+// ImportGames upserts records for a user by external key with the import_games
+// database function (see db/migrations). All records are written in one
+// transaction: on error none of them is. Records with the key of a game in
+// the trash restore it.
+func (s *GameService) ImportGames(ctx context.Context, userID uuid.UUID, records []models.GameRecord) (ImportResult, error) {
+	var result ImportResult
+
+	params := map[string]interface{}{
+		"owner_id": userID,
+		"records":  records,
+	}
+	rpcURL := fmt.Sprintf("%s/rest/v1/rpc/import_games", s.cfg.SupabaseURL)
+	headers := map[string]string{
+		"apikey":        s.cfg.SupabaseKey,
+		"Authorization": "Bearer " + s.cfg.SupabaseKey,
+		"Content-Type":  "application/json",
+	}
+	resp, err := s.call(ctx, http.MethodPost, rpcURL, params, headers)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return result, err
+	}
+	if err := checkResponse("postgrest", resp, body); err != nil {
+		return result, err
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return result, fmt.Errorf("failed to decode import result: %w", err)
+	}
+	return result, nil
+}
+
+// ExportGames calls emit with every game of a user as a bulk record, oldest
+// first. Games without an external key, which predate migration 005, are
+// exported with their ID, the key that migration gives them.
+func (s *GameService) ExportGames(ctx context.Context, userID uuid.UUID, emit func(models.GameRecord) error) error {
+	subjects, err := s.subjectNames(ctx)
+	if err != nil {
+		return err
+	}
+
+	opts := ListOptions{
+		Sort:    []SortKey{{Column: "created_at"}, {Column: "id"}},
+		Limit:   exportPageSize,
+		NoCount: true,
+	}
+	for {
+		page, err := listPage[models.Game](ctx, s.SupabaseClient, func() *Query {
+			return live(From("games").Eq("user_id", userID))
+		}, opts)
+		if err != nil {
+			return err
+		}
+		for _, game := range page.Items {
+			record := models.GameRecord{
+				ExternalKey: game.ExternalKey,
+				Title:       game.Title,
+				Description: game.Description,
+				Difficulty:  game.Difficulty,
+				Language:    game.Language,
+			}
+			if record.ExternalKey == "" {
+				record.ExternalKey = game.ID.String()
+			}
+			if game.SubjectID != nil {
+				record.Subject = subjects[*game.SubjectID]
+			}
+			if err := emit(record); err != nil {
+				return err
+			}
+		}
+		if page.Next == nil {
+			return nil
+		}
+		opts.After = page.Next
+	}
+}
+
+// subjectNames maps the ID of every subject to its name
+func (s *GameService) subjectNames(ctx context.Context) (map[uuid.UUID]string, error) {
+	names := make(map[uuid.UUID]string)
+	opts := ListOptions{Sort: []SortKey{{Column: "id"}}, Limit: exportPageSize, NoCount: true}
+	for {
+		page, err := listPage[models.Subject](ctx, s.SupabaseClient, func() *Query {
+			return From("subjects")
+		}, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, subject := range page.Items {
+			names[subject.ID] = subject.Name
+		}
+		if page.Next == nil {
+			return names, nil
+		}
+		opts.After = page.Next
+	}
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"backend/config"
+	"backend/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestImportGamesCallsRPC(t *testing.T) {
+	userID := uuid.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params struct {
+			OwnerID uuid.UUID           `json:"owner_id"`
+			Records []models.GameRecord `json:"records"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Fatal(err)
+		}
+		if r.URL.Path != "/rest/v1/rpc/import_games" || params.OwnerID != userID || len(params.Records) != 2 {
+			t.Errorf("unexpected request %s %+v", r.URL, params)
+		}
+		w.Write([]byte(`{"created":1,"updated":1}`))
+	}))
+	defer server.Close()
+
+	records := []models.GameRecord{{ExternalKey: "a", Title: "A"}, {ExternalKey: "b", Title: "B"}}
+	result, err := NewGameService(NewSupabaseClient(config.Config{SupabaseURL: server.URL})).ImportGames(context.Background(), userID, records)
+	if err != nil || result != (ImportResult{Created: 1, Updated: 1}) {
+		t.Errorf("unexpected result %+v %v", result, err)
+	}
+}
+
+func TestExportGamesPagesThroughGames(t *testing.T) {
+	subjectID := uuid.New()
+	var gameRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("exports must not count rows, got %s", r.Method)
+		}
+		if r.URL.Path == "/rest/v1/subjects" {
+			fmt.Fprintf(w, `[{"id":%q,"name":"Grammar"}]`, subjectID)
+			return
+		}
+		gameRequests++
+		// Serve exportPageSize+1 rows first so a second page is requested
+		var games []models.Game
+		n := exportPageSize + 1
+		if r.URL.Query().Get("or") != "" {
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			games = append(games, models.Game{ID: uuid.New(), Title: "Game", SubjectID: &subjectID, ExternalKey: "key"})
+		}
+		json.NewEncoder(w).Encode(games)
+	}))
+	defer server.Close()
+
+	var records []models.GameRecord
+	err := NewGameService(NewSupabaseClient(config.Config{SupabaseURL: server.URL})).ExportGames(context.Background(), uuid.New(), func(record models.GameRecord) error {
+		records = append(records, record)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gameRequests != 2 || len(records) != exportPageSize+1 {
+		t.Errorf("expected two pages with %d games, got %d requests and %d games", exportPageSize+1, gameRequests, len(records))
+	}
+	if records[0].Subject != "Grammar" || records[0].ExternalKey != "key" {
+		t.Errorf("expected subject names in the records, got %+v", records[0])
+	}
+}
+
+func TestExportedGamesImportWithoutCopies(t *testing.T) {
+	// A Supabase stand-in that stores games and upserts imports by external key like import_games
+	var games []models.Game
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/rest/v1/subjects":
+			w.Write([]byte(`[]`))
+		case r.URL.Path == "/rest/v1/games" && r.Method == http.MethodPost:
+			var game models.Game
+			json.NewDecoder(r.Body).Decode(&game)
+			games = append(games, game)
+			json.NewEncoder(w).Encode([]models.Game{game})
+		case r.URL.Path == "/rest/v1/games":
+			json.NewEncoder(w).Encode(games)
+		case r.URL.Path == "/rest/v1/rpc/import_games":
+			var params struct {
+				Records []models.GameRecord `json:"records"`
+			}
+			json.NewDecoder(r.Body).Decode(&params)
+			var result ImportResult
+		records:
+			for _, record := range params.Records {
+				for i := range games {
+					if games[i].ExternalKey == record.ExternalKey {
+						games[i].Title = record.Title
+						result.Updated++
+						continue records
+					}
+				}
+				games = append(games, models.Game{ID: uuid.New(), Title: record.Title, ExternalKey: record.ExternalKey})
+				result.Created++
+			}
+			json.NewEncoder(w).Encode(result)
+		}
+	}))
+	defer server.Close()
+
+	service := NewGameService(NewSupabaseClient(config.Config{SupabaseURL: server.URL}))
+	ctx, userID := context.Background(), uuid.New()
+	for _, title := range []string{"Verbs", "Nouns"} {
+		if _, err := service.CreateGame(ctx, models.GameRequest{Title: title}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var records []models.GameRecord
+	if err := service.ExportGames(ctx, userID, func(record models.GameRecord) error {
+		records = append(records, record)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	result, err := service.ImportGames(ctx, userID, records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 2 || result != (ImportResult{Updated: 2}) {
+		t.Errorf("expected the import to update both games, got %d games after %+v", len(games), result)
+	}
+	if games[0].ExternalKey != games[0].ID.String() {
+		t.Errorf("expected a created game to have its ID as key, got %+v", games[0])
+	}
+}
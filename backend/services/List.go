@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SortKey orders a listing by one column
+type SortKey struct {
+	Column     string
+	Descending bool
+}
+
+// FormatSort writes keys like the sort parameter of the list endpoints, e.g.
+// "-created_at,-id". Page cursors are bound to this form of their sort.
+func FormatSort(keys []SortKey) string {
+	fields := make([]string, len(keys))
+	for i, key := range keys {
+		fields[i] = key.Column
+		if key.Descending {
+			fields[i] = "-" + key.Column
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// Filter operators accepted in ListOptions
+const (
+	OpEq  = "eq"
+	OpGte = "gte"
+	OpLte = "lte"
+)
+
+// Filter narrows a listing to the rows whose column compares to Value
+type Filter struct {
+	Column   string
+	Operator string // OpEq, OpGte or OpLte
+	Value    interface{}
+}
+
+// ListOptions selects one page of a listing. Sort must end with a column
+// that is unique and never null, such as id, so the keyset is unambiguous.
+type ListOptions struct {
+	Filters []Filter
+	Sort    []SortKey
+	After   []*string // sort key values of the last row of the previous page, nil for the first page
+	Limit   int
+	NoCount bool // skip counting the matching rows; Total is -1
+}
+
+// Page is one page of a listing
+type Page[T any] struct {
+	Items []T
+	Next  []*string // sort key values of the last item, nil on the last page
+	Total int       // rows matching the filters across all pages, -1 when unknown
+}
+
+// listPage fetches one page of the rows selected by base and opts. base must
+// return a fresh query each call; it is also used to count all matching rows.
+func listPage[T any](ctx context.Context, c *SupabaseClient, base func() *Query, opts ListOptions) (Page[T], error) {
+	page := Page[T]{Total: -1}
+
+	query := applyFilters(base(), opts.Filters)
+	for _, key := range opts.Sort {
+		direction := Ascending
+		if key.Descending {
+			direction = Descending
+		}
+		query.OrderNullsLast(key.Column, direction)
+	}
+	if opts.After != nil {
+		query.After(opts.Sort, opts.After)
+	}
+	// One extra row tells whether there is a next page
+	query.Limit(opts.Limit + 1)
+
+	endpoint, err := query.URL(c.cfg.SupabaseURL)
+	if err != nil {
+		return page, err
+	}
+	req, err := c.newRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return page, err
+	}
+	c.setTableHeaders(req)
+	if opts.After == nil && !opts.NoCount {
+		// Without a keyset filter the count of this request is the total
+		req.Header.Set("Prefer", "count=exact")
+	}
+
+	resp, err := c.do(req, query.Table(), "select")
+	if err != nil {
+		return page, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return page, err
+	}
+	if err := checkResponse("postgrest", resp, body); err != nil {
+		return page, err
+	}
+
+	var rows []json.RawMessage
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return page, err
+	}
+	more := len(rows) > opts.Limit
+	if more {
+		rows = rows[:opts.Limit]
+	}
+	page.Items = make([]T, len(rows))
+	for i, row := range rows {
+		if err := json.Unmarshal(row, &page.Items[i]); err != nil {
+			return page, err
+		}
+	}
+	if more {
+		if page.Next, err = keysetOf(rows[len(rows)-1], opts.Sort); err != nil {
+			return page, err
+		}
+	}
+
+	switch {
+	case opts.NoCount:
+	case opts.After == nil:
+		page.Total = totalFromContentRange(resp.Header.Get("Content-Range"))
+	default:
+		if page.Total, err = c.count(ctx, applyFilters(base(), opts.Filters)); err != nil {
+			return page, err
+		}
+	}
+	return page, nil
+}
+
+func applyFilters(query *Query, filters []Filter) *Query {
+	for _, f := range filters {
+		switch f.Operator {
+		case OpEq:
+			query.Eq(f.Column, f.Value)
+		case OpGte:
+			query.Gte(f.Column, f.Value)
+		case OpLte:
+			query.Lte(f.Column, f.Value)
+		default:
+			query.fail(fmt.Errorf("invalid filter operator %q", f.Operator))
+		}
+	}
+	return query
+}
+
+// count returns the number of rows matching query with a HEAD request
+func (c *SupabaseClient) count(ctx context.Context, query *Query) (int, error) {
+	endpoint, err := query.Limit(0).URL(c.cfg.SupabaseURL)
+	if err != nil {
+		return 0, err
+	}
+	req, err := c.newRequest(ctx, http.MethodHead, endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+	c.setTableHeaders(req)
+	req.Header.Set("Prefer", "count=exact")
+
+	resp, err := c.do(req, query.Table(), "count")
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse("postgrest", resp, nil); err != nil {
+		return 0, err
+	}
+	return totalFromContentRange(resp.Header.Get("Content-Range")), nil
+}
+
+// setTableHeaders authenticates a PostgREST request with the anon key
+func (c *SupabaseClient) setTableHeaders(req *http.Request) {
+	req.Header.Set("apikey", c.cfg.SupabaseKey)
+	req.Header.Set("Authorization", "Bearer "+c.cfg.SupabaseKey)
+}
+
+// totalFromContentRange reads the total of a PostgREST Content-Range header
+// such as "0-24/3573", returning -1 when it is missing or unknown ("*")
+func totalFromContentRange(header string) int {
+	_, total, ok := strings.Cut(header, "/")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.Atoi(total)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// keysetOf extracts the values of the sort columns from a row as PostgREST
+// filter values. JSON strings are unquoted; numbers and booleans are used as is.
+func keysetOf(row json.RawMessage, sort []SortKey) ([]*string, error) {
+	var columns map[string]json.RawMessage
+	if err := json.Unmarshal(row, &columns); err != nil {
+		return nil, err
+	}
+	keyset := make([]*string, len(sort))
+	for i, key := range sort {
+		raw, ok := columns[key.Column]
+		if !ok {
+			return nil, fmt.Errorf("sort column %q missing from the row", key.Column)
+		}
+		if string(raw) == "null" {
+			continue
+		}
+		value := string(raw)
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			value = s
+		}
+		keyset[i] = &value
+	}
+	return keyset, nil
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"backend/config"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestQueryAfter(t *testing.T) {
+	created, id, title := "2024-11-22T10:00:00", "g1", `a,b")`
+	query, err := From("games").
+		After([]SortKey{{"created_at", true}, {"title", false}, {"id", true}}, []*string{&created, nil, &id}).
+		Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `(and(or(created_at.lt."2024-11-22T10:00:00",created_at.is.null)),` +
+		`and(created_at.eq."2024-11-22T10:00:00",title.is.null,or(id.lt."g1",id.is.null)))`
+	if got, _ := url.ParseQuery(query); got.Get("or") != want {
+		t.Errorf("unexpected keyset filter\n got: %s\nwant: %s", got.Get("or"), want)
+	}
+
+	// Values are quoted, so they cannot close the logical tree
+	query, _ = From("games").After([]SortKey{{"title", false}}, []*string{&title}).Encode()
+	if got, _ := url.ParseQuery(query); got.Get("or") != `(and(or(title.gt."a,b\")",title.is.null)))` {
+		t.Errorf("unexpected quoting %s", got.Get("or"))
+	}
+
+	if _, err := From("games").After([]SortKey{{"id", false}}, []*string{nil}).Encode(); err == nil {
+		t.Error("expected an all-null keyset to be rejected")
+	}
+}
+
+func TestListPageAfterCountsAllRows(t *testing.T) {
+	var counted bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.Method == http.MethodHead {
+			counted = true
+			if query.Get("or") != "" || query.Get("difficulty_level") != "gte.2" {
+				t.Errorf("count must apply the filters but not the keyset: %s", r.URL.RawQuery)
+			}
+			w.Header().Set("Content-Range", "*/42")
+			return
+		}
+		if query.Get("or") == "" || r.Header.Get("Prefer") != "" {
+			t.Errorf("expected a keyset page without count, got %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(`[{"id":"s3","name":"Art"}]`))
+	}))
+	defer server.Close()
+
+	client := NewSupabaseClient(config.Config{SupabaseURL: server.URL})
+	after := "s2"
+	page, err := listPage[map[string]any](context.Background(), client, func() *Query { return From("subjects") }, ListOptions{
+		Filters: []Filter{{"difficulty_level", OpGte, 2}},
+		Sort:    []SortKey{{"id", false}},
+		After:   []*string{&after},
+		Limit:   5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !counted || page.Total != 42 || len(page.Items) != 1 || page.Next != nil {
+		t.Errorf("unexpected page %+v (counted %v)", page, counted)
+	}
+}
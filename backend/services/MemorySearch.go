@@ -0,0 +1,164 @@
+package services
+
+import (
+	"backend/models"
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// MemoryGameIndex is an in-memory GameSearcher for tests. It splits text into
+// lowercase words at anything that is not a letter or digit and matches query
+// words as prefixes, like the database, but without language-specific stemming.
+type MemoryGameIndex struct {
+	mu    sync.RWMutex
+	games []indexedGame
+}
+
+type indexedGame struct {
+	userID uuid.UUID
+	game   models.Game
+	title  []string
+	body   []string
+}
+
+// NewMemoryGameIndex creates an empty index
+func NewMemoryGameIndex() *MemoryGameIndex {
+	return &MemoryGameIndex{}
+}
+
+// Add indexes games owned by userID
+func (m *MemoryGameIndex) Add(userID uuid.UUID, games ...models.Game) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, game := range games {
+		m.games = append(m.games, indexedGame{
+			userID: userID,
+			game:   game,
+			title:  tokenize(game.Title),
+			body:   tokenize(game.Description),
+		})
+	}
+}
+
+// SearchGames ranks matches in the title above matches in the description, then sorts by title
+func (m *MemoryGameIndex) SearchGames(ctx context.Context, userID uuid.UUID, query SearchQuery) (SearchResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	type match struct {
+		game models.Game
+		rank int
+	}
+	var matches []match
+	words := tokenize(query.Text)
+	for _, entry := range m.games {
+		game := entry.game
+		if len(words) == 0 || entry.userID != userID ||
+			(query.Language != "" && cmp.Or(game.Language, "simple") != query.Language) ||
+			(query.SubjectID != nil && (game.SubjectID == nil || *game.SubjectID != *query.SubjectID)) ||
+			(query.Difficulty != 0 && game.Difficulty != query.Difficulty) {
+			continue
+		}
+		rank, ok := 0, true
+		for _, word := range words {
+			inTitle, inBody := hasPrefix(entry.title, word), hasPrefix(entry.body, word)
+			if !inTitle && !inBody {
+				ok = false
+				break
+			}
+			if inTitle {
+				rank += 2
+			} else {
+				rank++
+			}
+		}
+		if ok {
+			matches = append(matches, match{game, rank})
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b match) int {
+		return cmp.Or(b.rank-a.rank, strings.Compare(a.game.Title, b.game.Title), strings.Compare(a.game.ID.String(), b.game.ID.String()))
+	})
+
+	result := SearchResult{Items: []models.Game{}, Total: len(matches)}
+	subjects := map[uuid.UUID]int{}
+	difficulties := map[int]int{}
+	for i, hit := range matches {
+		if i >= query.Offset && len(result.Items) < query.Limit {
+			result.Items = append(result.Items, hit.game)
+		}
+		if hit.game.SubjectID != nil {
+			subjects[*hit.game.SubjectID]++
+		} else {
+			subjects[uuid.Nil]++
+		}
+		difficulties[hit.game.Difficulty]++
+	}
+	result.Facets = facetsOf(subjects, difficulties)
+	return result, nil
+}
+
+// facetsOf orders subject facets by count and difficulty facets by level,
+// with games lacking a subject or difficulty (uuid.Nil and 0) counted as null, last
+func facetsOf(subjects map[uuid.UUID]int, difficulties map[int]int) SearchFacets {
+	facets := SearchFacets{Subjects: []SubjectFacet{}, Difficulties: []DifficultyFacet{}}
+	for id, n := range subjects {
+		facet := SubjectFacet{Count: n}
+		if id != uuid.Nil {
+			facet.SubjectID = &id
+		}
+		facets.Subjects = append(facets.Subjects, facet)
+	}
+	slices.SortFunc(facets.Subjects, func(a, b SubjectFacet) int {
+		return cmp.Or(b.Count-a.Count, cmpNullsLast(a.SubjectID, b.SubjectID, func(x, y uuid.UUID) int {
+			return strings.Compare(x.String(), y.String())
+		}))
+	})
+
+	for level, n := range difficulties {
+		facet := DifficultyFacet{Count: n}
+		if level != 0 {
+			facet.Difficulty = &level
+		}
+		facets.Difficulties = append(facets.Difficulties, facet)
+	}
+	slices.SortFunc(facets.Difficulties, func(a, b DifficultyFacet) int {
+		return cmpNullsLast(a.Difficulty, b.Difficulty, cmp.Compare[int])
+	})
+	return facets
+}
+
+func cmpNullsLast[T any](a, b *T, compare func(T, T) int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return compare(*a, *b)
+}
+
+// tokenize lowercases s and splits it into words of letters and digits
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func hasPrefix(tokens []string, prefix string) bool {
+	for _, token := range tokens {
+		if strings.HasPrefix(token, prefix) {
+			return true
+		}
+	}
+	return false
+}
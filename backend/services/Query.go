@@ -0,0 +1,288 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// identifier matches the table and column names a Query accepts. Names are
+// never taken from user input, but rejecting anything else keeps a mistake
+// from turning into a PostgREST operator or logical filter.
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Query builds a PostgREST request URL for one table. Filter values are
+// escaped so that user input can only ever be compared against, never change
+// which columns, operators or filters the request contains.
+//
+//	services.From("games").Eq("id", gameID).Eq("user_id", userID).Order("created_at", Descending).Limit(20)
+type Query struct {
+	table  string
+	params url.Values
+	order  []string
+	joins  []string
+	err    error
+}
+
+// Sort directions for Order
+const (
+	Ascending  = "asc"
+	Descending = "desc"
+)
+
+// From starts a query against table
+func From(table string) *Query {
+	q := &Query{table: table, params: url.Values{}}
+	q.checkName(table)
+	return q
+}
+
+// Select limits the returned columns. "*" selects every column.
+func (q *Query) Select(columns ...string) *Query {
+	for _, column := range columns {
+		if column != "*" {
+			q.checkName(column)
+		}
+	}
+	q.params.Set("select", strings.Join(columns, ","))
+	return q
+}
+
+// Eq keeps rows where column equals value
+func (q *Query) Eq(column string, value interface{}) *Query {
+	return q.filter(column, "eq", formatValue(value))
+}
+
+// Gte keeps rows where column is greater than or equal to value
+func (q *Query) Gte(column string, value interface{}) *Query {
+	return q.filter(column, "gte", formatValue(value))
+}
+
+// Lte keeps rows where column is less than or equal to value
+func (q *Query) Lte(column string, value interface{}) *Query {
+	return q.filter(column, "lte", formatValue(value))
+}
+
+// Lt keeps rows where column is less than value
+func (q *Query) Lt(column string, value interface{}) *Query {
+	return q.filter(column, "lt", formatValue(value))
+}
+
+// IsNull keeps rows where column is null
+func (q *Query) IsNull(column string) *Query {
+	return q.filter(column, "is", "null")
+}
+
+// NotNull keeps rows where column is not null
+func (q *Query) NotNull(column string) *Query {
+	return q.filter(column, "not.is", "null")
+}
+
+// Like keeps rows where column matches pattern, in which * is the wildcard.
+// Use LikeEscape to match user input literally inside a pattern.
+func (q *Query) Like(column, pattern string) *Query {
+	return q.filter(column, "like", pattern)
+}
+
+// In keeps rows where column equals one of values
+func (q *Query) In(column string, values ...interface{}) *Query {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = quoteListItem(formatValue(value))
+	}
+	return q.filter(column, "in", "("+strings.Join(quoted, ",")+")")
+}
+
+// InnerJoin embeds table, which the rows reference by a foreign key, and
+// keeps only the rows whose table row passes filter. The embedded row is not
+// returned.
+//
+//	services.From("game_results").InnerJoin("games", live)
+func (q *Query) InnerJoin(table string, filter func(*Query) *Query) *Query {
+	joined := filter(From(table))
+	if joined.err != nil {
+		q.fail(joined.err)
+		return q
+	}
+	for column, values := range joined.params {
+		if column == "select" {
+			continue
+		}
+		for _, value := range values {
+			q.params.Add(table+"."+column, value)
+		}
+	}
+	q.joins = append(q.joins, table+"!inner()")
+	return q
+}
+
+// Order sorts by column in direction (Ascending or Descending). Later calls add tie-breakers.
+func (q *Query) Order(column, direction string) *Query {
+	q.checkName(column)
+	if direction != Ascending && direction != Descending {
+		q.fail(fmt.Errorf("invalid sort direction %q", direction))
+	}
+	q.order = append(q.order, column+"."+direction)
+	return q
+}
+
+// OrderNullsLast is Order with rows whose column is null sorted after all others,
+// whatever the direction. Keyset pagination with After relies on it.
+func (q *Query) OrderNullsLast(column, direction string) *Query {
+	q.Order(column, direction)
+	q.order[len(q.order)-1] += ".nullslast"
+	return q
+}
+
+// After keeps the rows that sort after the row whose keys hold values, for
+// keyset pagination. keys must repeat the OrderNullsLast calls of the query
+// and end with a column that is never null; a nil value stands for null.
+func (q *Query) After(keys []SortKey, values []*string) *Query {
+	if len(keys) != len(values) {
+		q.fail(fmt.Errorf("keyset has %d values for %d sort keys", len(values), len(keys)))
+		return q
+	}
+
+	// (k1 after v1) or (k1 = v1 and k2 after v2) or ...
+	var terms []string
+	for i, key := range keys {
+		q.checkName(key.Column)
+		if values[i] == nil {
+			// Nothing sorts after null when nulls are last
+			continue
+		}
+		var conds []string
+		for j := 0; j < i; j++ {
+			conds = append(conds, equalsKey(keys[j].Column, values[j]))
+		}
+		operator := "gt"
+		if key.Descending {
+			operator = "lt"
+		}
+		conds = append(conds, fmt.Sprintf("or(%s.%s.%s,%s.is.null)", key.Column, operator, quoteListItem(*values[i]), key.Column))
+		terms = append(terms, "and("+strings.Join(conds, ",")+")")
+	}
+	if len(terms) == 0 {
+		q.fail(fmt.Errorf("keyset needs a non-null value"))
+		return q
+	}
+	q.params.Add("or", "("+strings.Join(terms, ",")+")")
+	return q
+}
+
+func equalsKey(column string, value *string) string {
+	if value == nil {
+		return column + ".is.null"
+	}
+	return column + ".eq." + quoteListItem(*value)
+}
+
+// Limit caps the number of returned rows
+func (q *Query) Limit(n int) *Query {
+	if n < 0 {
+		q.fail(fmt.Errorf("invalid limit %d", n))
+	}
+	q.params.Set("limit", strconv.Itoa(n))
+	return q
+}
+
+// Range returns the rows from offset from to offset to, both inclusive
+func (q *Query) Range(from, to int) *Query {
+	if from < 0 || to < from {
+		q.fail(fmt.Errorf("invalid range %d-%d", from, to))
+	}
+	q.params.Set("offset", strconv.Itoa(from))
+	q.params.Set("limit", strconv.Itoa(to-from+1))
+	return q
+}
+
+// Encode returns the query string, or the first error recorded while building
+func (q *Query) Encode() (string, error) {
+	if q.err != nil {
+		return "", q.err
+	}
+	params := url.Values{}
+	for key, values := range q.params {
+		params[key] = append([]string(nil), values...)
+	}
+	if len(q.order) > 0 {
+		params.Set("order", strings.Join(q.order, ","))
+	}
+	if len(q.joins) > 0 {
+		columns := params.Get("select")
+		if columns == "" {
+			columns = "*"
+		}
+		params.Set("select", columns+","+strings.Join(q.joins, ","))
+	}
+	return params.Encode(), nil
+}
+
+// URL returns the REST endpoint of the query under the Supabase project URL
+func (q *Query) URL(supabaseURL string) (string, error) {
+	query, err := q.Encode()
+	if err != nil {
+		return "", err
+	}
+	endpoint := strings.TrimSuffix(supabaseURL, "/") + "/rest/v1/" + q.table
+	if query != "" {
+		endpoint += "?" + query
+	}
+	return endpoint, nil
+}
+
+// Table returns the table the query targets
+func (q *Query) Table() string {
+	return q.table
+}
+
+// LikeEscape escapes the wildcard and escape characters of s so it matches literally in a Like pattern
+func LikeEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (q *Query) filter(column, operator, value string) *Query {
+	q.checkName(column)
+	switch column {
+	case "select", "order", "limit", "offset", "or", "and", "not":
+		q.fail(fmt.Errorf("column name %q is reserved", column))
+	}
+	q.params.Add(column, operator+"."+value)
+	return q
+}
+
+func (q *Query) checkName(name string) {
+	if !identifier.MatchString(name) {
+		q.fail(fmt.Errorf("invalid PostgREST identifier %q", name))
+	}
+}
+
+func (q *Query) fail(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// formatValue renders a filter value the way PostgREST parses it
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case time.Time:
+		// Timestamp columns have no time zone and PostgREST drops the offset, so compare in UTC
+		return v.UTC().Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// quoteListItem double-quotes a value inside an in.(...) list so commas,
+// parentheses and quotes in it are not read as list syntax
+func quoteListItem(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryURL(t *testing.T) {
+	// Times are compared in UTC whatever their offset
+	since := time.Date(2024, 11, 22, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+	got, err := From("games").
+		Select("id", "title").
+		Eq("user_id", "u1").
+		Gte("created_at", since).
+		Lte("difficulty_level", 3).
+		In("subject_id", "a", "b,c").
+		Like("title", "*"+LikeEscape("50%_off")+"*").
+		Order("created_at", Descending).
+		Order("id", Ascending).
+		Range(20, 29).
+		URL("https://x.supabase.co/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "https://x.supabase.co/rest/v1/games?" + url.Values{
+		"select":           {"id,title"},
+		"user_id":          {"eq.u1"},
+		"created_at":       {"gte.2024-11-22T10:00:00Z"},
+		"difficulty_level": {"lte.3"},
+		"subject_id":       {`in.("a","b,c")`},
+		"title":            {`like.*50\%\_off*`},
+		"order":            {"created_at.desc,id.asc"},
+		"offset":           {"20"},
+		"limit":            {"10"},
+	}.Encode()
+	if got != want {
+		t.Errorf("unexpected URL\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestQueryNullAndLessThanFilters(t *testing.T) {
+	cutoff := time.Date(2026, 9, 19, 0, 0, 0, 0, time.UTC)
+	got, err := From("games").IsNull("deleted_at").URL("https://x.supabase.co")
+	if err != nil || got != "https://x.supabase.co/rest/v1/games?deleted_at=is.null" {
+		t.Errorf("unexpected URL %s %v", got, err)
+	}
+	got, err = From("users").NotNull("deleted_at").Lt("deleted_at", cutoff).Encode()
+	want := url.Values{"deleted_at": {"not.is.null", "lt.2026-09-19T00:00:00Z"}}.Encode()
+	if err != nil || got != want {
+		t.Errorf("unexpected query\n got: %s\nwant: %s", got, want)
+	}
+}
+
+func TestQueryInnerJoin(t *testing.T) {
+	got, err := From("game_results").Eq("user_id", "u1").InnerJoin("games", live).Encode()
+	want := url.Values{
+		"select":           {"*,games!inner()"},
+		"user_id":          {"eq.u1"},
+		"games.deleted_at": {"is.null"},
+	}.Encode()
+	if err != nil || got != want {
+		t.Errorf("unexpected query\n got: %s\nwant: %s", got, want)
+	}
+
+	if _, err := From("game_results").InnerJoin("games;", live).Encode(); err == nil {
+		t.Error("expected an invalid joined table to be rejected")
+	}
+}
+
+func TestQueryRejectsInvalidIdentifiers(t *testing.T) {
+	cases := map[string]*Query{
+		"table":     From("games?id=eq.1"),
+		"column":    From("games").Eq("id&role", "x"),
+		"reserved":  From("games").Eq("order", "x"),
+		"select":    From("games").Select("id,role"),
+		"order":     From("games").Order("id", "asc;drop"),
+		"range":     From("games").Range(5, 1),
+		"negative":  From("games").Limit(-1),
+		"logical":   From("games").Eq("or", "(id.eq.1)").Select("or)"),
+		"empty col": From("games").Lte("", 1),
+	}
+	for name, q := range cases {
+		if _, err := q.URL("https://x.supabase.co"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// FuzzQueryEq proves that user supplied IDs only ever become the value of the filters they were passed to
+func FuzzQueryEq(f *testing.F) {
+	f.Add("5803acaf-821a-4463-b8b4-15ac6e0e466a", "1")
+	f.Add("1&user_id=neq.x", "x,or=(id.gt.0)")
+	f.Add("eq.1&select=*", "%26role=eq.admin#")
+	f.Add("", "\x00\n?;")
+
+	f.Fuzz(func(t *testing.T, gameID, userID string) {
+		endpoint, err := From("games").Eq("id", gameID).Eq("user_id", userID).URL("https://x.supabase.co")
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			t.Fatalf("builder produced an unparsable URL %q: %v", endpoint, err)
+		}
+		if u.Path != "/rest/v1/games" || u.Fragment != "" {
+			t.Fatalf("input changed the target: %q", endpoint)
+		}
+		params, err := url.ParseQuery(u.RawQuery)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(params) != 2 || len(params["id"]) != 1 || len(params["user_id"]) != 1 {
+			t.Fatalf("input changed the filter structure: %v", params)
+		}
+		if params.Get("id") != "eq."+gameID || params.Get("user_id") != "eq."+userID {
+			t.Fatalf("filter values were altered: %v", params)
+		}
+	})
+}
+
+// FuzzQueryIn proves that list items cannot add or split items of an in.() filter
+func FuzzQueryIn(f *testing.F) {
+	f.Add("a", "b")
+	f.Add(`a","b`, `)`)
+	f.Add(`\`, `"),id.eq.(1`)
+
+	f.Fuzz(func(t *testing.T, first, second string) {
+		query, err := From("games").In("id", first, second).Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		params, err := url.ParseQuery(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(params) != 1 || len(params["id"]) != 1 {
+			t.Fatalf("input changed the filter structure: %v", params)
+		}
+		items, ok := parseInList(params.Get("id"))
+		if !ok || len(items) != 2 || items[0] != first || items[1] != second {
+			t.Fatalf("list %q did not round-trip %q, %q: %q", params.Get("id"), first, second, items)
+		}
+	})
+}
+
+// parseInList reads an in.(...) value the way PostgREST does: items are
+// separated by commas and double-quoted items may contain escaped quotes and backslashes
+func parseInList(value string) ([]string, bool) {
+	list, ok := strings.CutPrefix(value, "in.(")
+	if !ok || !strings.HasSuffix(list, ")") {
+		return nil, false
+	}
+	list = strings.TrimSuffix(list, ")")
+
+	var items []string
+	for len(list) > 0 {
+		if list[0] != '"' {
+			return nil, false
+		}
+		var item strings.Builder
+		i := 1
+		for ; i < len(list) && list[i] != '"'; i++ {
+			if list[i] == '\\' {
+				i++
+				if i == len(list) {
+					return nil, false
+				}
+			}
+			item.WriteByte(list[i])
+		}
+		if i == len(list) {
+			return nil, false
+		}
+		items = append(items, item.String())
+		list = list[i+1:]
+		if len(list) > 0 {
+			if list[0] != ',' {
+				return nil, false
+			}
+			list = list[1:]
+		}
+	}
+	return items, true
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"backend/models"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// ResultService reads the game_results table through the Supabase REST API
+type ResultService struct {
+	*SupabaseClient
+}
+
+// NewResultService creates a ResultService that calls Supabase through client
+func NewResultService(client *SupabaseClient) *ResultService {
+	return &ResultService{client}
+}
+
+// ListResults retrieves one page of the game results of a user, leaving out
+// the results of games in the trash
+func (s *ResultService) ListResults(ctx context.Context, userID uuid.UUID, opts ListOptions) (Page[models.GameResult], error) {
+	return listPage[models.GameResult](ctx, s.SupabaseClient, func() *Query {
+		return ofLiveGames(From("game_results").Eq("user_id", userID))
+	}, opts)
+}
+
+// ResultsForGames retrieves every result of a user in the given games that
+// are not in the trash, most recent first
+func (s *ResultService) ResultsForGames(ctx context.Context, userID uuid.UUID, gameIDs []uuid.UUID) ([]models.GameResult, error) {
+	return selectByIDs[models.GameResult](ctx, s.SupabaseClient, func() *Query {
+		return ofLiveGames(From("game_results").Eq("user_id", userID)).Order("completed_at", Descending).Order("id", Descending)
+	}, "game_id", gameIDs)
+}
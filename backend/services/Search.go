@@ -0,0 +1,101 @@
+package services
+
+import (
+	"backend/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// SearchLanguages are the Postgres text search configurations games may be written in
+var SearchLanguages = models.Languages
+
+// GameSearcher finds a user's games by words of their title and description
+type GameSearcher interface {
+	SearchGames(ctx context.Context, userID uuid.UUID, query SearchQuery) (SearchResult, error)
+}
+
+// SearchQuery is a full-text game search. Every word of Text must match the
+// start of a word in the title or description.
+type SearchQuery struct {
+	Text       string
+	Language   string     // only games in this language when set
+	SubjectID  *uuid.UUID // only games of this subject when set
+	Difficulty int        // only games of this difficulty when not 0
+	Limit      int
+	Offset     int
+}
+
+// SearchResult is one page of ranked matches with facet counts over all matches
+type SearchResult struct {
+	Items  []models.Game `json:"items"`
+	Total  int           `json:"total"`
+	Facets SearchFacets  `json:"facets"`
+}
+
+// SearchFacets count the matches by subject and difficulty
+type SearchFacets struct {
+	Subjects     []SubjectFacet    `json:"subject_id"`
+	Difficulties []DifficultyFacet `json:"difficulty_level"`
+}
+
+// SubjectFacet counts the matches of one subject; SubjectID is nil for games without one
+type SubjectFacet struct {
+	SubjectID *uuid.UUID `json:"value"`
+	Count     int        `json:"count"`
+}
+
+// DifficultyFacet counts the matches of one difficulty level; Difficulty is nil when unset
+type DifficultyFacet struct {
+	Difficulty *int `json:"value"`
+	Count      int  `json:"count"`
+}
+
+// SearchGames runs the search_games database function (see db/migrations)
+func (s *GameService) SearchGames(ctx context.Context, userID uuid.UUID, query SearchQuery) (SearchResult, error) {
+	var result SearchResult
+
+	params := map[string]interface{}{
+		"search_query": query.Text,
+		"owner_id":     userID,
+		"page_limit":   query.Limit,
+		"page_offset":  query.Offset,
+	}
+	if query.Language != "" {
+		params["search_language"] = query.Language
+	}
+	if query.SubjectID != nil {
+		params["subject"] = query.SubjectID
+	}
+	if query.Difficulty != 0 {
+		params["difficulty"] = query.Difficulty
+	}
+
+	rpcURL := fmt.Sprintf("%s/rest/v1/rpc/search_games", s.cfg.SupabaseURL)
+	headers := map[string]string{
+		"apikey":        s.cfg.SupabaseKey,
+		"Authorization": "Bearer " + s.cfg.SupabaseKey,
+		"Content-Type":  "application/json",
+	}
+	resp, err := s.call(ctx, http.MethodPost, rpcURL, params, headers)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return result, err
+	}
+	if err := checkResponse("postgrest", resp, body); err != nil {
+		return result, err
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return result, fmt.Errorf("failed to decode search result: %w", err)
+	}
+	return result, nil
+}
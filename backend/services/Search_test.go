@@ -0,0 +1,102 @@
+package services
+
+import (
+	"backend/config"
+	"backend/models"
+	"backend/validation"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestMemoryGameIndexSearch(t *testing.T) {
+	owner, other := uuid.New(), uuid.New()
+	verbs, nouns := uuid.New(), uuid.New()
+	games := []models.Game{
+		{ID: uuid.New(), Title: "Irregular verbs", Description: "Past tense drills", SubjectID: &verbs, Difficulty: 3},
+		{ID: uuid.New(), Title: "Kitchen nouns", Description: "Verbally describe the kitchen", SubjectID: &nouns, Difficulty: 1},
+		{ID: uuid.New(), Title: "Verb endings", Description: "Conjugation", SubjectID: &verbs, Difficulty: 3, Language: "german"},
+		{ID: uuid.New(), Title: "Colours", Description: "Basic vocabulary"},
+	}
+	index := NewMemoryGameIndex()
+	index.Add(owner, games...)
+	index.Add(other, models.Game{ID: uuid.New(), Title: "Verbs of someone else"})
+
+	result, err := index.SearchGames(context.Background(), owner, SearchQuery{Text: "VERB", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := make([]string, len(result.Items))
+	for i, game := range result.Items {
+		titles[i] = game.Title
+	}
+	// Title matches rank above description matches; other users' games never match
+	if want := []string{"Irregular verbs", "Verb endings", "Kitchen nouns"}; !slices.Equal(titles, want) || result.Total != 3 {
+		t.Errorf("expected %v, got %v (total %d)", want, titles, result.Total)
+	}
+	if f := result.Facets.Subjects; len(f) != 2 || *f[0].SubjectID != verbs || f[0].Count != 2 || f[1].Count != 1 {
+		t.Errorf("unexpected subject facets %+v", f)
+	}
+	if f := result.Facets.Difficulties; len(f) != 2 || *f[0].Difficulty != 1 || *f[1].Difficulty != 3 || f[1].Count != 2 {
+		t.Errorf("unexpected difficulty facets %+v", f)
+	}
+
+	// Every word must match, the filters narrow the matches and paging keeps the total
+	result, _ = index.SearchGames(context.Background(), owner, SearchQuery{Text: "verb dri", Limit: 10})
+	if result.Total != 1 || result.Items[0].Title != "Irregular verbs" {
+		t.Errorf("expected only the game matching both words, got %+v", result.Items)
+	}
+	result, _ = index.SearchGames(context.Background(), owner, SearchQuery{Text: "verb", Language: "simple", Difficulty: 3, Limit: 10})
+	if result.Total != 1 {
+		t.Errorf("expected the language and difficulty filters to leave one game, got %d", result.Total)
+	}
+	result, _ = index.SearchGames(context.Background(), owner, SearchQuery{Text: "verb", Limit: 1, Offset: 1})
+	if result.Total != 3 || len(result.Items) != 1 || result.Items[0].Title != "Verb endings" {
+		t.Errorf("unexpected second page %+v", result)
+	}
+	if result, _ = index.SearchGames(context.Background(), owner, SearchQuery{Text: " - ", Limit: 10}); result.Total != 0 {
+		t.Errorf("expected a query without words to match nothing, got %d", result.Total)
+	}
+}
+
+func TestSearchGamesCallsRPC(t *testing.T) {
+	owner := uuid.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&params)
+		if r.Method != http.MethodPost || r.URL.Path != "/rest/v1/rpc/search_games" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		if params["search_query"] != "verb" || params["owner_id"] != owner.String() || params["difficulty"] != 3.0 {
+			t.Errorf("unexpected parameters %v", params)
+		}
+		if _, ok := params["subject"]; ok {
+			t.Errorf("unset filters must be left to the SQL defaults: %v", params)
+		}
+		w.Write([]byte(`{"total":1,"items":[{"id":"` + uuid.NewString() + `","title":"Verbs"}],
+			"facets":{"subject_id":[{"value":null,"count":1}],"difficulty_level":[{"value":3,"count":1}]}}`))
+	}))
+	defer server.Close()
+
+	service := NewGameService(NewSupabaseClient(config.Config{SupabaseURL: server.URL}))
+	result, err := service.SearchGames(context.Background(), owner, SearchQuery{Text: "verb", Difficulty: 3, Limit: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 || result.Facets.Subjects[0].SubjectID != nil || *result.Facets.Difficulties[0].Difficulty != 3 {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestGameLanguagesMatchSearchLanguages(t *testing.T) {
+	for _, rules := range validation.Describe(models.GameRequest{}) {
+		if rules.Field == "language" && !slices.Equal(rules.Enum, SearchLanguages) {
+			t.Errorf("GameRequest languages %v differ from SearchLanguages %v", rules.Enum, SearchLanguages)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"backend/models"
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// SubjectService reads the subjects table through the Supabase REST API
+type SubjectService struct {
+	*SupabaseClient
+}
+
+// NewSubjectService creates a SubjectService that calls Supabase through client
+func NewSubjectService(client *SupabaseClient) *SubjectService {
+	return &SubjectService{client}
+}
+
+// ListSubjects retrieves one page of subjects
+func (s *SubjectService) ListSubjects(ctx context.Context, opts ListOptions) (Page[models.Subject], error) {
+	return listPage[models.Subject](ctx, s.SupabaseClient, func() *Query {
+		return From("subjects")
+	}, opts)
+}
+
+// FetchSubjectsByIDs retrieves the subjects with the given IDs. IDs of missing subjects are left out.
+func (s *SubjectService) FetchSubjectsByIDs(ctx context.Context, ids []uuid.UUID) ([]models.Subject, error) {
+	return selectByIDs[models.Subject](ctx, s.SupabaseClient, func() *Query {
+		return From("subjects")
+	}, "id", ids)
+}
@@ -2,34 +2,205 @@ package services
 
 import (
 	"backend/config"
+	"backend/logging"
+	"backend/metrics"
+	"backend/tracing"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"math/rand/v2"
 	"net/http"
+	"time"
+)
 
-	"github.com/nedpals/supabase-go"
+// Fallbacks used when the Supabase client configuration leaves a setting unset
+const (
+	defaultSupabaseTimeout      = 10 * time.Second
+	defaultSupabaseMaxIdleConns = 32
 )
 
-var Supabase *supabase.Client
+// SupabaseClient is the client shared by all services for Supabase calls.
+// Requests carry the caller's context and request ID, reuse keep-alive
+// connections, are retried with jittered backoff when the method is
+// idempotent and the failure transient, and fail fast while the circuit
+// breaker is open.
+type SupabaseClient struct {
+	cfg     config.Config
+	http    *http.Client
+	breaker *CircuitBreaker
+}
 
-// InitSupabase initializes the Supabase client
-func InitSupabase(cfg config.Config) {
-	supabaseURL := cfg.SupabaseURL
-	supabaseKey := cfg.SupabaseKey
+// NewSupabaseClient creates the Supabase client from the configuration
+func NewSupabaseClient(cfg config.Config) *SupabaseClient {
+	return &SupabaseClient{
+		cfg:     cfg,
+		http:    newHTTPClient(cfg.Supabase),
+		breaker: NewCircuitBreaker(cfg.Supabase.BreakerFailures, cfg.Supabase.BreakerCooldown),
+	}
+}
 
-	if supabaseURL == "" || supabaseKey == "" {
-		log.Fatalf("SUPABASE_URL and SUPABASE_KEY must be set")
+// newHTTPClient builds a client whose idle connections are kept per host so
+// requests reuse TLS sessions instead of dialing Supabase for every call
+func newHTTPClient(cfg config.SupabaseClientConfig) *http.Client {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultSupabaseTimeout
 	}
+	maxIdle := cfg.MaxIdleConns
+	if maxIdle <= 0 {
+		maxIdle = defaultSupabaseMaxIdleConns
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = maxIdle
+	transport.MaxIdleConnsPerHost = maxIdle
+	transport.IdleConnTimeout = 90 * time.Second
+	transport.TLSHandshakeTimeout = 5 * time.Second
+
+	// The timeout applies to each attempt, so retries get a fresh budget
+	return &http.Client{Transport: transport, Timeout: timeout}
+}
 
-	client := supabase.CreateClient(supabaseURL, supabaseKey)
-	Supabase = client
+// Breaker returns the circuit breaker guarding calls to Supabase
+func (c *SupabaseClient) Breaker() *CircuitBreaker {
+	return c.breaker
 }
 
+// CloseIdleConnections closes the pooled keep-alive connections
+func (c *SupabaseClient) CloseIdleConnections() {
+	c.http.CloseIdleConnections()
+}
+
+// newRequest creates a request bound to ctx and tags it with the
+// incoming request ID so calls can be correlated on both sides
+func (c *SupabaseClient) newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		req.Header.Set(logging.RequestIDHeader, requestID)
+	}
+	return req, nil
+}
+
+// do sends req, retrying transient failures of idempotent requests, and
+// records each attempt under the given table and operation
+func (c *SupabaseClient) do(req *http.Request, table, operation string) (*http.Response, error) {
+	attempts := 1
+	if isIdempotent(req.Method) && c.cfg.Supabase.RetryAttempts > 1 {
+		attempts = c.cfg.Supabase.RetryAttempts
+	}
 
-// callSupabaseAPI sends an HTTP request to the Supabase API and returns the response
-func callSupabaseAPI(method, url string, payload interface{}, headers map[string]string) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := c.attempt(req, table, operation)
+		if attempt >= attempts || !isTransient(req.Context(), resp, err) {
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrUpstream, err)
+			}
+			return resp, nil
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err := sleep(req.Context(), c.backoff(attempt)); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrUpstream, err)
+		}
+		metrics.SupabaseRetries.WithLabelValues(table, operation).Inc()
+
+		// The body was consumed by the previous attempt
+		retry := req.Clone(req.Context())
+		if req.GetBody != nil {
+			if retry.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+		req = retry
+	}
+}
+
+// attempt sends req once inside a client span, guarded by the circuit breaker
+func (c *SupabaseClient) attempt(req *http.Request, table, operation string) (*http.Response, error) {
+	if err := c.breaker.Allow(); err != nil {
+		metrics.SupabaseBreakerOpen.Set(1)
+		return nil, err
+	}
+
+	req, span := tracing.StartClientSpan(req, table, operation)
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	metrics.ObserveSupabase(table, operation, resp, time.Since(start))
+	tracing.EndClientSpan(span, resp, err)
+
+	// A caller that gave up says nothing about Supabase's health
+	if req.Context().Err() != nil {
+		c.breaker.Release()
+	} else {
+		c.breaker.Record(err == nil && resp.StatusCode < http.StatusInternalServerError)
+	}
+	if c.breaker.State() == BreakerOpen {
+		metrics.SupabaseBreakerOpen.Set(1)
+	} else {
+		metrics.SupabaseBreakerOpen.Set(0)
+	}
+	return resp, err
+}
+
+// backoff returns the jittered delay before retry number attempt: the base
+// delay doubled per retry and capped, of which a random half is kept
+func (c *SupabaseClient) backoff(attempt int) time.Duration {
+	delay := c.cfg.Supabase.RetryBaseDelay << (attempt - 1)
+	if limit := c.cfg.Supabase.RetryMaxDelay; limit > 0 && (delay > limit || delay <= 0) {
+		delay = limit
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isIdempotent reports whether repeating a request with method cannot change the outcome
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isTransient reports whether a failed attempt is worth retrying
+func isTransient(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// call sends an HTTP request to the Supabase API and returns the response
+func (c *SupabaseClient) call(ctx context.Context, method, url string, payload interface{}, headers map[string]string) (*http.Response, error) {
 	var body io.Reader
 	if payload != nil {
 		// Marshal the payload to JSON
@@ -37,11 +208,11 @@ func callSupabaseAPI(method, url string, payload interface{}, headers map[string
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal payload: %w", err)
 		}
-		body = bytes.NewBuffer(data)
+		body = bytes.NewReader(data)
 	}
 
 	// Create a new HTTP request
-	req, err := http.NewRequest(method, url, body)
+	req, err := c.newRequest(ctx, method, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -51,12 +222,46 @@ func callSupabaseAPI(method, url string, payload interface{}, headers map[string
 		req.Header.Set(key, value)
 	}
 
-	// Create an HTTP client and send the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	// Send the request, labelling its metrics from the target URL
+	table, operation := metrics.SupabaseLabels(method, req.URL)
+	resp, err := c.do(req, table, operation)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 
 	return resp, nil
 }
+
+// callTable runs a PostgREST request for query, asking for the affected rows,
+// and decodes the result into out
+func (c *SupabaseClient) callTable(ctx context.Context, method string, query *Query, payload interface{}, out interface{}) error {
+	cfg := c.cfg
+	tableURL, err := query.URL(cfg.SupabaseURL)
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{
+		"apikey":        cfg.SupabaseKey,
+		"Authorization": "Bearer " + cfg.SupabaseKey,
+		"Content-Type":  "application/json",
+		"Prefer":        "return=representation",
+	}
+
+	resp, err := c.call(ctx, method, tableURL, payload, headers)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := checkResponse("postgrest", resp, body); err != nil {
+		return err
+	}
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
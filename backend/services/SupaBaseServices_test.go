@@ -0,0 +1,142 @@
+package services
+
+import (
+	"backend/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestClient(url string) *SupabaseClient {
+	return NewSupabaseClient(config.Config{
+		SupabaseURL: url,
+		Supabase: config.SupabaseClientConfig{
+			RetryAttempts:   3,
+			RetryBaseDelay:  time.Millisecond,
+			RetryMaxDelay:   5 * time.Millisecond,
+			BreakerFailures: 3,
+			BreakerCooldown: time.Hour,
+		},
+	})
+}
+
+func TestIdempotentRequestsAreRetried(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	resp, err := client.call(context.Background(), http.MethodGet, server.URL+"/rest/v1/games", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Errorf("expected success on the third attempt, got status %d after %d calls", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestRetriedRequestResendsBody(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["email"] != "a@b.c" {
+			t.Errorf("attempt %d: unexpected body %v (%v)", calls.Load()+1, body, err)
+		}
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	resp, err := newTestClient(server.URL).call(context.Background(), http.MethodPut, server.URL+"/auth/v1/admin/users/1", map[string]string{"email": "a@b.c"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 2 {
+		t.Errorf("expected one retry, got %d calls", calls.Load())
+	}
+}
+
+func TestNonIdempotentRequestsAreNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	resp, err := newTestClient(server.URL).call(context.Background(), http.MethodPost, server.URL+"/rest/v1/games", map[string]string{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 1 {
+		t.Errorf("expected POST to be sent once, got %d calls", calls.Load())
+	}
+}
+
+func TestBreakerFailsFastWhenSupabaseIsDown(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server.URL)
+	for i := 0; i < 3; i++ {
+		resp, err := client.call(context.Background(), http.MethodPost, server.URL+"/rest/v1/games", nil, nil)
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+	if state := client.Breaker().State(); state != BreakerOpen {
+		t.Fatalf("expected breaker to be open, got %s", state)
+	}
+
+	_, err := client.call(context.Background(), http.MethodGet, server.URL+"/rest/v1/games", nil, nil)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("expected no request while open, got %d calls", calls.Load())
+	}
+}
+
+func TestBreakerHalfOpenProbe(t *testing.T) {
+	now := time.Now()
+	breaker := NewCircuitBreaker(1, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	breaker.Allow()
+	breaker.Record(false)
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected open breaker to reject, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	if err := breaker.Allow(); err != nil {
+		t.Fatalf("expected a probe after the cooldown, got %v", err)
+	}
+	if err := breaker.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected only one probe at a time, got %v", err)
+	}
+	breaker.Record(true)
+	if state := breaker.State(); state != BreakerClosed {
+		t.Errorf("expected successful probe to close the breaker, got %s", state)
+	}
+}
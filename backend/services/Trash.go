@@ -0,0 +1,152 @@
+package services
+
+import (
+	"backend/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Deleting a game or user moves it to the trash by setting deleted_at. Every
+// read leaves trashed rows out, and the results and states of trashed games
+// with them; the trash listings and restores are the only way to reach them
+// until a purge removes them for good.
+
+// maxPurgeUsers caps the users one PurgeUsers call removes, since each needs a
+// call to the auth admin API. The rest are left for the next purge.
+const maxPurgeUsers = 500
+
+// live narrows q to rows that are not in the trash
+func live(q *Query) *Query {
+	return q.IsNull("deleted_at")
+}
+
+// ofLiveGames narrows q to rows whose game is not in the trash, for the
+// tables that reference games
+func ofLiveGames(q *Query) *Query {
+	return q.InnerJoin("games", live)
+}
+
+// trashed narrows q to rows in the trash
+func trashed(q *Query) *Query {
+	return q.NotNull("deleted_at")
+}
+
+// trashPatch moves rows to the trash
+func trashPatch() map[string]interface{} {
+	return map[string]interface{}{"deleted_at": time.Now().UTC()}
+}
+
+// restorePatch takes rows out of the trash
+func restorePatch() map[string]interface{} {
+	return map[string]interface{}{"deleted_at": nil}
+}
+
+// ListTrashedGames retrieves one page of the games a user moved to the trash
+func (s *GameService) ListTrashedGames(ctx context.Context, userID uuid.UUID, opts ListOptions) (Page[models.Game], error) {
+	return listPage[models.Game](ctx, s.SupabaseClient, func() *Query {
+		return trashed(From("games").Eq("user_id", userID))
+	}, opts)
+}
+
+// RestoreGame takes a game of a user out of the trash. Games that are missing
+// or not in the trash are reported as ErrNotFound.
+func (s *GameService) RestoreGame(ctx context.Context, gameID, userID uuid.UUID) (models.Game, error) {
+	var restored []models.Game
+	query := trashed(From("games").Eq("id", gameID).Eq("user_id", userID))
+	if err := s.callTable(ctx, http.MethodPatch, query, restorePatch(), &restored); err != nil {
+		return models.Game{}, err
+	}
+	if len(restored) == 0 {
+		return models.Game{}, notFound("trashed game", gameID.String())
+	}
+	return restored[0], nil
+}
+
+// PurgeGames deletes the games moved to the trash before cutoff for good,
+// together with their results and game states, and returns how many it deleted
+func (s *GameService) PurgeGames(ctx context.Context, cutoff time.Time) (int, error) {
+	var purged []struct{}
+	query := From("games").Select("id").Lt("deleted_at", cutoff)
+	if err := s.callTable(ctx, http.MethodDelete, query, nil, &purged); err != nil {
+		return 0, err
+	}
+	return len(purged), nil
+}
+
+// ListTrashedUsers retrieves one page of the users in the trash for administrators
+func (s *UserService) ListTrashedUsers(ctx context.Context, opts ListOptions) (Page[models.User], error) {
+	return listPage[models.User](ctx, s.SupabaseClient, func() *Query {
+		return trashed(From("users"))
+	}, opts)
+}
+
+// RestoreUser takes a row of public.users out of the trash. Users that are
+// missing or not in the trash are reported as ErrNotFound.
+func (s *UserService) RestoreUser(ctx context.Context, userID uuid.UUID) (models.User, error) {
+	var restored []models.User
+	query := trashed(From("users").Eq("id", userID))
+	if err := s.callTable(ctx, http.MethodPatch, query, restorePatch(), &restored); err != nil {
+		return models.User{}, err
+	}
+	if len(restored) == 0 {
+		return models.User{}, notFound("trashed user", userID.String())
+	}
+	return restored[0], nil
+}
+
+// SetAuthBanned bans or unbans the auth.users account with the admin API, so
+// users in the trash cannot log in or refresh their session
+func (s *UserService) SetAuthBanned(ctx context.Context, userID uuid.UUID, banned bool) error {
+	// GoTrue takes a duration; a century is as good as forever
+	duration := "none"
+	if banned {
+		duration = "876000h"
+	}
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"apikey":        s.cfg.SupabaseKey,
+		"Authorization": "Bearer " + s.cfg.ServiceRoleKey,
+	}
+	return s.callAdminUsers(ctx, http.MethodPut, userID, map[string]string{"ban_duration": duration}, headers)
+}
+
+// PurgeUsers deletes up to maxPurgeUsers users moved to the trash before
+// cutoff for good: first their public.users row with everything that cascades
+// from it, then the auth.users account of each row it deleted. Accounts that
+// cannot be deleted stay banned and are reported in the error. It returns how
+// many users it deleted.
+func (s *UserService) PurgeUsers(ctx context.Context, cutoff time.Time) (int, error) {
+	var expired []models.User
+	query := From("users").Select("id").Lt("deleted_at", cutoff).Order("deleted_at", Ascending).Limit(maxPurgeUsers)
+	if err := s.callTable(ctx, http.MethodGet, query, nil, &expired); err != nil {
+		return 0, err
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+	ids := make([]interface{}, len(expired))
+	for i, user := range expired {
+		ids[i] = user.ID
+	}
+
+	// The deleted_at filter skips users restored since they were read, so only
+	// the accounts of rows that are really gone are deleted below
+	var purged []models.User
+	query = From("users").Select("id").In("id", ids...).Lt("deleted_at", cutoff)
+	if err := s.callTable(ctx, http.MethodDelete, query, nil, &purged); err != nil {
+		return 0, err
+	}
+
+	var errs []error
+	for _, user := range purged {
+		if err := s.DeleteAuthUser(ctx, user.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			errs = append(errs, fmt.Errorf("auth account of purged user %s: %w", user.ID, err))
+		}
+	}
+	return len(purged), errors.Join(errs...)
+}
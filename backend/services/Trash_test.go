@@ -0,0 +1,94 @@
+package services
+
+import (
+	"backend/config"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestDeleteGameMovesItToTheTrash(t *testing.T) {
+	gameID := uuid.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Query().Get("deleted_at") != "is.null" {
+			t.Errorf("expected a PATCH of a game outside the trash, got %s %s", r.Method, r.URL)
+		}
+		var patch map[string]*string
+		json.NewDecoder(r.Body).Decode(&patch)
+		if patch["deleted_at"] == nil {
+			t.Errorf("expected deleted_at to be set, got %v", patch)
+		}
+		w.Write([]byte(`[{"id":"` + gameID.String() + `","deleted_at":"2026-10-19T12:00:00Z"}]`))
+	}))
+	defer server.Close()
+
+	service := NewGameService(NewSupabaseClient(config.Config{SupabaseURL: server.URL}))
+	if err := service.DeleteGameByID(context.Background(), gameID, uuid.New(), nil); err != nil {
+		t.Errorf("DeleteGameByID failed: %v", err)
+	}
+}
+
+func TestPurgeUsersSkipsUsersRestoredDuringThePurge(t *testing.T) {
+	restored, purged := uuid.New(), uuid.New()
+	cutoff := time.Date(2026, time.September, 19, 0, 0, 0, 0, time.UTC)
+	var deletedAccounts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/auth/v1/admin/users/"):
+			if r.Method != http.MethodDelete {
+				t.Errorf("expected the account to be deleted, got %s", r.Method)
+			}
+			deletedAccounts = append(deletedAccounts, strings.TrimPrefix(r.URL.Path, "/auth/v1/admin/users/"))
+			w.WriteHeader(http.StatusOK)
+		case r.Method == http.MethodGet:
+			if r.URL.Query().Get("deleted_at") != "lt.2026-09-19T00:00:00Z" {
+				t.Errorf("expected users trashed before the cutoff, got %s", r.URL)
+			}
+			w.Write([]byte(`[{"id":"` + restored.String() + `"},{"id":"` + purged.String() + `"}]`))
+		case r.Method == http.MethodDelete:
+			if r.URL.Query().Get("deleted_at") != "lt.2026-09-19T00:00:00Z" {
+				t.Errorf("expected the delete to recheck the cutoff, got %s", r.URL)
+			}
+			// An administrator restored the first user after it was read
+			w.Write([]byte(`[{"id":"` + purged.String() + `"}]`))
+		}
+	}))
+	defer server.Close()
+
+	service := NewUserService(NewSupabaseClient(config.Config{SupabaseURL: server.URL}))
+	n, err := service.PurgeUsers(context.Background(), cutoff)
+	if n != 1 || err != nil {
+		t.Errorf("expected 1 user purged, got %d %v", n, err)
+	}
+	if len(deletedAccounts) != 1 || deletedAccounts[0] != purged.String() {
+		t.Errorf("expected only the account of the purged user to be deleted, got %v", deletedAccounts)
+	}
+}
+
+func TestPurgeUsersReportsAccountsItCannotDelete(t *testing.T) {
+	failing, purged := uuid.New(), uuid.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/auth/v1/admin/users/"+failing.String()):
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"msg":"cannot delete"}`))
+		case strings.HasPrefix(r.URL.Path, "/auth/v1/admin/users/"):
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.Write([]byte(`[{"id":"` + failing.String() + `"},{"id":"` + purged.String() + `"}]`))
+		}
+	}))
+	defer server.Close()
+
+	service := NewUserService(NewSupabaseClient(config.Config{SupabaseURL: server.URL}))
+	n, err := service.PurgeUsers(context.Background(), time.Now())
+	if n != 2 || err == nil || !strings.Contains(err.Error(), failing.String()) {
+		t.Errorf("expected 2 users purged and an error for one account, got %d %v", n, err)
+	}
+}
@@ -1,36 +1,203 @@
 package services
 
 import (
+	"backend/metrics"
 	"backend/models"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"os"
+
+	"github.com/google/uuid"
 )
 
-type UserService struct{}
+// UserService manages accounts in Supabase auth and the public.users table
+type UserService struct {
+	*SupabaseClient
+}
+
+// NewUserService creates a UserService that calls Supabase through client
+func NewUserService(client *SupabaseClient) *UserService {
+	return &UserService{client}
+}
+
+func (s *UserService) CreateUser(ctx context.Context, email, password string) (models.SupabaseUser, error) {
+	cfg := s.cfg
+	authURL := fmt.Sprintf("%s/auth/v1/signup", cfg.SupabaseURL)
+	headers := map[string]string{
+		"Content-Type": "application/json",
+		"apikey":       cfg.SupabaseKey,
+	}
+
+	payload := map[string]string{"email": email, "password": password}
+	resp, err := s.call(ctx, http.MethodPost, authURL, payload, headers)
+	if err != nil {
+		return models.SupabaseUser{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return models.SupabaseUser{}, err
+	}
+	if err := checkResponse("gotrue", resp, body); err != nil {
+		return models.SupabaseUser{}, err
+	}
+
+	// GoTrue wraps the user in a session when email confirmation is disabled
+	// and returns it bare when a confirmation email is sent instead
+	var supabaseResp struct {
+		models.SupabaseUser
+		User *models.SupabaseUser `json:"user"`
+	}
+	if err := json.Unmarshal(body, &supabaseResp); err != nil {
+		return models.SupabaseUser{}, fmt.Errorf("failed to decode signup response: %w", err)
+	}
+	user := supabaseResp.SupabaseUser
+	if supabaseResp.User != nil {
+		user = *supabaseResp.User
+	}
+	if user.ID == uuid.Nil {
+		return models.SupabaseUser{}, fmt.Errorf("signup returned no user: %w", ErrUpstream)
+	}
+
+	metrics.Signups.Inc()
+	return user, nil
+}
+
+// GetUserByID retrieves a single row from public.users
+func (s *UserService) GetUserByID(ctx context.Context, userID uuid.UUID) (models.User, error) {
+	var users []models.User
+	query := live(From("users").Select("*").Eq("id", userID))
+	if err := s.callTable(ctx, http.MethodGet, query, nil, &users); err != nil {
+		return models.User{}, err
+	}
+	if len(users) == 0 {
+		return models.User{}, notFound("user", userID.String())
+	}
+	return users[0], nil
+}
+
+// ListUsers retrieves one page of public.users for administrators
+func (s *UserService) ListUsers(ctx context.Context, opts ListOptions) (Page[models.User], error) {
+	return listPage[models.User](ctx, s.SupabaseClient, func() *Query {
+		return live(From("users"))
+	}, opts)
+}
+
+// GetUsersByIDs retrieves the rows of public.users with the given IDs. IDs of missing and trashed users are left out.
+func (s *UserService) GetUsersByIDs(ctx context.Context, ids []uuid.UUID) ([]models.User, error) {
+	return selectByIDs[models.User](ctx, s.SupabaseClient, func() *Query {
+		return live(From("users"))
+	}, "id", ids)
+}
+
+// UpdateUser patches a row in public.users and returns the updated row. With
+// ifMatch it only updates a row at one of those versions and fails with
+// ErrPreconditionFailed for a row at another version.
+func (s *UserService) UpdateUser(ctx context.Context, userID uuid.UUID, updates map[string]interface{}, ifMatch []int64) (models.User, error) {
+	var updatedUsers []models.User
+	query := matchVersions(live(From("users").Eq("id", userID)), ifMatch)
+	if err := s.callTable(ctx, http.MethodPatch, query, updates, &updatedUsers); err != nil {
+		return models.User{}, err
+	}
+	if len(updatedUsers) == 0 {
+		return models.User{}, s.missedUserWrite(ctx, userID, ifMatch)
+	}
+	return updatedUsers[0], nil
+}
+
+// DeleteUser moves a row of public.users to the trash. ifMatch makes the
+// delete conditional like in UpdateUser.
+func (s *UserService) DeleteUser(ctx context.Context, userID uuid.UUID, ifMatch []int64) error {
+	var deletedUsers []models.User
+	query := matchVersions(live(From("users").Eq("id", userID)), ifMatch)
+	if err := s.callTable(ctx, http.MethodPatch, query, trashPatch(), &deletedUsers); err != nil {
+		return err
+	}
+	if len(deletedUsers) == 0 {
+		return s.missedUserWrite(ctx, userID, ifMatch)
+	}
+	return nil
+}
+
+// missedUserWrite explains a conditional write that matched no user
+func (s *UserService) missedUserWrite(ctx context.Context, userID uuid.UUID, ifMatch []int64) error {
+	return missedWrite("user", userID, ifMatch, func() (int64, error) {
+		user, err := s.GetUserByID(ctx, userID)
+		return user.Version, err
+	})
+}
+
+// ErrInvalidCredentials is returned by Login when Supabase rejects the email and password
+var ErrInvalidCredentials = fmt.Errorf("invalid credentials: %w", ErrUnauthorized)
+
+// Login exchanges an email and password for a Supabase session
+func (s *UserService) Login(ctx context.Context, email, password string) (models.Session, error) {
+	authURL := fmt.Sprintf("%s/auth/v1/token?grant_type=password", s.cfg.SupabaseURL)
+	headers := map[string]string{
+		"Content-Type": "application/json",
+		"apikey":       s.cfg.SupabaseKey,
+	}
+	payload := map[string]string{"email": email, "password": password}
+
+	var session models.Session
+	resp, err := s.call(ctx, http.MethodPost, authURL, payload, headers)
+	if err != nil {
+		return session, err
+	}
+	defer resp.Body.Close()
 
-func NewUserService() *UserService {
-    return &UserService{}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return session, err
+	}
+	// Unknown emails, wrong passwords and malformed input are all reported alike
+	if err := checkResponse("gotrue", resp, body); err != nil {
+		if errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrValidation) {
+			return session, ErrInvalidCredentials
+		}
+		return session, err
+	}
+	if err := json.Unmarshal(body, &session); err != nil {
+		return session, fmt.Errorf("failed to decode session: %w", err)
+	}
+	return session, nil
 }
 
-func (s *UserService) CreateUser(email, password string) (models.SupabaseUser, error) {
-    authURL := fmt.Sprintf("%s/auth/v1/signup", os.Getenv("SUPABASE_URL"))
-    headers := map[string]string{
-        "Content-Type": "application/json",
-        "apikey":       os.Getenv("SUPABASE_KEY"),
-    }
+// UpdateAuthEmail changes the email of the auth.users account with the admin API
+func (s *UserService) UpdateAuthEmail(ctx context.Context, userID uuid.UUID, email string) error {
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"apikey":        s.cfg.SupabaseKey,
+		"Authorization": "Bearer " + s.cfg.ServiceRoleKey,
+	}
+	return s.callAdminUsers(ctx, http.MethodPut, userID, map[string]string{"email": email}, headers)
+}
+
+// DeleteAuthUser removes the auth.users account with the admin API
+func (s *UserService) DeleteAuthUser(ctx context.Context, userID uuid.UUID) error {
+	headers := map[string]string{
+		"apikey":        s.cfg.ServiceRoleKey,
+		"Authorization": "Bearer " + s.cfg.ServiceRoleKey,
+	}
+	return s.callAdminUsers(ctx, http.MethodDelete, userID, nil, headers)
+}
 
-    payload := map[string]string{"email": email, "password": password}
-    resp, err := callSupabaseAPI(http.MethodPost, authURL, payload, headers)
-    if err != nil {
-        return models.SupabaseUser{}, err
-    }
-    defer resp.Body.Close()
+// callAdminUsers sends a request to the GoTrue admin endpoint of one user
+func (s *UserService) callAdminUsers(ctx context.Context, method string, userID uuid.UUID, payload interface{}, headers map[string]string) error {
+	adminURL := fmt.Sprintf("%s/auth/v1/admin/users/%s", s.cfg.SupabaseURL, userID)
+	resp, err := s.call(ctx, method, adminURL, payload, headers)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
 
-    var supabaseResp struct {
-        User models.SupabaseUser `json:"user"`
-    }
-    json.NewDecoder(resp.Body).Decode(&supabaseResp)
-    return supabaseResp.User, nil
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return checkResponse("gotrue", resp, body)
 }
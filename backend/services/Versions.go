@@ -0,0 +1,39 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// matchVersions narrows q to rows at one of versions. Conditional writes put
+// the check into the statement itself, so no other write can slip in between
+// checking the version and writing. Without versions q is left unchanged.
+func matchVersions(q *Query, versions []int64) *Query {
+	switch len(versions) {
+	case 0:
+		return q
+	case 1:
+		return q.Eq("version", versions[0])
+	}
+	values := make([]interface{}, len(versions))
+	for i, v := range versions {
+		values[i] = v
+	}
+	return q.In("version", values...)
+}
+
+// missedWrite explains why a write matched no row. Without versions the row
+// is missing; otherwise current reads the row's version to tell a missing row
+// from one that has changed since the caller read it.
+func missedWrite(resource string, id uuid.UUID, versions []int64, current func() (int64, error)) error {
+	if len(versions) == 0 {
+		return notFound(resource, id.String())
+	}
+	version, err := current()
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("%s %s is at version %d: %w", resource, strconv.Quote(id.String()), version, ErrPreconditionFailed)
+}
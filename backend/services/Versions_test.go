@@ -0,0 +1,53 @@
+package services
+
+import (
+	"backend/config"
+	"backend/models"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestConditionalUpdateFiltersOnVersion(t *testing.T) {
+	gameID, userID := uuid.New(), uuid.New()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPatch:
+			if got := r.URL.Query().Get("version"); got != "eq.3" {
+				t.Errorf("expected the update to be filtered on version 3, got %q", got)
+			}
+			w.Write([]byte(`[]`))
+		case http.MethodGet:
+			w.Write([]byte(`[{"id":"` + gameID.String() + `","title":"Verbs","version":4}]`))
+		}
+	}))
+	defer server.Close()
+
+	service := NewGameService(NewSupabaseClient(config.Config{SupabaseURL: server.URL}))
+	_, err := service.UpdateGameByID(context.Background(), gameID, userID, models.GameRequest{Title: "Nouns"}, []int64{3})
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Errorf("expected ErrPreconditionFailed for a game at another version, got %v", err)
+	}
+}
+
+func TestConditionalDeleteOfMissingRowIsNotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			if got := r.URL.Query().Get("version"); got != `in.("1","2")` {
+				t.Errorf("expected the delete to be filtered on versions 1 and 2, got %q", got)
+			}
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+
+	service := NewUserService(NewSupabaseClient(config.Config{SupabaseURL: server.URL}))
+	err := service.DeleteUser(context.Background(), uuid.New(), []int64{1, 2})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing user, got %v", err)
+	}
+}
@@ -1,22 +1,121 @@
 package utils
 
 import (
+	"backend/logging"
 	"encoding/json"
 	"net/http"
 )
 
-type ErrorResponse struct {
-	Error string `json:"error"`
+// ProblemContentType is the media type of RFC 7807 error responses
+const ProblemContentType = "application/problem+json"
+
+// problemTypeBase prefixes the error code to form the problem type URI
+const problemTypeBase = "/problems/"
+
+// Stable machine-readable error codes. Clients may switch on these, so
+// existing codes must not be renamed.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeInvalidBody          = "invalid_body"
+	CodeBodyTooLarge         = "body_too_large"
+	CodeValidationFailed     = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeInvalidToken         = "invalid_token"
+	CodeInvalidCredentials   = "invalid_credentials"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeRouteNotFound        = "route_not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeUnknownVersion       = "unknown_api_version"
+	CodeInvalidQuery         = "invalid_query"
+	CodeQueryTooComplex      = "query_too_complex"
+	CodeConflict             = "conflict"
+	CodePreconditionFailed   = "precondition_failed"
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodeRequestInFlight      = "request_in_flight"
+	CodeUpstreamError        = "upstream_error"
+	CodeUnavailable          = "service_unavailable"
+	CodeInternal             = "internal_error"
+)
+
+// Problem is an RFC 7807 problem details object with an error code and field errors as extensions
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	// Extensions are further members of the problem, written next to the standard ones
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// FieldError describes one invalid field of a request
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewProblem creates a problem whose type and title are derived from code and status
+func NewProblem(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   problemTypeBase + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// WithFieldErrors attaches per-field validation errors
+func (p *Problem) WithFieldErrors(errs ...FieldError) *Problem {
+	p.Errors = append(p.Errors, errs...)
+	return p
 }
 
-// writeError writes a standardized error response to the client
-func WriteError(w http.ResponseWriter, statusCode int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
+// WithExtension adds the member name to the problem
+func (p *Problem) WithExtension(name string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+	p.Extensions[name] = value
+	return p
+}
+
+// MarshalJSON writes the standard members followed by the extensions
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type standard Problem
+	body, err := json.Marshal(standard(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return body, err
+	}
+	extensions, err := json.Marshal(p.Extensions)
+	if err != nil {
+		return nil, err
+	}
+	// Join {"type":...} and {"name":...} into one object
+	return append(append(body[:len(body)-1], ','), extensions[1:]...), nil
+}
 
-	response := ErrorResponse{
-		Error: message,
+// WriteProblem writes p as application/problem+json, filling in the request path and ID
+func WriteProblem(w http.ResponseWriter, r *http.Request, p *Problem) {
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	if p.RequestID == "" {
+		p.RequestID = logging.RequestID(r.Context())
 	}
 
-	json.NewEncoder(w).Encode(response)
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// WriteError writes a problem response with the given status, error code and detail
+func WriteError(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	WriteProblem(w, r, NewProblem(status, code, detail))
 }
@@ -0,0 +1,25 @@
+package validation
+
+import "reflect"
+
+// FieldRules describes the constraints of one field in a form clients can
+// apply before sending a request
+type FieldRules struct {
+	Field    string   `json:"field"`
+	Type     string   `json:"type"` // string, integer, number, boolean or array
+	Required bool     `json:"required"`
+	Min      *int64   `json:"min,omitempty"` // length for strings and arrays, value for numbers
+	Max      *int64   `json:"max,omitempty"`
+	Format   string   `json:"format,omitempty"` // email or uuid
+	Enum     []string `json:"enum,omitempty"`
+}
+
+// Describe returns the rules of every validated field of the struct v, in field order
+func Describe(v any) []FieldRules {
+	fields := fieldsOf(reflect.Indirect(reflect.ValueOf(v)).Type())
+	rules := make([]FieldRules, len(fields))
+	for i, f := range fields {
+		rules[i] = f.rules
+	}
+	return rules
+}
@@ -0,0 +1,266 @@
+package validation
+
+import (
+	"backend/utils"
+	"encoding"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Field error codes are the names of the rules that failed
+const (
+	RuleRequired = "required"
+	RuleMin      = "min"
+	RuleMax      = "max"
+	RuleEmail    = "email"
+	RuleUUID     = "uuid"
+	RuleOneOf    = "oneof"
+)
+
+// enums are the value lists registered with RegisterEnum, by rule name
+var enums sync.Map // string -> []string
+
+// RegisterEnum adds the rule name, which accepts the same values as oneof
+// with values and fails with the oneof code. It lets a list kept in code, such
+// as the search languages, be used in tags without repeating it. Register the
+// rule before validating any struct that uses it, e.g. in an init function.
+func RegisterEnum(name string, values []string) {
+	switch name {
+	case RuleRequired, RuleMin, RuleMax, RuleEmail, RuleUUID, RuleOneOf, "":
+		panic(fmt.Sprintf("validation: %q is a built-in rule", name))
+	}
+	enums.Store(name, values)
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Errors lists every invalid field of a value
+type Errors []utils.FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Struct checks every `validate` tag of the struct v and returns Errors when a rule fails.
+//
+// Tags are comma separated rules: required, min=N, max=N, email, uuid and
+// oneof=a b c, plus the rules added with RegisterEnum. min and max bound the length of strings and slices and the value
+// of numbers. Rules other than required are skipped for empty fields.
+func Struct(v any) error {
+	return check(v, false)
+}
+
+// Partial is Struct for updates: empty fields are left out of the update, so they are not required
+func Partial(v any) error {
+	return check(v, true)
+}
+
+func check(v any, partial bool) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	var errs Errors
+	for _, f := range fieldsOf(value.Type()) {
+		if fe, ok := f.check(value.Field(f.index), partial); !ok {
+			errs = append(errs, fe)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// field is one validated struct field with its parsed rules
+type field struct {
+	index int
+	rules FieldRules
+}
+
+var cache sync.Map // reflect.Type -> []field
+
+// fieldsOf parses the validate tags of t once. A malformed tag is a programming error and panics.
+func fieldsOf(t reflect.Type) []field {
+	if cached, ok := cache.Load(t); ok {
+		return cached.([]field)
+	}
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation: %s is not a struct", t))
+	}
+
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("validate")
+		if !ok || !sf.IsExported() {
+			continue
+		}
+		rules, err := parseRules(jsonName(sf), sf.Type, tag)
+		if err != nil {
+			panic(fmt.Sprintf("validation: %s.%s: %v", t.Name(), sf.Name, err))
+		}
+		fields = append(fields, field{index: i, rules: rules})
+	}
+	cache.Store(t, fields)
+	return fields
+}
+
+func jsonName(sf reflect.StructField) string {
+	name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return sf.Name
+	}
+	return name
+}
+
+func parseRules(name string, t reflect.Type, tag string) (FieldRules, error) {
+	rules := FieldRules{Field: name, Type: typeName(t)}
+	if rules.Type == "" {
+		return rules, fmt.Errorf("unsupported type %s", t)
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		key, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch key {
+		case RuleRequired:
+			rules.Required = true
+		case RuleMin, RuleMax:
+			n, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				return rules, fmt.Errorf("%s needs an integer argument, got %q", key, arg)
+			}
+			if key == RuleMin {
+				rules.Min = &n
+			} else {
+				rules.Max = &n
+			}
+		case RuleEmail, RuleUUID:
+			if rules.Type != "string" {
+				return rules, fmt.Errorf("%s only applies to strings", key)
+			}
+			rules.Format = key
+		case RuleOneOf:
+			if rules.Type != "string" || arg == "" {
+				return rules, fmt.Errorf("oneof needs string values")
+			}
+			rules.Enum = strings.Fields(arg)
+		case "":
+		default:
+			values, ok := enums.Load(key)
+			if !ok || arg != "" {
+				return rules, fmt.Errorf("unknown rule %q", key)
+			}
+			if rules.Type != "string" {
+				return rules, fmt.Errorf("%s only applies to strings", key)
+			}
+			rules.Enum = values.([]string)
+		}
+	}
+	return rules, nil
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+func typeName(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	// Types such as uuid.UUID are strings on the wire and check their own format when decoded
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice:
+		return "array"
+	default:
+		return ""
+	}
+}
+
+func (f field) check(v reflect.Value, partial bool) (utils.FieldError, bool) {
+	r := f.rules
+	fail := func(code, format string, args ...any) (utils.FieldError, bool) {
+		return utils.FieldError{Field: r.Field, Code: code, Message: fmt.Sprintf(format, args...)}, false
+	}
+
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if r.Required && !partial {
+				return fail(RuleRequired, "is required")
+			}
+			return utils.FieldError{}, true
+		}
+		v = v.Elem()
+	}
+
+	// A blank string counts as missing, but only an omitted one may be left out of an update
+	if v.IsZero() || (v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "") {
+		if r.Required && (!partial || !v.IsZero()) {
+			return fail(RuleRequired, "is required")
+		}
+		return utils.FieldError{}, true
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		n := int64(utf8.RuneCountInString(s))
+		if r.Min != nil && n < *r.Min {
+			return fail(RuleMin, "must be at least %d characters", *r.Min)
+		}
+		if r.Max != nil && n > *r.Max {
+			return fail(RuleMax, "must be at most %d characters", *r.Max)
+		}
+		if r.Format == RuleEmail && !isEmail(s) {
+			return fail(RuleEmail, "must be a valid email address")
+		}
+		if r.Format == RuleUUID && !uuidPattern.MatchString(s) {
+			return fail(RuleUUID, "must be a UUID")
+		}
+		if r.Enum != nil && !slices.Contains(r.Enum, s) {
+			return fail(RuleOneOf, "must be one of %s", strings.Join(r.Enum, ", "))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n := v.Int(); r.Min != nil && n < *r.Min {
+			return fail(RuleMin, "must be at least %d", *r.Min)
+		} else if r.Max != nil && n > *r.Max {
+			return fail(RuleMax, "must be at most %d", *r.Max)
+		}
+	case reflect.Float32, reflect.Float64:
+		if n := v.Float(); r.Min != nil && n < float64(*r.Min) {
+			return fail(RuleMin, "must be at least %d", *r.Min)
+		} else if r.Max != nil && n > float64(*r.Max) {
+			return fail(RuleMax, "must be at most %d", *r.Max)
+		}
+	case reflect.Slice:
+		if n := int64(v.Len()); r.Min != nil && n < *r.Min {
+			return fail(RuleMin, "must have at least %d items", *r.Min)
+		} else if r.Max != nil && n > *r.Max {
+			return fail(RuleMax, "must have at most %d items", *r.Max)
+		}
+	}
+	return utils.FieldError{}, true
+}
+
+// isEmail accepts a bare address such as ada@example.com, without a display name
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Name == "" && addr.Address == s && strings.Contains(s[strings.LastIndex(s, "@"):], ".")
+}
@@ -0,0 +1,110 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+)
+
+type signup struct {
+	Email    string   `json:"email" validate:"required,email,max=254"`
+	Password string   `json:"password" validate:"required,min=6"`
+	Role     string   `json:"role,omitempty" validate:"oneof=viewer admin"`
+	Level    int      `json:"level,omitempty" validate:"min=1,max=5"`
+	TeamID   *string  `json:"team_id,omitempty" validate:"uuid"`
+	Tags     []string `json:"tags,omitempty" validate:"max=2"`
+	Notes    string   `json:"notes"`
+}
+
+func TestStructReportsEveryField(t *testing.T) {
+	team := "not-a-uuid"
+	err := Struct(signup{
+		Email:    "ada@",
+		Password: "   ",
+		Role:     "owner",
+		Level:    6,
+		TeamID:   &team,
+		Tags:     []string{"a", "b", "c"},
+	})
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected Errors, got %v", err)
+	}
+	want := map[string]string{
+		"email":    RuleEmail,
+		"password": RuleRequired,
+		"role":     RuleOneOf,
+		"level":    RuleMax,
+		"team_id":  RuleUUID,
+		"tags":     RuleMax,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d field errors, got %+v", len(want), errs)
+	}
+	for _, fe := range errs {
+		if want[fe.Field] != fe.Code {
+			t.Errorf("%s: expected rule %q, got %q (%s)", fe.Field, want[fe.Field], fe.Code, fe.Message)
+		}
+	}
+}
+
+func TestStructAcceptsValidValues(t *testing.T) {
+	team := "5803acaf-821a-4463-b8b4-15ac6e0e466a"
+	if err := Struct(&signup{Email: "ada@example.com", Password: "secret", Role: "admin", Level: 3, TeamID: &team}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestPartialSkipsOmittedFields(t *testing.T) {
+	if err := Partial(signup{Level: 2}); err != nil {
+		t.Errorf("expected omitted fields to pass, got %v", err)
+	}
+
+	var errs Errors
+	if !errors.As(Partial(signup{Password: " ", Level: 9}), &errs) || len(errs) != 2 {
+		t.Errorf("expected a blank password and the level to fail, got %v", errs)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	rules := Describe(signup{})
+	if len(rules) != 6 {
+		t.Fatalf("expected rules for the 6 tagged fields, got %+v", rules)
+	}
+	email, level := rules[0], rules[3]
+	if email.Field != "email" || !email.Required || email.Format != RuleEmail || *email.Max != 254 {
+		t.Errorf("unexpected email rules %+v", email)
+	}
+	if level.Type != "integer" || level.Required || *level.Min != 1 || *level.Max != 5 {
+		t.Errorf("unexpected level rules %+v", level)
+	}
+}
+
+func TestMalformedTagPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an unknown rule")
+		}
+	}()
+	Struct(struct {
+		Name string `validate:"requird"`
+	}{})
+}
+
+func TestRegisteredEnum(t *testing.T) {
+	RegisterEnum("test_color", []string{"red", "green"})
+	type paint struct {
+		Color string `json:"color" validate:"required,test_color"`
+	}
+
+	var errs Errors
+	if err := Struct(paint{Color: "blue"}); !errors.As(err, &errs) || errs[0].Code != RuleOneOf {
+		t.Errorf("expected a oneof error for an unregistered value, got %v", err)
+	}
+	if err := Struct(paint{Color: "green"}); err != nil {
+		t.Errorf("expected a registered value to pass, got %v", err)
+	}
+	if rules := Describe(paint{}); len(rules[0].Enum) != 2 {
+		t.Errorf("expected the registered values to be described, got %+v", rules[0])
+	}
+}